@@ -0,0 +1,128 @@
+package quick
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+)
+
+// defaultBatchRequests is the number of sub-requests a batch may hold
+// when Batch is given no limit
+const defaultBatchRequests = 20
+
+// BatchRequest is one sub-request of a batch
+type BatchRequest struct {
+	Method  string            `json:"method"` // default GET
+	Path    string            `json:"path"`   // path and query, e.g. /users/1?fields=name
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"` // sent as application/json
+}
+
+// BatchResponse is the response to one sub-request of a batch
+type BatchResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"` // JSON bodies as they are, others as a JSON string
+}
+
+// Batch returns a handler that runs a JSON array of sub-requests through
+// the router, one after the other, and answers with the array of their
+// responses in the same order, so clients can send several API calls in
+// one HTTP request:
+//
+//	q.Post("/batch", q.Batch(10))
+//
+//	// [{"method":"GET","path":"/users/1"},{"method":"POST","path":"/users","body":{"name":"jeff"}}]
+//	// => [{"status":200,"headers":{...},"body":{...}},{"status":201,...}]
+//
+// Sub-requests go through the middlewares of their route and inherit the
+// headers of the batch, e.g. Authorization, which their own headers
+// override. A batch holds at most maxRequests sub-requests (20 when 0),
+// and batches cannot be nested.
+// The result will Batch(maxRequests int) HandleFunc
+func (q *Quick) Batch(maxRequests int) HandleFunc {
+	if maxRequests <= 0 {
+		maxRequests = defaultBatchRequests
+	}
+	return func(c *Ctx) error {
+		if c.Request.Context().Value(batchKey) != nil {
+			return c.Status(StatusBadRequest).JSON(map[string]string{"error": "batches cannot be nested"})
+		}
+
+		body := c.Body()
+		var reqs []BatchRequest
+		if err := json.Unmarshal(body, &reqs); err != nil {
+			return malformedBodyError(err, len(body))
+		}
+		if len(reqs) > maxRequests {
+			return c.Status(StatusBadRequest).JSON(map[string]string{
+				"error": fmt.Sprintf("batch of %d requests exceeds the limit of %d", len(reqs), maxRequests),
+			})
+		}
+
+		ctx := context.WithValue(c.Context(), batchKey, true)
+		res := make([]BatchResponse, len(reqs))
+		for i, sub := range reqs {
+			res[i] = q.serveBatch(ctx, c.Request, sub)
+		}
+		return c.Status(StatusOK).JSON(res)
+	}
+}
+
+// serveBatch runs one sub-request of a batch through the router
+// Method Used Internally
+// The result will serveBatch(ctx context.Context, parent *http.Request, sub BatchRequest) BatchResponse
+func (q *Quick) serveBatch(ctx context.Context, parent *http.Request, sub BatchRequest) BatchResponse {
+	method := strings.ToUpper(strings.TrimSpace(sub.Method))
+	if method == "" {
+		method = MethodGet
+	}
+	if !strings.HasPrefix(sub.Path, "/") {
+		return batchError(fmt.Sprintf("invalid path %q", sub.Path))
+	}
+	req, err := http.NewRequestWithContext(ctx, method, sub.Path, bytes.NewReader(sub.Body))
+	if err != nil {
+		return batchError(err.Error())
+	}
+
+	req.Header = parent.Header.Clone()
+	req.Header.Del("Content-Length")
+	req.Header.Del("Content-Type")
+	if len(sub.Body) > 0 {
+		req.Header.Set("Content-Type", ContentTypeAppJSON)
+	}
+	for k, v := range sub.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Host = parent.Host
+	req.RemoteAddr = parent.RemoteAddr
+	req.TLS = parent.TLS
+
+	rec := httptest.NewRecorder()
+	q.ServeHTTP(rec, req)
+
+	out := BatchResponse{Status: rec.Code, Headers: make(map[string]string, len(rec.Header()))}
+	for k := range rec.Header() {
+		out.Headers[k] = rec.Header().Get(k)
+	}
+	if b := bytes.TrimSpace(rec.Body.Bytes()); len(b) > 0 {
+		if json.Valid(b) {
+			out.Body = b
+		} else {
+			out.Body, _ = json.Marshal(rec.Body.String())
+		}
+	}
+	return out
+}
+
+// batchError is the response to a sub-request that cannot be sent
+// Method Used Internally
+// The result will batchError(msg string) BatchResponse
+func batchError(msg string) BatchResponse {
+	body, _ := json.Marshal(map[string]string{"error": msg})
+	return BatchResponse{Status: StatusBadRequest, Body: body}
+}
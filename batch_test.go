@@ -0,0 +1,86 @@
+package quick
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestQuickBatch(t *testing.T) {
+	q := New(Config{MaxBodySize: 1 << 20})
+	q.Get("/users/:id", func(c *Ctx) error {
+		return c.Status(StatusOK).JSON(map[string]string{"id": c.Param("id"), "auth": c.Request.Header.Get("Authorization")})
+	})
+	q.Post("/users", func(c *Ctx) error {
+		var user struct{ Name string }
+		if err := c.BodyParser(&user); err != nil {
+			return err
+		}
+		c.Set("Location", "/users/"+user.Name)
+		return c.Status(StatusCreated).JSON(user)
+	})
+	q.Get("/text", func(c *Ctx) error {
+		return c.Status(StatusOK).SendString("plain")
+	})
+	q.Post("/batch", q.Batch(0))
+
+	batch := `[
+		{"method":"GET","path":"/users/1"},
+		{"path":"/users/2","headers":{"Authorization":"Bearer other"}},
+		{"method":"POST","path":"/users","body":{"Name":"jeff"}},
+		{"method":"GET","path":"/text"},
+		{"method":"GET","path":"/missing"},
+		{"method":"GET","path":"users"},
+		{"method":"POST","path":"/batch","body":[]}
+	]`
+	req := httptest.NewRequest(MethodPost, "/batch", strings.NewReader(batch))
+	req.Header.Set("Authorization", "Bearer token")
+	rec := httptest.NewRecorder()
+	q.ServeHTTP(rec, req)
+	if rec.Code != StatusOK {
+		t.Fatalf("expected 200, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	var res []BatchResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		status int
+		body   string
+	}{
+		{StatusOK, `{"auth":"Bearer token","id":"1"}`},
+		{StatusOK, `{"auth":"Bearer other","id":"2"}`},
+		{StatusCreated, `{"Name":"jeff"}`},
+		{StatusOK, `"plain"`},
+		{StatusNotFound, `"404 page not found\n"`},
+		{StatusBadRequest, `{"error":"invalid path \"users\""}`},
+		{StatusBadRequest, `{"error":"batches cannot be nested"}`},
+	}
+	if len(res) != len(want) {
+		t.Fatalf("expected %d responses, got %s", len(want), rec.Body.String())
+	}
+	for i, w := range want {
+		if res[i].Status != w.status || string(res[i].Body) != w.body {
+			t.Errorf("%d: expected %d %s, got %d %s", i, w.status, w.body, res[i].Status, res[i].Body)
+		}
+	}
+	if res[2].Headers["Location"] != "/users/jeff" {
+		t.Errorf("expected the sub-response headers, got %v", res[2].Headers)
+	}
+}
+
+func TestQuickBatchInvalid(t *testing.T) {
+	q := New(Config{MaxBodySize: 1 << 20})
+	q.Get("/", func(c *Ctx) error { return c.Status(StatusOK).SendString("ok") })
+	q.Post("/batch", q.Batch(2))
+
+	for _, body := range []string{`{"method":"GET"}`, `[{"path":"/"},{"path":"/"},{"path":"/"}]`} {
+		rec := httptest.NewRecorder()
+		q.ServeHTTP(rec, httptest.NewRequest(MethodPost, "/batch", strings.NewReader(body)))
+		if rec.Code != StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d %s", body, rec.Code, rec.Body.String())
+		}
+	}
+}
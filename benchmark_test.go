@@ -2,6 +2,7 @@ package quick
 
 import (
 	"fmt"
+	"net/http/httptest"
 	"os"
 	"testing"
 )
@@ -43,3 +44,40 @@ func BenchmarkWriteToStdout_1000Bytes(b *testing.B) {
 func BenchmarkPrintln_1000Bytes(b *testing.B) {
 	benchmarkPrintln(b, 1000)
 }
+
+// benchmarkRouterLookup registers n routes and measures the lookup of the last one
+func benchmarkRouterLookup(b *testing.B, n int) {
+	q := New()
+	for i := 0; i < n; i++ {
+		q.Get(fmt.Sprintf("/api/v1/resource%d/:id", i), func(c *Ctx) error { return nil })
+	}
+	path := fmt.Sprintf("/api/v1/resource%d/42", n-1)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		q.router.lookup(MethodGet, path)
+	}
+}
+
+func BenchmarkRouterLookup_10Routes(b *testing.B) {
+	benchmarkRouterLookup(b, 10)
+}
+
+func BenchmarkRouterLookup_500Routes(b *testing.B) {
+	benchmarkRouterLookup(b, 500)
+}
+
+// BenchmarkServeHTTPParams measures allocations of a request hitting a dynamic route
+func BenchmarkServeHTTPParams(b *testing.B) {
+	q := New()
+	q.Get("/a/:x/:y", func(c *Ctx) error { return nil })
+	req := httptest.NewRequest(MethodGet, "/a/1/2", nil)
+	w := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		q.ServeHTTP(w, req)
+	}
+}
@@ -0,0 +1,150 @@
+package quick
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConfigFromEnv returns GetDefaultConfig() overridden by the QUICK_*
+// environment variables that are set and not empty, for 12-factor apps:
+//
+//	QUICK_BODY_LIMIT, QUICK_MAX_BODY_SIZE, QUICK_MAX_HEADER_BYTES,
+//	QUICK_MULTIPART_MEMORY_LIMIT   sizes: 1048576, 512KB, 10MB
+//	QUICK_ROUTE_CAPACITY, QUICK_MORE_REQUESTS, QUICK_MAX_ROUTE_PARAMS   integers
+//	QUICK_READ_TIMEOUT, QUICK_WRITE_TIMEOUT, QUICK_IDLE_TIMEOUT,
+//	QUICK_READ_HEADER_TIMEOUT, QUICK_REQUEST_TIMEOUT   durations: 500ms, 10s, 1m
+//	QUICK_ROUTE_CONFLICT_ERROR, QUICK_CLEAN_PATH, QUICK_REDIRECT_CLEAN_PATH   booleans: true, false, 1, 0
+//	QUICK_TRUSTED_PROXIES   comma separated IPs and CIDR ranges
+//
+// Invalid values are reported together in the error, naming each variable,
+// and leave the default in place, e.g.
+//
+//	cfg, err := quick.ConfigFromEnv()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	q := quick.New(cfg)
+//
+// The result will ConfigFromEnv() (Config, error)
+func ConfigFromEnv() (Config, error) {
+	cfg := GetDefaultConfig()
+	var errs []error
+
+	for _, v := range []struct {
+		name string
+		dst  *int64
+	}{
+		{"QUICK_BODY_LIMIT", &cfg.BodyLimit},
+		{"QUICK_MAX_BODY_SIZE", &cfg.MaxBodySize},
+		{"QUICK_MAX_HEADER_BYTES", &cfg.MaxHeaderBytes},
+		{"QUICK_MULTIPART_MEMORY_LIMIT", &cfg.MultipartMemoryLimit},
+	} {
+		if s, ok := lookupEnv(v.name); ok {
+			n, err := parseEnvSize(s)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("quick: %s: invalid size %q", v.name, s))
+				continue
+			}
+			*v.dst = n
+		}
+	}
+
+	for _, v := range []struct {
+		name string
+		dst  *int
+	}{
+		{"QUICK_ROUTE_CAPACITY", &cfg.RouteCapacity},
+		{"QUICK_MORE_REQUESTS", &cfg.MoreRequests},
+		{"QUICK_MAX_ROUTE_PARAMS", &cfg.MaxRouteParams},
+	} {
+		if s, ok := lookupEnv(v.name); ok {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 0 {
+				errs = append(errs, fmt.Errorf("quick: %s: invalid number %q", v.name, s))
+				continue
+			}
+			*v.dst = n
+		}
+	}
+
+	for _, v := range []struct {
+		name string
+		dst  *time.Duration
+	}{
+		{"QUICK_READ_TIMEOUT", &cfg.ReadTimeout},
+		{"QUICK_WRITE_TIMEOUT", &cfg.WriteTimeout},
+		{"QUICK_IDLE_TIMEOUT", &cfg.IdleTimeout},
+		{"QUICK_READ_HEADER_TIMEOUT", &cfg.ReadHeaderTimeout},
+		{"QUICK_REQUEST_TIMEOUT", &cfg.RequestTimeout},
+	} {
+		if s, ok := lookupEnv(v.name); ok {
+			d, err := time.ParseDuration(s)
+			if err != nil || d < 0 {
+				errs = append(errs, fmt.Errorf("quick: %s: invalid duration %q", v.name, s))
+				continue
+			}
+			*v.dst = d
+		}
+	}
+
+	for _, v := range []struct {
+		name string
+		dst  *bool
+	}{
+		{"QUICK_ROUTE_CONFLICT_ERROR", &cfg.RouteConflictError},
+		{"QUICK_CLEAN_PATH", &cfg.CleanPath},
+		{"QUICK_REDIRECT_CLEAN_PATH", &cfg.RedirectCleanPath},
+	} {
+		if s, ok := lookupEnv(v.name); ok {
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("quick: %s: invalid boolean %q", v.name, s))
+				continue
+			}
+			*v.dst = b
+		}
+	}
+
+	if s, ok := lookupEnv("QUICK_TRUSTED_PROXIES"); ok {
+		var proxies []string
+		for _, p := range strings.Split(s, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				proxies = append(proxies, p)
+			}
+		}
+		if _, err := parseTrustedProxies(proxies); err != nil {
+			errs = append(errs, fmt.Errorf("quick: QUICK_TRUSTED_PROXIES: %w", err))
+		} else {
+			cfg.TrustedProxies = proxies
+		}
+	}
+
+	return cfg, errors.Join(errs...)
+}
+
+// lookupEnv returns the trimmed value of the environment variable name,
+// reporting false when it is unset or empty
+// Method Used Internally
+// The result will lookupEnv(name string) (string, bool)
+func lookupEnv(name string) (string, bool) {
+	s := strings.TrimSpace(os.Getenv(name))
+	return s, s != ""
+}
+
+// parseEnvSize parses a size given in bytes, e.g. "1048576", or with a
+// unit, e.g. "10MB", as accepted by FormFileLimit
+// Method Used Internally
+// The result will parseEnvSize(s string) (int64, error)
+func parseEnvSize(s string) (int64, error) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if n < 0 {
+			return 0, errors.New("negative size")
+		}
+		return n, nil
+	}
+	return parseSize(s)
+}
@@ -0,0 +1,67 @@
+package quick
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("QUICK_BODY_LIMIT", "10MB")
+	t.Setenv("QUICK_MAX_BODY_SIZE", "4096")
+	t.Setenv("QUICK_MAX_HEADER_BYTES", " 64kb ")
+	t.Setenv("QUICK_ROUTE_CAPACITY", "50")
+	t.Setenv("QUICK_READ_TIMEOUT", "5s")
+	t.Setenv("QUICK_REQUEST_TIMEOUT", "1m")
+	t.Setenv("QUICK_CLEAN_PATH", "true")
+	t.Setenv("QUICK_TRUSTED_PROXIES", "10.0.0.0/8, 127.0.0.1")
+	t.Setenv("QUICK_WRITE_TIMEOUT", "")
+
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := GetDefaultConfig()
+	want.BodyLimit = 10 << 20
+	want.MaxBodySize = 4096
+	want.MaxHeaderBytes = 64 << 10
+	want.RouteCapacity = 50
+	want.ReadTimeout = 5 * time.Second
+	want.RequestTimeout = time.Minute
+	want.CleanPath = true
+	if cfg.BodyLimit != want.BodyLimit || cfg.MaxBodySize != want.MaxBodySize || cfg.MaxHeaderBytes != want.MaxHeaderBytes ||
+		cfg.RouteCapacity != want.RouteCapacity || cfg.MoreRequests != want.MoreRequests ||
+		cfg.ReadTimeout != want.ReadTimeout || cfg.WriteTimeout != 0 || cfg.RequestTimeout != want.RequestTimeout ||
+		!cfg.CleanPath || cfg.RedirectCleanPath {
+		t.Errorf("expected %+v, got %+v", want, cfg)
+	}
+	if strings.Join(cfg.TrustedProxies, ",") != "10.0.0.0/8,127.0.0.1" {
+		t.Errorf("expected the trusted proxies, got %v", cfg.TrustedProxies)
+	}
+}
+
+func TestConfigFromEnvInvalid(t *testing.T) {
+	t.Setenv("QUICK_BODY_LIMIT", "ten")
+	t.Setenv("QUICK_MORE_REQUESTS", "-1")
+	t.Setenv("QUICK_IDLE_TIMEOUT", "5")
+	t.Setenv("QUICK_CLEAN_PATH", "yes")
+	t.Setenv("QUICK_TRUSTED_PROXIES", "10.0.0.0/33")
+	t.Setenv("QUICK_MAX_BODY_SIZE", "1kb")
+
+	cfg, err := ConfigFromEnv()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, name := range []string{"QUICK_BODY_LIMIT", "QUICK_MORE_REQUESTS", "QUICK_IDLE_TIMEOUT", "QUICK_CLEAN_PATH", "QUICK_TRUSTED_PROXIES"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("expected %s in the error, got %v", name, err)
+		}
+	}
+	def := GetDefaultConfig()
+	if cfg.BodyLimit != def.BodyLimit || cfg.MoreRequests != def.MoreRequests || cfg.TrustedProxies != nil {
+		t.Errorf("expected the defaults for invalid values, got %+v", cfg)
+	}
+	if cfg.MaxBodySize != 1024 {
+		t.Errorf("expected the valid values to apply, got %d", cfg.MaxBodySize)
+	}
+}
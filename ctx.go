@@ -1,18 +1,41 @@
 package quick
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
+	"fmt"
+	"hash"
 	"io"
+	"log/slog"
+	"mime"
 	"mime/multipart"
+	"net"
 	"net/http"
+	"net/http/httputil"
+	"net/textproto"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
+// Ctx is the context of a request handled by Quick. Ctx values, with
+// their Params and Query maps, are pooled: once the handler returns they
+// are cleared and reused by another request. Do not use c after the
+// handler returns, e.g. in a goroutine; copy the values you need, and
+// take c.Context() before starting it.
 type Ctx struct {
 	Response       http.ResponseWriter
 	Request        *http.Request
@@ -23,7 +46,60 @@ type Ctx struct {
 	Headers        map[string][]string
 	Params         map[string]string
 	Query          map[string]string
-	uploadFileSize int64 // Upload limit in bytes
+	uploadFileSize int64                  // in-memory part of multipart forms, see FormFileLimit
+	paramNames     []string               // matched param names, in path order
+	paramValues    []string               // matched param values, substrings of the path
+	locals         map[string]interface{} // request scoped values shared between handlers
+	bodyDeferred   bool                   // multipart body not buffered yet, see loadBody
+	streamBody     io.ReadCloser          // multipart body without the MaxBodySize limit, see MultipartReader
+	statusSent     bool                   // status already written by Write
+	startTime      time.Time              // when the request reached Quick, see StartTime
+	logger         *slog.Logger           // request scoped logger, see Logger
+}
+
+// ctxPool reuses Ctx instances between requests to reduce GC pressure
+var ctxPool = sync.Pool{
+	New: func() any {
+		return &Ctx{
+			Params: make(map[string]string),
+			Query:  make(map[string]string),
+		}
+	},
+}
+
+// acquireCtx takes a clean Ctx from the pool and binds it to the request.
+// The Ctx must not be retained after the handler returns.
+// Method Used Internally
+// The result will acquireCtx(w http.ResponseWriter, req *http.Request, moreRequests int) *Ctx
+func acquireCtx(w http.ResponseWriter, req *http.Request, moreRequests int) *Ctx {
+	c := ctxPool.Get().(*Ctx)
+	if c.Params == nil {
+		c.Params = make(map[string]string)
+	}
+	if c.Query == nil {
+		c.Query = make(map[string]string)
+	}
+	c.Response = w
+	c.Request = req
+	c.MoreRequests = moreRequests
+	c.startTime = time.Now()
+	if cval, ok := c.matched(); ok && !cval.Start.IsZero() {
+		c.startTime = cval.Start
+	}
+	return c
+}
+
+// releaseCtx resets every field of c so no state leaks into the next
+// request, then returns it to the pool. The Params and Query maps are
+// cleared and kept to avoid allocating them again.
+// Method Used Internally
+// The result will releaseCtx(c *Ctx)
+func releaseCtx(c *Ctx) {
+	params, query := c.Params, c.Query
+	clear(params)
+	clear(query)
+	*c = Ctx{Params: params, Query: query}
+	ctxPool.Put(c)
 }
 
 // UploadedFile holds details of an uploaded file.
@@ -33,6 +109,12 @@ type UploadedFile struct {
 	Info      FileInfo
 }
 
+// MultipartPart is a single part of a multipart/mixed response
+type MultipartPart struct {
+	Header map[string]string
+	Body   []byte
+}
+
 // FileInfo contains metadata of the uploaded file.
 type FileInfo struct {
 	Filename    string
@@ -65,36 +147,66 @@ func (c *Ctx) File(filePath string) error {
 	return nil
 }
 
-// Bind analyzes and links the request body to a Go structure
+// Bind analyzes and links the request body to a Go structure,
+// then checks its `validate` tags. Type mismatches and rule violations
+// are returned as a *ValidationError with one entry per field,
+// malformed bodies as a *BodyError.
 // The result will Bind(v interface{}) (err error)
 func (c *Ctx) Bind(v interface{}) (err error) {
-	return extractBind(c, v)
+	if err = extractBind(c, v); err != nil {
+		return bindDecodeError(malformedBodyError(err, len(c.bodyByte)))
+	}
+	return validate(v, c.Logger())
 }
 
 // BodyParser analyzes the request body and deserializes it to the Go structure reported.
+// Malformed bodies return a *BodyError, answered with 400 when the handler returns it.
 // The result will BodyParser(v interface{}) (err error)
 func (c *Ctx) BodyParser(v interface{}) (err error) {
 	if strings.Contains(c.Request.Header.Get("Content-Type"), ContentTypeAppJSON) {
-		err = json.Unmarshal(c.bodyByte, v)
+		body := c.loadBody()
+		err = json.Unmarshal(body, v)
 		if err != nil {
-			return err
+			return malformedBodyError(err, len(body))
 		}
 	}
 
 	if strings.Contains(c.Request.Header.Get("Content-Type"), ContentTypeTextXML) ||
 		strings.Contains(c.Request.Header.Get("Content-Type"), ContentTypeAppXML) {
-		err = xml.Unmarshal(c.bodyByte, v)
+		body := c.loadBody()
+		err = xml.Unmarshal(body, v)
 		if err != nil {
-			return err
+			return malformedBodyError(err, len(body))
 		}
 	}
 
 	return nil
 }
 
+// setParams stores the matched params as slices and mirrors them into
+// the pooled Params map, kept for compatibility
+// Method Used Internally
+// The result will setParams(names, values []string)
+func (c *Ctx) setParams(names, values []string) {
+	c.paramNames = names
+	c.paramValues = values
+	for i, name := range names {
+		c.Params[name] = values[i]
+	}
+}
+
 // Param returns the value of the URL parameter corresponding to the given key
+// Lookups scan the matched params slice before falling back to the Params map.
+// A Ctx built by a middleware around the request, e.g. &quick.Ctx{Request: r},
+// reads the params matched by the router from the request context.
 // The result will Param(key string) string
 func (c *Ctx) Param(key string) string {
+	c.loadParams()
+	for i, name := range c.paramNames {
+		if name == key {
+			return c.paramValues[i]
+		}
+	}
 	val, ok := c.Params[key]
 	if ok {
 		return val
@@ -102,16 +214,520 @@ func (c *Ctx) Param(key string) string {
 	return ""
 }
 
-// Body returns the request body as a byte slice ([]byte)
+// loadParams reads the params matched by the router from the request
+// context when c was not built by Quick, e.g. &quick.Ctx{Request: r}
+// Method Used Internally
+// The result will loadParams()
+func (c *Ctx) loadParams() {
+	if c.paramNames == nil {
+		if cval, ok := c.matched(); ok {
+			c.paramNames, c.paramValues = cval.ParamNames, cval.ParamValues
+		}
+	}
+}
+
+// ParamNames returns the names of the path params in the order they
+// appear in the route pattern, e.g. [org id] for /orgs/:org/users/:id,
+// to iterate over the params in a stable order
+// The result will ParamNames() []string
+func (c *Ctx) ParamNames() []string {
+	c.loadParams()
+	if len(c.paramNames) > 0 {
+		return append([]string(nil), c.paramNames...)
+	}
+	names := make([]string, 0, len(c.Params))
+	for name := range c.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParamsMap returns a copy of the path params, safe to keep or change
+// after the handler returns. Use ParamNames for a stable order.
+// The result will ParamsMap() map[string]string
+func (c *Ctx) ParamsMap() map[string]string {
+	c.loadParams()
+	params := make(map[string]string, len(c.paramNames)+len(c.Params))
+	for name, value := range c.Params {
+		params[name] = value
+	}
+	for i, name := range c.paramNames {
+		params[name] = c.paramValues[i]
+	}
+	return params
+}
+
+// ParamArray splits the value of the URL parameter key by sep, e.g. for
+// /users/:ids and /users/1,2,3 ParamArray("ids", ",") returns [1 2 3].
+// Empty items are dropped, so a missing param returns nil.
+// The result will ParamArray(key, sep string) []string
+func (c *Ctx) ParamArray(key, sep string) []string {
+	val := c.Param(key)
+	if val == "" {
+		return nil
+	}
+	items := strings.Split(val, sep)
+	out := items[:0]
+	for _, item := range items {
+		if item != "" {
+			out = append(out, item)
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// Context returns the request context, cancelled when the client disconnects
+// or the server shuts down. Pass it to outbound calls so they stop with the request.
+// When Config.RequestTimeout or the timeout middleware bounds the route, it
+// carries the deadline too, so e.g. db.QueryContext(c.Context(), ...) is
+// cancelled on timeout without passing durations around.
+// The result will Context() context.Context
+func (c *Ctx) Context() context.Context {
+	return c.Request.Context()
+}
+
+// Path returns the concrete request path, e.g. /users/42
+// The result will Path() string
+func (c *Ctx) Path() string {
+	return c.Request.URL.Path
+}
+
+// Method returns the request HTTP method
+// The result will Method() string
+func (c *Ctx) Method() string {
+	return c.Request.Method
+}
+
+// RoutePattern returns the pattern of the matched route, e.g. /users/:id,
+// or an empty string when no route matched. Unlike Path it has a bounded
+// number of values, so it is safe to use as a metrics or log label.
+// The result will RoutePattern() string
+func (c *Ctx) RoutePattern() string {
+	cval, _ := c.matched()
+	return cval.Pattern
+}
+
+// Route returns the matched route, or nil outside a Quick route. Its
+// Requires, Tags and Group let a middleware decide by route rather than
+// by path, e.g. an authorization middleware registered with q.Use:
+//
+//	if route := c.Route(); route != nil && !hasAll(user, route.Requires) {
+//		return c.Status(403).SendString("forbidden")
+//	}
+//
+// The route is shared by every request: read it, do not change it.
+// The result will Route() *Route
+func (c *Ctx) Route() *Route {
+	cval, _ := c.matched()
+	return cval.Route
+}
+
+// Logger returns the logger configured in Config.Logger for the Quick
+// instance serving the request, or slog.Default() outside a Quick route,
+// tagged with the request: method, path, route pattern and the attributes
+// added by middlewares with WithLogAttrs, e.g. the request_id of the
+// requestid middleware. c.Logger().Info("created user") thus carries the
+// fields that correlate it with the request.
+// Middlewares can use it through &quick.Ctx{Request: r}.
+// The result will Logger() *slog.Logger
+func (c *Ctx) Logger() *slog.Logger {
+	if c.logger != nil {
+		return c.logger
+	}
+	logger := slog.Default()
+	cval, ok := c.matched()
+	if ok && cval.Logger != nil {
+		logger = cval.Logger
+	}
+	if c.Request == nil {
+		return logger
+	}
+
+	args := []any{"method", c.Request.Method, "path", c.Request.URL.Path}
+	if ok && cval.Pattern != "" {
+		args = append(args, "route", cval.Pattern)
+	}
+	if attrs, _ := c.Request.Context().Value(logAttrsKey).([]slog.Attr); len(attrs) > 0 {
+		for _, attr := range attrs {
+			args = append(args, attr)
+		}
+	}
+	c.logger = logger.With(args...)
+	return c.logger
+}
+
+// WithLogAttrs returns a copy of ctx whose requests log attrs in every line
+// written through Ctx.Logger, after the ones added before. Middlewares use it
+// to tag handler logs, e.g.
+//
+//	next.ServeHTTP(w, r.WithContext(quick.WithLogAttrs(r.Context(), slog.String("tenant", id))))
+//
+// The result will WithLogAttrs(ctx context.Context, attrs ...slog.Attr) context.Context
+func WithLogAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	prev, _ := ctx.Value(logAttrsKey).([]slog.Attr)
+	merged := make([]slog.Attr, 0, len(prev)+len(attrs))
+	merged = append(append(merged, prev...), attrs...)
+	return context.WithValue(ctx, logAttrsKey, merged)
+}
+
+// ResponseSize returns the number of response body bytes written so far,
+// as sent on the wire: when a compression middleware is active it counts
+// the compressed bytes. Middlewares read it after calling the next handler
+// through &quick.Ctx{Request: r}. It returns 0 outside a Quick route.
+// The result will ResponseSize() int
+func (c *Ctx) ResponseSize() int {
+	if cval, ok := c.matched(); ok && cval.Written != nil {
+		return cval.Written.size
+	}
+	return 0
+}
+
+// StartTime returns when the request reached Quick, before any middleware
+// ran. Middlewares read it through &quick.Ctx{Request: r}. It is zero
+// outside a Quick route.
+// The result will StartTime() time.Time
+func (c *Ctx) StartTime() time.Time {
+	if c.startTime.IsZero() {
+		if cval, ok := c.matched(); ok {
+			return cval.Start
+		}
+	}
+	return c.startTime
+}
+
+// Latency returns the time elapsed since StartTime, e.g. for a
+// Server-Timing header. It is zero outside a Quick route.
+// The result will Latency() time.Duration
+func (c *Ctx) Latency() time.Duration {
+	start := c.StartTime()
+	if start.IsZero() {
+		return 0
+	}
+	return time.Since(start)
+}
+
+// PanicError is the error handed to Config.ErrorHandler for a panic caught
+// by the recover middleware
+type PanicError struct {
+	Value interface{} // value passed to panic
+	Stack []byte      // stack trace of the panicking goroutine
+}
+
+// Error returns the panic value as text
+// The result will Error() string
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value when it is an error
+// The result will Unwrap() error
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// HandleError writes the response for err through Config.ErrorHandler, so
+// middlewares answer errors in the same format as handlers. Without an
+// ErrorHandler, or when it fails, malformed bodies get 400, fields that
+// fail binding or validation (*ValidationError) get 422 with the JSON
+// field list, e.g. {"errors":[{"field":"age",...}]}, bodies over
+// MaxBodySize (*http.MaxBytesError) get 413, panics get 500 "Internal
+// Server Error" and other errors get 500 with the error text.
+// It returns the error of the ErrorHandler, if any.
+// The result will HandleError(err error) error
+func (c *Ctx) HandleError(err error) error {
+	var herr error
+	if cval, _ := c.matched(); cval.ErrorHandler != nil {
+		if herr = cval.ErrorHandler(c, err); herr == nil {
+			return nil
+		}
+		c.Logger().Error("error handler failed", "error", herr)
+	}
+
+	status, msg := StatusInternalServerError, err.Error()
+	var pe *PanicError
+	var tooLarge *http.MaxBytesError
+	ve, invalid := AsValidationError(err)
+	if errors.As(err, &tooLarge) {
+		// a multipart body read past MaxBodySize
+		status, msg = StatusRequestEntityTooLarge, "Request body too large"
+	} else if _, ok := AsBodyError(err); ok {
+		status = StatusBadRequest
+	} else if invalid {
+		status = StatusUnprocessableEntity
+	} else if errors.As(err, &pe) {
+		msg = http.StatusText(StatusInternalServerError)
+	}
+	if c.Written() {
+		// the client already has a response, an error body would corrupt it
+		return herr
+	}
+	if invalid {
+		// #nosec G104
+		c.Status(status).JSON(ve)
+		return herr
+	}
+	c.Set("Content-Type", "text/plain; charset=utf-8")
+	// #nosec G104
+	c.Status(status).SendString(msg)
+	return herr
+}
+
+// sizeWriter counts the bytes written to the client and records whether
+// the response was started, see Ctx.Written
+type sizeWriter struct {
+	http.ResponseWriter
+	size  int
+	wrote bool
+}
+
+// WriteHeader records the status; informational 1xx responses, e.g. 103
+// Early Hints, do not start the response
+func (w *sizeWriter) WriteHeader(status int) {
+	if status >= 200 {
+		w.wrote = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *sizeWriter) Write(b []byte) (int, error) {
+	w.wrote = true
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
+
+// Flush keeps streaming responses working through the counter
+func (w *sizeWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets websocket upgrades take over the connection
+func (w *sizeWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Unwrap exposes the original writer to http.ResponseController
+func (w *sizeWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// dropInformational hides informational statuses from writers that buffer
+// the response, like the one of http.TimeoutHandler, which would take a
+// 103 Early Hints as the final status
+// Method Used Internally
+// The result will dropInformational(next http.Handler) http.Handler
+func dropInformational(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(informationalFilter{w}, r)
+	})
+}
+
+// informationalFilter ignores WriteHeader calls with 1xx statuses
+type informationalFilter struct {
+	http.ResponseWriter
+}
+
+func (w informationalFilter) WriteHeader(status int) {
+	if status >= 100 && status <= 199 {
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap exposes the original writer to http.ResponseController
+func (w informationalFilter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// matched returns the routing result stored in the request context by ServeHTTP
+// Method Used Internally
+// The result will matched() (ctxServeHttp, bool)
+func (c *Ctx) matched() (ctxServeHttp, bool) {
+	if c.Request == nil {
+		return ctxServeHttp{}, false
+	}
+	cval, ok := c.Request.Context().Value(myContextKey).(*ctxServeHttp)
+	if !ok {
+		return ctxServeHttp{}, false
+	}
+	return *cval, true
+}
+
+// loadBody buffers a deferred multipart body on first use, so handlers that
+// stream it with MultipartReader never hold the whole upload in memory
+// Method Used Internally
+// The result will loadBody() []byte
+func (c *Ctx) loadBody() []byte {
+	if c.bodyDeferred {
+		c.bodyDeferred = false
+		c.bodyByte, c.Request.Body = extractBodyBytes(c.Request.Body)
+	}
+	return c.bodyByte
+}
+
+// MultipartReader returns a streaming reader over the parts of a multipart
+// request body. Parts are read straight from the connection, one at a time,
+// so large uploads can be copied to disk or remote storage with flat memory.
+// Since nothing is buffered, MaxBodySize does not apply; the handler decides
+// how much to accept while it copies the parts.
+// It must be called before anything else reads the body (Body, Bind, FormFile...).
+// The result will MultipartReader() (*multipart.Reader, error)
+func (c *Ctx) MultipartReader() (*multipart.Reader, error) {
+	if c.Request == nil || c.Request.Body == nil {
+		return nil, errors.New("request body is nil")
+	}
+	// the body now belongs to the reader
+	c.bodyDeferred = false
+	if c.streamBody != nil {
+		c.Request.Body, c.streamBody = c.streamBody, nil
+	}
+	return c.Request.MultipartReader()
+}
+
+// Body returns the request body as a byte slice ([]byte).
+// The raw body is read once and cached, so it can be verified, e.g. with
+// VerifyHMAC, and then parsed with BodyParser or Bind.
 // The result will Body() []byte
 func (c *Ctx) Body() []byte {
-	return c.bodyByte
+	return c.loadBody()
+}
+
+// VerifyHMAC checks the signature sent in the request header against the
+// HMAC of the raw body, e.g. for webhooks. The signature may be hex, with an
+// optional "algo=" prefix as in GitHub's "sha256=...", or base64. The
+// comparison runs in constant time. An error is returned when the header is
+// missing or cannot be decoded.
+//
+//	ok, err := c.VerifyHMAC("X-Hub-Signature-256", secret, sha256.New)
+//
+// The result will VerifyHMAC(header, secret string, algo func() hash.Hash) (bool, error)
+func (c *Ctx) VerifyHMAC(header, secret string, algo func() hash.Hash) (bool, error) {
+	sig := strings.TrimSpace(c.Request.Header.Get(header))
+	if sig == "" {
+		return false, fmt.Errorf("quick: missing signature header %s", header)
+	}
+	// base64 only has '=' as trailing padding, so an '=' followed by more
+	// characters ends an "algo=" prefix
+	if i := strings.IndexByte(sig, '='); i > 0 && i < len(sig)-1 && sig[i+1] != '=' {
+		sig = sig[i+1:]
+	}
+
+	mac := hmac.New(algo, []byte(secret))
+	mac.Write(c.loadBody())
+	expected := mac.Sum(nil)
+
+	got, err := hex.DecodeString(sig)
+	if err != nil || len(got) != len(expected) {
+		if got, err = base64.StdEncoding.DecodeString(sig); err != nil {
+			return false, fmt.Errorf("quick: signature in %s is neither hex nor base64", header)
+		}
+	}
+	return hmac.Equal(got, expected), nil
 }
 
 // BodyString returns the request body as a string
 // The result will BodyString() string
 func (c *Ctx) BodyString() string {
-	return string(c.bodyByte)
+	return string(c.loadBody())
+}
+
+// ApplyFields keeps only the fields requested in the "fields" query
+// param, e.g. ?fields=id,email, as sparse fieldsets, so clients download
+// what they use. v is read through its JSON form, so the keys are the json
+// names; slices are filtered item by item and dotted fields select nested
+// keys, e.g. ?fields=id,address.city. Without the param, or when v cannot
+// be encoded, v is returned unchanged.
+//
+//	return c.Status(200).JSON(c.ApplyFields(users))
+//
+// The result will ApplyFields(v interface{}) interface{}
+func (c *Ctx) ApplyFields(v interface{}) interface{} {
+	fields := parseFields(c.Request.URL.Query().Get("fields"))
+	if fields == nil {
+		return v
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var data interface{}
+	if err := dec.Decode(&data); err != nil {
+		return v
+	}
+	return filterFields(data, fields)
+}
+
+// fieldSet is a tree of the requested fields; a nil subtree keeps the
+// whole value of the field
+type fieldSet map[string]fieldSet
+
+// parseFields builds the tree of a comma separated list of dotted fields
+// Method Used Internally
+// The result will parseFields(list string) fieldSet
+func parseFields(list string) fieldSet {
+	var fields fieldSet
+	for _, field := range strings.Split(list, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		if fields == nil {
+			fields = fieldSet{}
+		}
+		node := fields
+		parts := strings.Split(field, ".")
+		for i, part := range parts {
+			sub, ok := node[part]
+			if ok && sub == nil {
+				break // the whole field is already kept
+			}
+			if i == len(parts)-1 {
+				node[part] = nil
+				break
+			}
+			if sub == nil {
+				sub = fieldSet{}
+				node[part] = sub
+			}
+			node = sub
+		}
+	}
+	return fields
+}
+
+// filterFields keeps the fields of data found in fields
+// Method Used Internally
+// The result will filterFields(data interface{}, fields fieldSet) interface{}
+func filterFields(data interface{}, fields fieldSet) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(fields))
+		for name, sub := range fields {
+			val, ok := v[name]
+			if !ok {
+				continue
+			}
+			if sub != nil {
+				val = filterFields(val, sub)
+			}
+			out[name] = val
+		}
+		return out
+	case []interface{}:
+		for i, item := range v {
+			v[i] = filterFields(item, fields)
+		}
+		return v
+	}
+	return data
 }
 
 // JSON serializes the value provided in JSON and writes to the HTTP response
@@ -121,7 +737,7 @@ func (c *Ctx) JSON(v interface{}) error {
 	if err != nil {
 		return err
 	}
-	c.Response.Header().Set("F", ContentTypeAppJSON)
+	c.Response.Header().Set("Content-Type", ContentTypeAppJSON)
 	return c.writeResponse(b)
 }
 
@@ -132,10 +748,275 @@ func (c *Ctx) JSONIN(v interface{}, params ...string) error {
 	if err != nil {
 		return err
 	}
-	c.Response.Header().Set("F", ContentTypeAppJSON)
+	c.Response.Header().Set("Content-Type", ContentTypeAppJSON)
 	return c.writeResponse(b)
 }
 
+// JSONPretty serializes v as indented JSON, e.g. for debug endpoints,
+// using indent for each level ("  " when empty). c.JSON stays compact.
+// The result will JSONPretty(v interface{}, indent string) error
+func (c *Ctx) JSONPretty(v interface{}, indent string) error {
+	if indent == "" {
+		indent = "  "
+	}
+	b, err := json.MarshalIndent(v, "", indent)
+	if err != nil {
+		return err
+	}
+	c.Response.Header().Set("Content-Type", ContentTypeAppJSON)
+	return c.writeResponse(b)
+}
+
+// jsonStreamFlushEvery is how many elements a JSONStream writes between flushes
+const jsonStreamFlushEvery = 100
+
+// ErrStreamAborted is returned by the writes of JSONStream and SSEStream
+// once the request context is done: the client disconnected, the server is
+// shutting down or the deadline passed. It wraps the context error, e.g.
+// errors.Is(err, context.DeadlineExceeded) tells a timeout apart.
+var ErrStreamAborted = errors.New("stream aborted")
+
+// streamAborted returns ErrStreamAborted when the request context is done
+// Method Used Internally
+// The result will streamAborted() error
+func (c *Ctx) streamAborted() error {
+	if c.Request == nil {
+		return nil
+	}
+	if err := c.Request.Context().Err(); err != nil {
+		return fmt.Errorf("%w: %w", ErrStreamAborted, err)
+	}
+	return nil
+}
+
+// JSONStream writes a JSON array one element at a time, see Ctx.JSONStream
+type JSONStream struct {
+	c   *Ctx
+	w   *bufio.Writer
+	n   int
+	err error
+}
+
+// JSONStream starts a streamed JSON array, for result sets too large to
+// build in memory. Each Encode writes one element and the buffered output is
+// flushed to the client every 100 elements; Close writes the closing bracket.
+// The status set with Status is sent with the first write.
+//
+//	s := c.JSONStream()
+//	for rows.Next() {
+//		...
+//		if err := s.Encode(row); err != nil {
+//			return err
+//		}
+//	}
+//	return s.Close()
+//
+// The result will JSONStream() *JSONStream
+func (c *Ctx) JSONStream() *JSONStream {
+	c.Response.Header().Set("Content-Type", ContentTypeAppJSON)
+	return &JSONStream{c: c, w: bufio.NewWriter(c)}
+}
+
+// Encode writes v as the next element of the array. A value that cannot be
+// encoded returns its error and is skipped; write errors are kept and
+// returned by every later call. Once the client disconnects it returns
+// ErrStreamAborted, so the loop producing the rows stops.
+// The result will Encode(v interface{}) error
+func (s *JSONStream) Encode(v interface{}) error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.err = s.c.streamAborted(); s.err != nil {
+		return s.err
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	sep := byte(',')
+	if s.n == 0 {
+		sep = '['
+	}
+	s.w.WriteByte(sep)
+	if _, s.err = s.w.Write(b); s.err != nil {
+		return s.err
+	}
+	s.n++
+	if s.n%jsonStreamFlushEvery == 0 {
+		return s.Flush()
+	}
+	return nil
+}
+
+// Flush sends the buffered elements to the client
+// The result will Flush() error
+func (s *JSONStream) Flush() error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.err = s.c.streamAborted(); s.err != nil {
+		return s.err
+	}
+	if s.err = s.w.Flush(); s.err != nil {
+		return s.err
+	}
+	err := http.NewResponseController(s.c.Response).Flush()
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		s.err = err
+	}
+	return s.err
+}
+
+// Close ends the array, writing "[]" when nothing was encoded, and flushes
+// The result will Close() error
+func (s *JSONStream) Close() error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.n == 0 {
+		s.w.WriteByte('[')
+	}
+	s.w.WriteByte(']')
+	return s.Flush()
+}
+
+// SSEvent is one server-sent event, see Ctx.SSE
+type SSEvent struct {
+	ID    string        // sent as "id:", resumed by the client in Last-Event-ID
+	Event string        // event type, "message" when empty
+	Data  string        // payload, split into one "data:" line per line
+	Retry time.Duration // reconnection delay asked to the client, if set
+}
+
+// SSEStream writes server-sent events, see Ctx.SSE
+type SSEStream struct {
+	c   *Ctx
+	err error
+}
+
+// SSE starts a text/event-stream response. The headers, with the status set
+// with Status, are sent right away so the client sees the stream open; each
+// Send writes and flushes one event. Send returns ErrStreamAborted once the
+// client disconnects, and Done is closed at the same moment, so producers
+// can select on it and stop promptly:
+//
+//	s := c.SSE()
+//	for {
+//		select {
+//		case <-s.Done():
+//			return nil
+//		case price := <-prices:
+//			if err := s.Send(quick.SSEvent{Event: "price", Data: price}); err != nil {
+//				return err
+//			}
+//		}
+//	}
+//
+// Disconnects are seen through the request context, which net/http cancels
+// when the connection closes, replacing the deprecated CloseNotifier.
+// The result will SSE() *SSEStream
+func (c *Ctx) SSE() *SSEStream {
+	h := c.Response.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no") // keep proxies such as nginx from buffering the stream
+	h.Del("Content-Length")
+
+	s := &SSEStream{c: c}
+	c.writeStatus()
+	s.flush()
+	return s
+}
+
+// Send writes ev and flushes it to the client. Write errors are kept and
+// returned by every later call.
+// The result will Send(ev SSEvent) error
+func (s *SSEStream) Send(ev SSEvent) error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.err = s.c.streamAborted(); s.err != nil {
+		return s.err
+	}
+
+	var b strings.Builder
+	if ev.ID != "" {
+		b.WriteString("id: " + sseField(ev.ID) + "\n")
+	}
+	if ev.Event != "" {
+		b.WriteString("event: " + sseField(ev.Event) + "\n")
+	}
+	if ev.Retry > 0 {
+		b.WriteString("retry: " + strconv.FormatInt(ev.Retry.Milliseconds(), 10) + "\n")
+	}
+	for _, line := range strings.Split(strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(ev.Data), "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+
+	if _, s.err = s.c.Write([]byte(b.String())); s.err != nil {
+		return s.err
+	}
+	return s.flush()
+}
+
+// Done is closed when the client disconnects or the request ends
+// The result will Done() <-chan struct{}
+func (s *SSEStream) Done() <-chan struct{} {
+	return s.c.Context().Done()
+}
+
+// flush sends the written events to the client
+// Method Used Internally
+// The result will flush() error
+func (s *SSEStream) flush() error {
+	err := http.NewResponseController(s.c.Response).Flush()
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		s.err = err
+	}
+	return s.err
+}
+
+// sseField drops the line breaks that would end an id or event field early
+// Method Used Internally
+// The result will sseField(v string) string
+func sseField(v string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(v)
+}
+
+// jsonpCallbackRgx allows JavaScript identifiers and dotted paths like "app.cb"
+var jsonpCallbackRgx = regexp.MustCompile(`^[a-zA-Z_$][a-zA-Z0-9_$]*(\.[a-zA-Z_$][a-zA-Z0-9_$]*)*$`)
+
+// JSONP serializes the value in JSON wrapped in a JavaScript callback.
+// The callback name comes from the argument, the "callback" query param
+// or defaults to "callback"; names that are not plain identifiers are
+// rejected to prevent script injection.
+// The result will JSONP(v interface{}, callback ...string) error
+func (c *Ctx) JSONP(v interface{}, callback ...string) error {
+	cb := "callback"
+	if len(callback) > 0 && callback[0] != "" {
+		cb = callback[0]
+	} else if c.Request != nil {
+		if qcb := c.Request.URL.Query().Get("callback"); qcb != "" {
+			cb = qcb
+		}
+	}
+
+	if len(cb) > 128 || !jsonpCallbackRgx.MatchString(cb) {
+		return errors.New("invalid JSONP callback name")
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	c.Response.Header().Set("Content-Type", "application/javascript; charset=utf-8")
+	c.Response.Header().Set("X-Content-Type-Options", "nosniff")
+	return c.writeResponse([]byte("/**/ " + cb + "(" + string(b) + ");"))
+}
+
 // XML serializes the provided value in XML and writes to the HTTP response
 // The result will XML(v interface{}) error
 func (c *Ctx) XML(v interface{}) error {
@@ -147,16 +1028,175 @@ func (c *Ctx) XML(v interface{}) error {
 	return c.writeResponse(b)
 }
 
+// Negotiate serializes v in the format preferred by the Accept header of the
+// request: JSON, XML (application/xml or text/xml) or plain text through
+// fmt.Sprint. Quality values are honored and JSON is used when Accept is
+// missing or lists nothing supported. The response varies by Accept.
+// The result will Negotiate(v interface{}) error
+func (c *Ctx) Negotiate(v interface{}) error {
+	var (
+		b   []byte
+		err error
+	)
+	contentType := negotiateContentType(c.Request.Header.Get("Accept"))
+	switch contentType {
+	case ContentTypeAppXML, ContentTypeTextXML:
+		b, err = xml.Marshal(v)
+	case "text/plain":
+		b = []byte(fmt.Sprint(v))
+	default:
+		b, err = json.Marshal(v)
+	}
+	if err != nil {
+		return err
+	}
+	c.Vary("Accept")
+	c.Response.Header().Set("Content-Type", contentType+"; charset=utf-8")
+	return c.writeResponse(b)
+}
+
+// negotiateContentType picks the supported media type with the highest
+// quality in accept, ties going to the first listed
+// Method Used Internally
+// The result will negotiateContentType(accept string) string
+func negotiateContentType(accept string) string {
+	best, bestQ := ContentTypeAppJSON, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if qs, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(qs, 64); err != nil {
+				continue
+			}
+		}
+		if q <= bestQ {
+			continue
+		}
+
+		var supported string
+		switch mediaType {
+		case ContentTypeAppJSON, "application/*", "*/*":
+			supported = ContentTypeAppJSON
+		case ContentTypeAppXML, ContentTypeTextXML:
+			supported = mediaType
+		case "text/plain", "text/*":
+			supported = "text/plain"
+		default:
+			continue
+		}
+		best, bestQ = supported, q
+	}
+	return best
+}
+
+// MultipartWriter starts a multipart/mixed response and returns a writer
+// to stream the parts; the caller must Close it to write the final boundary
+// The result will MultipartWriter() *multipart.Writer
+func (c *Ctx) MultipartWriter() *multipart.Writer {
+	mw := multipart.NewWriter(c.Response)
+	c.Response.Header().Set("Content-Type", "multipart/mixed; boundary="+mw.Boundary())
+	c.writeStatus()
+	return mw
+}
+
+// MultipartMixed writes the parts as a multipart/mixed response, each one
+// with its own headers and body
+// The result will MultipartMixed(parts ...MultipartPart) error
+func (c *Ctx) MultipartMixed(parts ...MultipartPart) error {
+	mw := c.MultipartWriter()
+	for _, part := range parts {
+		header := make(textproto.MIMEHeader, len(part.Header))
+		for k, v := range part.Header {
+			header.Set(k, v)
+		}
+		pw, err := mw.CreatePart(header)
+		if err != nil {
+			return err
+		}
+		if _, err := pw.Write(part.Body); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
 // writeResponse writes the content provided in the current request ResponseWriter
 // The result will writeResponse(b []byte) error
 func (c *Ctx) writeResponse(b []byte) error {
-	if c.resStatus != 0 {
-		c.Response.WriteHeader(c.resStatus)
-	}
+	c.writeStatus()
 	_, err := c.Response.Write(b)
 	return err
 }
 
+// Write writes b to the response with the status set by Status, so *Ctx
+// satisfies io.Writer, e.g. csv.NewWriter(c) or tmpl.Execute(c, data).
+// The status is sent with the first write only.
+// The result will Write(b []byte) (int, error)
+func (c *Ctx) Write(b []byte) (int, error) {
+	c.writeStatus()
+	return c.Response.Write(b)
+}
+
+// writeStatus sends the status set by Status once. It is skipped when the
+// response was already written, e.g. by a middleware, so net/http does not
+// warn about a superfluous WriteHeader.
+// Method Used Internally
+// The result will writeStatus()
+func (c *Ctx) writeStatus() {
+	if c.resStatus == 0 || c.statusSent {
+		return
+	}
+	written := c.Written()
+	c.statusSent = true
+	if !written {
+		c.Response.WriteHeader(c.resStatus)
+	}
+}
+
+// Written reports whether the response status or body was already sent,
+// by this Ctx or by another one of the request, e.g. a middleware that
+// answered with c.Write. A handler that finds it true can return nil:
+// a nil return never writes anything, and errors returned after the
+// response was written are logged without appending an error body.
+// Only the writer this Ctx holds counts, so a handler run again into a
+// recorder, e.g. by a cache refresh, starts with a fresh response.
+// The result will Written() bool
+func (c *Ctx) Written() bool {
+	if c.statusSent {
+		return true
+	}
+	if sw := c.responseTracker(); sw != nil {
+		return sw.wrote
+	}
+	return false
+}
+
+// responseTracker returns the sizeWriter of the request when c.Response
+// writes through it, directly or through writers that Unwrap to it
+// Method Used Internally
+// The result will responseTracker() *sizeWriter
+func (c *Ctx) responseTracker() *sizeWriter {
+	cval, ok := c.matched()
+	if !ok || cval.Written == nil {
+		return nil
+	}
+	w := c.Response
+	for w != nil {
+		if sw, ok := w.(*sizeWriter); ok && sw == cval.Written {
+			return sw
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return nil
+		}
+		w = u.Unwrap()
+	}
+	return nil
+}
+
 // Byte writes an array of bytes to the HTTP response, using writeResponse()
 // The result will Byte(b []byte) (err error)
 func (c *Ctx) Byte(b []byte) (err error) {
@@ -182,6 +1222,57 @@ func (c *Ctx) String(s string) error {
 	return c.writeResponse([]byte(s))
 }
 
+// SendStatus writes the status code and, for statuses that allow a body,
+// its text as the response body, e.g. "Not Found" for 404
+// The result will SendStatus(status int) error
+func (c *Ctx) SendStatus(status int) error {
+	status = c.validStatus(status)
+	c.resStatus = status
+	if !bodyAllowedForStatus(status) {
+		c.writeStatus()
+		return nil
+	}
+	return c.writeResponse([]byte(http.StatusText(status)))
+}
+
+// WriteEarlyHints sends a 103 Early Hints response carrying headers, usually
+// Link preloads, so the client can fetch resources while the handler keeps
+// working on the final response. The headers stay set for the final response.
+// HTTP/1.0 clients do not support informational responses and get none, and
+// middlewares that buffer the response (e.g. Config.RequestTimeout) drop them.
+// The result will WriteEarlyHints(headers http.Header) error
+func (c *Ctx) WriteEarlyHints(headers http.Header) error {
+	if c.Response == nil {
+		return errors.New("quick: WriteEarlyHints needs a response writer")
+	}
+	for key, values := range headers {
+		for _, v := range values {
+			if strings.ContainsAny(key+v, "\r\n") {
+				return errHeaderCRLF
+			}
+			c.Response.Header().Add(key, v)
+		}
+	}
+	if c.Request != nil && !c.Request.ProtoAtLeast(1, 1) {
+		return nil
+	}
+	c.Response.WriteHeader(StatusEarlyHints)
+	return nil
+}
+
+// bodyAllowedForStatus reports whether a response with the given status may carry a body
+// Method Used Internally
+// The result will bodyAllowedForStatus(status int) bool
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent, status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
+
 // SendFile writes a file in the HTTP response as an array of bytes
 // The result will SendFile(file []byte) error
 func (c *Ctx) SendFile(file []byte) error {
@@ -189,16 +1280,123 @@ func (c *Ctx) SendFile(file []byte) error {
 	return err
 }
 
+// headerCRLFReplacer strips CR and LF to prevent response splitting
+var headerCRLFReplacer = strings.NewReplacer("\r", "", "\n", "")
+
+// errHeaderCRLF is returned by SetHeader for names or values with CR/LF
+var errHeaderCRLF = errors.New("header name or value contains CR or LF characters")
+
 // Set defines an HTTP header in the response
+// CR and LF characters are stripped from the name and value to prevent header injection
 // The result will Set(key, value string)
 func (c *Ctx) Set(key, value string) {
+	c.Response.Header().Set(headerCRLFReplacer.Replace(key), headerCRLFReplacer.Replace(value))
+}
+
+// SetContentLength sets the Content-Length of the response, e.g. before
+// streaming a download of known size, so clients can show progress. The
+// body is then sent as is rather than with chunked encoding, and must be
+// exactly n bytes long. A negative n removes the header.
+// The result will SetContentLength(n int64)
+func (c *Ctx) SetContentLength(n int64) {
+	if n < 0 {
+		c.Response.Header().Del("Content-Length")
+		return
+	}
+	c.Response.Header().Set("Content-Length", strconv.FormatInt(n, 10))
+}
+
+// SetHeader defines an HTTP header in the response, rejecting names or
+// values containing CR or LF characters instead of sanitizing them
+// The result will SetHeader(key, value string) error
+func (c *Ctx) SetHeader(key, value string) error {
+	if strings.ContainsAny(key, "\r\n") || strings.ContainsAny(value, "\r\n") {
+		return errHeaderCRLF
+	}
 	c.Response.Header().Set(key, value)
+	return nil
 }
 
 // Append adds a value to the HTTP header specified in the response
+// CR and LF characters are stripped from the name and value to prevent header injection
 // The result will Append(key, value string)
 func (c *Ctx) Append(key, value string) {
-	c.Response.Header().Add(key, value)
+	c.Response.Header().Add(headerCRLFReplacer.Replace(key), headerCRLFReplacer.Replace(value))
+}
+
+// AddServerTiming appends a metric to the Server-Timing header, shown by
+// browser devtools, e.g. c.AddServerTiming("db", 53*time.Millisecond, "Postgres")
+// gives db;dur=53;desc="Postgres". The duration is written in milliseconds
+// and omitted when negative; desc is optional. Characters not allowed in a
+// metric name are replaced with "_".
+// The result will AddServerTiming(name string, d time.Duration, desc string)
+func (c *Ctx) AddServerTiming(name string, d time.Duration, desc string) {
+	var b strings.Builder
+	b.WriteString(serverTimingName(name))
+	if d >= 0 {
+		b.WriteString(";dur=")
+		b.WriteString(strconv.FormatFloat(float64(d.Microseconds())/1000, 'f', -1, 64))
+	}
+	if desc != "" {
+		b.WriteString(`;desc="`)
+		for _, r := range headerCRLFReplacer.Replace(desc) {
+			if r == '"' || r == '\\' {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		}
+		b.WriteByte('"')
+	}
+
+	h := c.Response.Header()
+	if prev := h.Get("Server-Timing"); prev != "" {
+		h.Set("Server-Timing", prev+", "+b.String())
+		return
+	}
+	h.Set("Server-Timing", b.String())
+}
+
+// serverTimingName turns name into an HTTP token, replacing other characters with "_"
+// Method Used Internally
+// The result will serverTimingName(name string) string
+func serverTimingName(name string) string {
+	if name == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r < 0x80 && strings.ContainsRune("!#$%&'*+-.^_`|~", r):
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// Vary adds fields to the Vary response header, skipping the ones already
+// listed, so helpers and middlewares can each declare the request headers
+// the response depends on, e.g. c.Vary("Accept"). A Vary of "*" is kept as is.
+// The result will Vary(fields ...string)
+func (c *Ctx) Vary(fields ...string) {
+	h := c.Response.Header()
+	present := map[string]bool{}
+	for _, value := range h.Values("Vary") {
+		for _, field := range strings.Split(value, ",") {
+			present[strings.ToLower(strings.TrimSpace(field))] = true
+		}
+	}
+	if present["*"] {
+		return
+	}
+	for _, field := range fields {
+		field = strings.TrimSpace(headerCRLFReplacer.Replace(field))
+		if field == "" || present[strings.ToLower(field)] {
+			continue
+		}
+		present[strings.ToLower(field)] = true
+		h.Add("Vary", field)
+	}
 }
 
 // Accepts defines the HTTP header "Accept" in the response
@@ -208,16 +1406,53 @@ func (c *Ctx) Accepts(acceptType string) *Ctx {
 	return c
 }
 
-// Status defines the HTTP status code of the response
+// Status defines the HTTP status code of the response. Codes outside
+// 100-599 go through Config.OnInvalidStatus; by default they are logged
+// and replaced with 500.
 // The result will Status(status int) *Ctx
 func (c *Ctx) Status(status int) *Ctx {
-	c.resStatus = status
+	c.resStatus = c.validStatus(status)
 	return c
 }
 
+// validStatus returns status when it is in the 100-599 range, otherwise
+// the status chosen by Config.OnInvalidStatus, or 500 after logging it
+// Method Used Internally
+// The result will validStatus(status int) int
+func (c *Ctx) validStatus(status int) int {
+	if status >= 100 && status <= 599 {
+		return status
+	}
+	if cval, ok := c.matched(); ok && cval.OnInvalidStatus != nil {
+		return cval.OnInvalidStatus(c, status)
+	}
+	c.Logger().Error("invalid HTTP status code, sending 500", "status", status)
+	return http.StatusInternalServerError
+}
+
 //MultipartForm
 
-// FormFileLimit sets the maximum allowed upload size.
+// defaultMultipartMemory is the part of a multipart form kept in memory
+// when neither Config.MultipartMemoryLimit nor FormFileLimit set one
+const defaultMultipartMemory = 1 << 20
+
+// multipartMemory returns how much of a multipart form is parsed in
+// memory: the FormFileLimit of the request, Config.MultipartMemoryLimit or 1MB
+// Method Used Internally
+// The result will multipartMemory() int64
+func (c *Ctx) multipartMemory() int64 {
+	if c.uploadFileSize > 0 {
+		return c.uploadFileSize
+	}
+	if cval, ok := c.matched(); ok && cval.MultipartMemory > 0 {
+		return cval.MultipartMemory
+	}
+	return defaultMultipartMemory
+}
+
+// FormFileLimit sets how much of the multipart form of this request is
+// kept in memory, e.g. "10MB", overriding Config.MultipartMemoryLimit;
+// larger parts are written to temporary files.
 func (c *Ctx) FormFileLimit(limit string) error {
 	size, err := parseSize(limit)
 	if err != nil {
@@ -258,10 +1493,6 @@ func (fw *fileWrapper) Close() error {
 // FormFiles processes an uploaded file and returns its details.
 // The result will FormFiles(fieldName string) (*UploadedFile, error)
 func (c *Ctx) FormFiles(fieldName string) ([]*UploadedFile, error) {
-	if c.uploadFileSize == 0 {
-		c.uploadFileSize = 1 << 20 // set default 1MB
-	}
-
 	// check request
 	if c.Request == nil {
 		return nil, errors.New("HTTP request is nil")
@@ -279,8 +1510,8 @@ func (c *Ctx) FormFiles(fieldName string) ([]*UploadedFile, error) {
 	}
 
 	// Parse multipart form with the defined limit
-	if err := c.Request.ParseMultipartForm(c.uploadFileSize); err != nil {
-		return nil, errors.New("failed to parse multipart form: " + err.Error())
+	if err := c.Request.ParseMultipartForm(c.multipartMemory()); err != nil {
+		return nil, fmt.Errorf("failed to parse multipart form: %w", err)
 	}
 
 	// Debugging: Check if files exist
@@ -334,10 +1565,50 @@ func (c *Ctx) FormFiles(fieldName string) ([]*UploadedFile, error) {
 	return uploadedFiles, nil
 }
 
+// Storage returns the Storage set in Config.Storage, or nil.
+// The result will Storage() Storage
+func (c *Ctx) Storage() Storage {
+	cval, _ := c.matched()
+	return cval.Storage
+}
+
+// SaveFileTo saves an uploaded file, as returned by FormFile, to storage
+// under its file name and returns the location reported by the storage.
+// A nil storage uses Config.Storage, so handlers can stay unaware of where
+// files go:
+//
+//	file, err := c.FormFile("file")
+//	...
+//	location, err := c.SaveFileTo(nil, file)
+//
+// The result will SaveFileTo(storage Storage, fh *UploadedFile) (string, error)
+func (c *Ctx) SaveFileTo(storage Storage, fh *UploadedFile) (string, error) {
+	if storage == nil {
+		storage = c.Storage()
+	}
+	if storage == nil {
+		return "", errors.New("no storage configured")
+	}
+	if fh == nil {
+		return "", errors.New("no file available to save")
+	}
+
+	var r io.Reader = bytes.NewReader(fh.Info.Bytes)
+	if fh.Info.Bytes == nil && fh.Multipart != nil {
+		file, err := fh.Multipart.Open()
+		if err != nil {
+			return "", errors.New("failed to open file: " + err.Error())
+		}
+		defer file.Close()
+		r = file
+	}
+	return storage.Save(fh.FileName(), r)
+}
+
 // MultipartForm allows access to the raw multipart form data (for advanced users)
 // The result will MultipartForm() (*multipart.Form, error)
 func (c *Ctx) MultipartForm() (*multipart.Form, error) {
-	if err := c.Request.ParseMultipartForm(c.uploadFileSize); err != nil {
+	if err := c.Request.ParseMultipartForm(c.multipartMemory()); err != nil {
 		return nil, err
 	}
 	return c.Request.MultipartForm, nil
@@ -348,7 +1619,7 @@ func (c *Ctx) MultipartForm() (*multipart.Form, error) {
 func (c *Ctx) FormValue(key string) string {
 	// Checks if the Content-Type is multipart
 	if c.Request.Header.Get("Content-Type") == "multipart/form-data" {
-		_ = c.Request.ParseMultipartForm(c.uploadFileSize) // Force correct processing
+		_ = c.Request.ParseMultipartForm(c.multipartMemory()) // Force correct processing
 	} else {
 		_ = c.Request.ParseForm() // For application/x-www-form-urlencoded
 	}
@@ -360,9 +1631,262 @@ func (c *Ctx) FormValue(key string) string {
 func (c *Ctx) FormValues() map[string][]string {
 	// Checks if the Content-Type is multipart
 	if c.Request.Header.Get("Content-Type") == "multipart/form-data" {
-		_ = c.Request.ParseMultipartForm(c.uploadFileSize) // Required to process multipart
+		_ = c.Request.ParseMultipartForm(c.multipartMemory()) // Required to process multipart
 	} else {
 		_ = c.Request.ParseForm() // Processes application/x-www-form-urlencoded
 	}
 	return c.Request.Form
 }
+
+// RangeSpec is an inclusive byte range [Start, End] of a resource
+type RangeSpec struct {
+	Start int64
+	End   int64
+}
+
+// Length returns the number of bytes covered by the range
+// The result will Length() int64
+func (r RangeSpec) Length() int64 {
+	return r.End - r.Start + 1
+}
+
+var (
+	// ErrRangeMalformed is returned by Range when the Range header cannot be parsed
+	ErrRangeMalformed = errors.New("malformed Range header")
+	// ErrRangeNotSatisfiable is returned by Range when no range overlaps the resource,
+	// the handler should answer 416 Range Not Satisfiable
+	ErrRangeNotSatisfiable = errors.New("range not satisfiable")
+)
+
+// Range parses the "bytes" Range header against a resource of the given size.
+// It supports "start-end", open ended "start-" and suffix "-n" ranges.
+// Ranges beyond the resource are skipped and the end is clamped to size-1.
+// Without a Range header it returns nil and no error, so the full resource can be sent.
+// The result will Range(size int64) ([]RangeSpec, error)
+func (c *Ctx) Range(size int64) ([]RangeSpec, error) {
+	header := c.Request.Header.Get("Range")
+	if header == "" {
+		return nil, nil
+	}
+
+	const prefix = "bytes="
+	if !strings.HasPrefix(header, prefix) {
+		return nil, ErrRangeMalformed
+	}
+
+	var ranges []RangeSpec
+	parsed := 0
+	for _, part := range strings.Split(header[len(prefix):], ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		parsed++
+		startStr, endStr, ok := strings.Cut(part, "-")
+		if !ok {
+			return nil, ErrRangeMalformed
+		}
+		startStr, endStr = strings.TrimSpace(startStr), strings.TrimSpace(endStr)
+
+		var r RangeSpec
+		if startStr == "" {
+			// suffix range: the last n bytes
+			n, err := strconv.ParseInt(endStr, 10, 64)
+			if err != nil || n < 0 {
+				return nil, ErrRangeMalformed
+			}
+			if n == 0 || size == 0 {
+				continue
+			}
+			if n > size {
+				n = size
+			}
+			r = RangeSpec{Start: size - n, End: size - 1}
+		} else {
+			start, err := strconv.ParseInt(startStr, 10, 64)
+			if err != nil || start < 0 {
+				return nil, ErrRangeMalformed
+			}
+			end := size - 1
+			if endStr != "" {
+				end, err = strconv.ParseInt(endStr, 10, 64)
+				if err != nil || end < start {
+					return nil, ErrRangeMalformed
+				}
+			}
+			if start >= size {
+				continue
+			}
+			if end >= size {
+				end = size - 1
+			}
+			r = RangeSpec{Start: start, End: end}
+		}
+		ranges = append(ranges, r)
+	}
+
+	if parsed == 0 {
+		return nil, ErrRangeMalformed
+	}
+	if len(ranges) == 0 {
+		return nil, ErrRangeNotSatisfiable
+	}
+	return ranges, nil
+}
+
+// Locals stores and returns request scoped values shared between handlers.
+// When a value is given it is stored under key; the current value is returned.
+// Within a Quick route the values are shared by every Ctx of the request,
+// so a middleware can store the current user through &quick.Ctx{Request: r}
+// and the handler reads it back. Values are dropped when the request ends.
+// The result will Locals(key string, value ...interface{}) interface{}
+func (c *Ctx) Locals(key string, value ...interface{}) interface{} {
+	if len(value) > 0 {
+		c.localValues(true)[key] = value[0]
+	}
+	return c.localValues(false)[key]
+}
+
+// localValues returns the locals of the request, created when create is
+// set. Within a Quick route they are the ones shared by the request.
+// Method Used Internally
+// The result will localValues(create bool) map[string]interface{}
+func (c *Ctx) localValues(create bool) map[string]interface{} {
+	if c.locals != nil {
+		return c.locals
+	}
+	if cval, ok := c.matched(); ok && cval.Locals != nil {
+		if cval.Locals.values == nil && create {
+			cval.Locals.values = make(map[string]interface{})
+		}
+		c.locals = cval.Locals.values
+		return c.locals
+	}
+	if create {
+		c.locals = make(map[string]interface{})
+	}
+	return c.locals
+}
+
+// Local returns the request scoped value stored under key as T.
+// ok is false when the key is missing or holds a value of another type,
+// so no type assertion or panic is needed at the call site.
+//
+//	user, ok := quick.Local[*User](c, "user")
+//
+// The result will Local[T any](c *Ctx, key string) (T, bool)
+func Local[T any](c *Ctx, key string) (T, bool) {
+	v, ok := c.localValues(false)[key].(T)
+	return v, ok
+}
+
+// SetLocal stores a typed request scoped value under key,
+// to be read back with Local
+// The result will SetLocal[T any](c *Ctx, key string, value T)
+func SetLocal[T any](c *Ctx, key string, value T) {
+	c.Locals(key, value)
+}
+
+// Fresh reports whether the client's cached copy is still valid, comparing the
+// request If-None-Match and If-Modified-Since headers with the ETag and
+// Last-Modified response headers already set by the handler.
+// Following RFC 7232, If-None-Match takes precedence and If-Modified-Since is
+// only evaluated when it is absent. Only GET and HEAD requests with a 2xx or
+// 304 status can be fresh, and "Cache-Control: no-cache" forces a reload.
+// The result will Fresh() bool
+func (c *Ctx) Fresh() bool {
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		return false
+	}
+	if c.resStatus != 0 && (c.resStatus < 200 || c.resStatus > 299) && c.resStatus != http.StatusNotModified {
+		return false
+	}
+
+	noneMatch := c.Request.Header.Get("If-None-Match")
+	modifiedSince := c.Request.Header.Get("If-Modified-Since")
+	if noneMatch == "" && modifiedSince == "" {
+		return false
+	}
+
+	if strings.Contains(c.Request.Header.Get("Cache-Control"), "no-cache") {
+		return false
+	}
+
+	if noneMatch != "" {
+		if strings.TrimSpace(noneMatch) == "*" {
+			return true
+		}
+		etag := c.Response.Header().Get("ETag")
+		if etag == "" {
+			return false
+		}
+		return etagWeakMatch(noneMatch, etag)
+	}
+
+	lastModified, err := http.ParseTime(c.Response.Header().Get("Last-Modified"))
+	if err != nil {
+		return false
+	}
+	since, err := http.ParseTime(modifiedSince)
+	if err != nil {
+		return false
+	}
+	return !lastModified.After(since)
+}
+
+// Stale is the opposite of Fresh
+// The result will Stale() bool
+func (c *Ctx) Stale() bool {
+	return !c.Fresh()
+}
+
+// etagWeakMatch reports whether etag matches one of the comma separated tags
+// in list, using the weak comparison required for If-None-Match
+// Method Used Internally
+// The result will etagWeakMatch(list, etag string) bool
+func etagWeakMatch(list, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(list, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// Proxy forwards the request to the target URL and streams the response back.
+// The request path and query are appended to the target path, hop-by-hop
+// headers are stripped and X-Forwarded-For/-Host/-Proto are set. The
+// incoming X-Forwarded-For chain is only kept when the request comes from
+// Config.TrustedProxies, otherwise it starts at the peer address.
+// If the upstream cannot be reached nothing is written and the error is
+// returned, so the handler can answer e.g. 502 Bad Gateway.
+// The result will Proxy(target string) error
+func (c *Ctx) Proxy(target string) error {
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("proxy: invalid target %q", target)
+	}
+
+	trusted := isTrustedProxy(c.trustedProxies(), c.remoteIP())
+	var proxyErr error
+	rp := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(u)
+			// keep the chain of the trusted proxies in front of us
+			if trusted {
+				pr.Out.Header["X-Forwarded-For"] = pr.In.Header["X-Forwarded-For"]
+			}
+			pr.SetXForwarded()
+		},
+		FlushInterval: -1,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			proxyErr = err
+		},
+	}
+	rp.ServeHTTP(c.Response, c.Request)
+	if proxyErr != nil {
+		return fmt.Errorf("proxy: %w", proxyErr)
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package quick
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// bindSource reads the values of a tagged field from one request source
+type bindSource struct {
+	tag    string
+	lookup func(c *Ctx, name string) ([]string, bool)
+}
+
+// bindSources lists the tagged sources in precedence order:
+// later sources override the values set by earlier ones
+var bindSources = []bindSource{
+	{"form", lookupForm},
+	{"header", lookupHeader},
+	{"query", lookupQuery},
+	{"param", lookupParam},
+}
+
+// BindAll fills the struct pointed to by out from every request source in a single call.
+//
+// Sources are applied in this order, each one overriding the previous:
+//  1. body, decoded by Content-Type: JSON and XML use the `json`/`xml` tags,
+//     urlencoded and multipart forms use the `form` tag
+//  2. headers, fields tagged `header:"X-Name"`
+//  3. query string, fields tagged `query:"name"`
+//  4. path params, fields tagged `param:"id"`
+//
+// Tagged fields may be strings, booleans, integers, floats, pointers to them
+// or slices of them. Values that cannot be converted and violated `validate`
+// rules are reported in a *ValidationError naming the field and the source.
+// The result will BindAll(out interface{}) error
+func (c *Ctx) BindAll(out interface{}) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("BindAll: out must be a non-nil pointer to a struct")
+	}
+
+	if !isFormContentType(c) && len(c.loadBody()) > 0 {
+		if err := c.BodyParser(out); err != nil {
+			return bindDecodeError(err)
+		}
+	}
+
+	ve := &ValidationError{}
+	c.bindTagged(rv.Elem(), ve)
+	if len(ve.Errors) > 0 {
+		return ve
+	}
+	return validate(out, c.Logger())
+}
+
+// bindTagged walks the struct fields, including embedded structs, and sets
+// the ones tagged with a bind source
+// Method Used Internally
+// The result will bindTagged(rv reflect.Value, ve *ValidationError)
+func (c *Ctx) bindTagged(rv reflect.Value, ve *ValidationError) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		fv := rv.Field(i)
+		if field.Anonymous && fv.Kind() == reflect.Struct {
+			c.bindTagged(fv, ve)
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		for _, src := range bindSources {
+			name, ok := field.Tag.Lookup(src.tag)
+			if !ok || name == "" || name == "-" {
+				continue
+			}
+			values, found := src.lookup(c, name)
+			if !found {
+				continue
+			}
+			if err := setFieldValues(fv, values); err != nil {
+				ve.add(name, src.tag, fmt.Sprintf("invalid %s value %q for field %s", src.tag, values[0], field.Name))
+			}
+		}
+	}
+}
+
+// isFormContentType reports whether the request body is an urlencoded or multipart form
+// Method Used Internally
+// The result will isFormContentType(c *Ctx) bool
+func isFormContentType(c *Ctx) bool {
+	mediaType, _, _ := mime.ParseMediaType(c.Request.Header.Get("Content-Type"))
+	return mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data"
+}
+
+// lookupForm reads a form field from the request body
+func lookupForm(c *Ctx, name string) ([]string, bool) {
+	if !isFormContentType(c) {
+		return nil, false
+	}
+	if c.Request.PostForm == nil {
+		if strings.HasPrefix(c.Request.Header.Get("Content-Type"), "multipart/form-data") {
+			_ = c.Request.ParseMultipartForm(c.multipartMemory())
+		} else {
+			_ = c.Request.ParseForm()
+		}
+	}
+	values, ok := c.Request.PostForm[name]
+	return values, ok
+}
+
+// lookupHeader reads a request header
+func lookupHeader(c *Ctx, name string) ([]string, bool) {
+	values := c.Request.Header.Values(name)
+	return values, len(values) > 0
+}
+
+// lookupQuery reads a query string value
+func lookupQuery(c *Ctx, name string) ([]string, bool) {
+	values, ok := c.Request.URL.Query()[name]
+	return values, ok
+}
+
+// lookupParam reads a path param
+func lookupParam(c *Ctx, name string) ([]string, bool) {
+	c.loadParams()
+	for i, n := range c.paramNames {
+		if n == name {
+			return []string{c.paramValues[i]}, true
+		}
+	}
+	if v, ok := c.Params[name]; ok {
+		return []string{v}, true
+	}
+	return nil, false
+}
+
+// setFieldValues converts the raw values into the field type
+// Method Used Internally
+// The result will setFieldValues(fv reflect.Value, values []string) error
+func setFieldValues(fv reflect.Value, values []string) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.Slice:
+		slice := reflect.MakeSlice(fv.Type(), len(values), len(values))
+		for i, v := range values {
+			if err := setFieldValue(slice.Index(i), v); err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+		return nil
+	case reflect.Ptr:
+		ptr := reflect.New(fv.Type().Elem())
+		if err := setFieldValues(ptr.Elem(), values); err != nil {
+			return err
+		}
+		fv.Set(ptr)
+		return nil
+	}
+	return setFieldValue(fv, values[0])
+}
+
+// setFieldValue converts a single raw value into a scalar field
+// Method Used Internally
+// The result will setFieldValue(fv reflect.Value, value string) error
+func setFieldValue(fv reflect.Value, value string) error {
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(value)
+	case reflect.Bool:
+		// a flag present without value, e.g. "?verbose", means true
+		if value == "" {
+			fv.SetBool(true)
+			return nil
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported field type %s", fv.Type())
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package quick
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type bindAllEmbedded struct {
+	Trace string `header:"X-Trace-Id"`
+}
+
+type bindAllInput struct {
+	bindAllEmbedded
+	ID     int      `param:"id"`
+	Page   *int     `query:"page"`
+	Tags   []string `query:"tag"`
+	Debug  bool     `query:"debug"`
+	Name   string   `json:"name" form:"name"`
+	Source string   `json:"source" form:"source" query:"source"`
+}
+
+func TestCtxBindAll(t *testing.T) {
+	var got bindAllInput
+	var bindErr error
+
+	q := New()
+	q.Put("/users/:id", func(c *Ctx) error {
+		got = bindAllInput{}
+		bindErr = c.BindAll(&got)
+		return c.Status(http.StatusNoContent).Send(nil)
+	})
+
+	t.Run("json body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/users/42?page=3&tag=a&tag=b&debug&source=query",
+			strings.NewReader(`{"name":"jeff","source":"body"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Trace-Id", "abc")
+		q.ServeHTTP(httptest.NewRecorder(), req)
+
+		if bindErr != nil {
+			t.Fatalf("unexpected error: %v", bindErr)
+		}
+		if got.ID != 42 || got.Page == nil || *got.Page != 3 || got.Name != "jeff" || got.Trace != "abc" || !got.Debug {
+			t.Errorf("unexpected bind result: %+v", got)
+		}
+		if len(got.Tags) != 2 || got.Tags[0] != "a" || got.Tags[1] != "b" {
+			t.Errorf("expected tags [a b], got %v", got.Tags)
+		}
+		// query overrides the body
+		if got.Source != "query" {
+			t.Errorf("expected source from query, got %q", got.Source)
+		}
+	})
+
+	t.Run("urlencoded form", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/users/7", strings.NewReader("name=ana&source=form"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		q.ServeHTTP(httptest.NewRecorder(), req)
+
+		if bindErr != nil {
+			t.Fatalf("unexpected error: %v", bindErr)
+		}
+		if got.ID != 7 || got.Name != "ana" || got.Source != "form" {
+			t.Errorf("unexpected bind result: %+v", got)
+		}
+	})
+
+	t.Run("post and params map", func(t *testing.T) {
+		var params map[string]string
+		q.Post("/users/:id", func(c *Ctx) error {
+			got = bindAllInput{}
+			bindErr = c.BindAll(&got)
+			params = map[string]string{}
+			for k, v := range c.Params {
+				params[k] = v
+			}
+			return c.Status(http.StatusNoContent).Send(nil)
+		})
+		req := httptest.NewRequest(http.MethodPost, "/users/7", strings.NewReader(`{"name":"ana"}`))
+		req.Header.Set("Content-Type", "application/json")
+		q.ServeHTTP(httptest.NewRecorder(), req)
+
+		if bindErr != nil {
+			t.Fatalf("unexpected error: %v", bindErr)
+		}
+		if got.ID != 7 || got.Name != "ana" || params["id"] != "7" {
+			t.Errorf("expected the id param on POST, got %+v %v", got, params)
+		}
+	})
+
+	t.Run("conversion error names field and source", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/users/abc", nil)
+		q.ServeHTTP(httptest.NewRecorder(), req)
+
+		if bindErr == nil {
+			t.Fatal("expected error, got nil")
+		}
+		ve, ok := AsValidationError(bindErr)
+		if !ok || len(ve.Errors) != 1 {
+			t.Fatalf("expected a single field error, got %v", bindErr)
+		}
+		if fe := ve.Errors[0]; fe.Field != "id" || fe.Tag != "param" || !strings.Contains(fe.Message, "field ID") {
+			t.Errorf("error should name field and source, got %+v", fe)
+		}
+	})
+
+	t.Run("invalid target", func(t *testing.T) {
+		c := &Ctx{Request: httptest.NewRequest(http.MethodGet, "/", nil)}
+		var n int
+		if err := c.BindAll(&n); err == nil {
+			t.Error("expected error for non-struct target")
+		}
+	})
+}
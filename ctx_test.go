@@ -1,12 +1,30 @@
 package quick
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/http/httptrace"
+	"net/textproto"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestCtx_Bind(t *testing.T) {
@@ -779,3 +797,1389 @@ func TestCtx_Status(t *testing.T) {
 		})
 	}
 }
+
+// TestCtxPoolNoParamsBleed ensures a pooled Ctx does not carry Params or Query
+// from a previous request into the next one
+// The will test TestCtxPoolNoParamsBleed(t *testing.T)
+//
+// Run:
+//
+//	$ go test -v -race -run ^TestCtxPoolNoParamsBleed
+func TestCtxPoolNoParamsBleed(t *testing.T) {
+	q := New()
+	q.Get("/user/:id", func(c *Ctx) error {
+		return c.SendString(c.Param("id"))
+	})
+	q.Get("/static", func(c *Ctx) error {
+		if len(c.Params) != 0 || len(c.Query) != 0 || c.bodyByte != nil || c.resStatus != 0 {
+			return c.Status(500).SendString("leaked state")
+		}
+		return c.SendString("clean")
+	})
+
+	for i := 0; i < 50; i++ {
+		if _, err := q.QuickTest("GET", "/user/42?x=1", nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		data, err := q.QuickTest("GET", "/static", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if data.BodyStr() != "clean" {
+			t.Fatalf("expected 'clean', got '%s'", data.BodyStr())
+		}
+	}
+}
+
+// TestReleaseCtx ensures releaseCtx clears every field and keeps the maps allocated
+// The will test TestReleaseCtx(t *testing.T)
+//
+// Run:
+//
+//	$ go test -v -run ^TestReleaseCtx
+func TestReleaseCtx(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	c := acquireCtx(httptest.NewRecorder(), req, 10)
+	c.Params["id"] = "1"
+	c.Query["q"] = "x"
+	c.bodyByte = []byte("body")
+	c.Status(201)
+
+	releaseCtx(c)
+
+	if c.Request != nil || c.Response != nil || c.bodyByte != nil || c.resStatus != 0 || c.MoreRequests != 0 {
+		t.Errorf("expected Ctx fields to be reset, got %+v", c)
+	}
+	if c.Params == nil || len(c.Params) != 0 || c.Query == nil || len(c.Query) != 0 {
+		t.Errorf("expected empty non-nil maps, got Params=%v Query=%v", c.Params, c.Query)
+	}
+}
+
+// TestCtxParamSlice ensures Param reads the matched params slice and
+// falls back to the Params map set by mocks or middleware
+// The will test TestCtxParamSlice(t *testing.T)
+//
+// Run:
+//
+//	$ go test -v -run ^TestCtxParamSlice
+func TestCtxParamSlice(t *testing.T) {
+	c := &Ctx{Params: map[string]string{"legacy": "v"}}
+	c.setParams([]string{"x", "y"}, []string{"1", "2"})
+
+	if got := c.Param("y"); got != "2" {
+		t.Errorf("expected '2', got '%s'", got)
+	}
+	if got := c.Params["x"]; got != "1" {
+		t.Errorf("expected Params map to mirror 'x'=1, got '%s'", got)
+	}
+	if got := c.Param("legacy"); got != "v" {
+		t.Errorf("expected fallback 'v', got '%s'", got)
+	}
+	if got := c.Param("missing"); got != "" {
+		t.Errorf("expected empty string, got '%s'", got)
+	}
+}
+
+// TestCtxMultipartMixed ensures the parts written by MultipartMixed parse back
+// with the boundary announced in the Content-Type
+// The will test TestCtxMultipartMixed(t *testing.T)
+//
+// Run:
+//
+//	$ go test -v -run ^TestCtxMultipartMixed
+func TestCtxMultipartMixed(t *testing.T) {
+	q := New()
+	q.Get("/mixed", func(c *Ctx) error {
+		return c.Status(200).MultipartMixed(
+			MultipartPart{Header: map[string]string{"Content-Type": ContentTypeAppJSON}, Body: []byte(`{"id":1}`)},
+			MultipartPart{Header: map[string]string{"Content-Type": ContentTypeAppJSON}, Body: []byte(`{"id":2}`)},
+		)
+	})
+
+	data, err := q.QuickTest("GET", "/mixed", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(data.Response().Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("unexpected Content-Type %q: %v", mediaType, err)
+	}
+
+	mr := multipart.NewReader(bytes.NewReader(data.Body()), params["boundary"])
+	var bodies []string
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading part: %v", err)
+		}
+		if part.Header.Get("Content-Type") != ContentTypeAppJSON {
+			t.Errorf("unexpected part Content-Type %q", part.Header.Get("Content-Type"))
+		}
+		b, _ := io.ReadAll(part)
+		bodies = append(bodies, string(b))
+	}
+
+	if !reflect.DeepEqual(bodies, []string{`{"id":1}`, `{"id":2}`}) {
+		t.Errorf("unexpected parts %v", bodies)
+	}
+}
+
+// TestCtxJSONP ensures JSONP wraps the JSON in the callback and rejects unsafe names
+// The will test TestCtxJSONP(t *testing.T)
+//
+// Run:
+//
+//	$ go test -v -run ^TestCtxJSONP
+func TestCtxJSONP(t *testing.T) {
+	q := New()
+	q.Get("/data", func(c *Ctx) error {
+		return c.JSONP(map[string]int{"id": 1})
+	})
+	q.Get("/named", func(c *Ctx) error {
+		return c.JSONP(map[string]int{"id": 1}, "app.render")
+	})
+
+	tests := []struct {
+		name     string
+		uri      string
+		wantCode int
+		wantBody string
+	}{
+		{"query callback", "/data?callback=cb", 200, `/**/ cb({"id":1});`},
+		{"default callback", "/data", 200, `/**/ callback({"id":1});`},
+		{"argument callback", "/named?callback=cb", 200, `/**/ app.render({"id":1});`},
+		{"unsafe callback", "/data?callback=alert(1)//", 500, "invalid JSONP callback name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := q.QuickTest("GET", tt.uri, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if data.StatusCode() != tt.wantCode || data.BodyStr() != tt.wantBody {
+				t.Errorf("expected %d %q, got %d %q", tt.wantCode, tt.wantBody, data.StatusCode(), data.BodyStr())
+			}
+			if tt.wantCode == 200 && data.Response().Header.Get("Content-Type") != "application/javascript; charset=utf-8" {
+				t.Errorf("unexpected Content-Type %q", data.Response().Header.Get("Content-Type"))
+			}
+		})
+	}
+}
+
+// TestCtxSetHeaderInjection ensures Set strips CR/LF and SetHeader rejects them
+// The will test TestCtxSetHeaderInjection(t *testing.T)
+//
+// Run:
+//
+//	$ go test -v -run ^TestCtxSetHeaderInjection
+func TestCtxSetHeaderInjection(t *testing.T) {
+	rec := httptest.NewRecorder()
+	c := &Ctx{Response: rec}
+	evil := "value\r\nSet-Cookie: session=hijacked"
+
+	c.Set("X-Test", evil)
+	c.Append("X-Append", evil)
+
+	for _, key := range []string{"X-Test", "X-Append"} {
+		if got := rec.Header().Get(key); got != "valueSet-Cookie: session=hijacked" {
+			t.Errorf("expected %s to be sanitized, got %q", key, got)
+		}
+	}
+	if rec.Header().Get("Set-Cookie") != "" {
+		t.Errorf("expected no injected Set-Cookie header")
+	}
+
+	if err := c.SetHeader("X-Strict", evil); err == nil {
+		t.Errorf("expected SetHeader to reject CR/LF")
+	}
+	if rec.Header().Get("X-Strict") != "" {
+		t.Errorf("expected rejected header not to be set")
+	}
+	if err := c.SetHeader("X-Strict", "ok"); err != nil || rec.Header().Get("X-Strict") != "ok" {
+		t.Errorf("expected valid header to be set, err=%v", err)
+	}
+}
+
+func TestCtxRange(t *testing.T) {
+	tests := []struct {
+		header  string
+		size    int64
+		want    []RangeSpec
+		wantErr error
+	}{
+		{"", 100, nil, nil},
+		{"bytes=0-9", 100, []RangeSpec{{0, 9}}, nil},
+		{"bytes=90-", 100, []RangeSpec{{90, 99}}, nil},
+		{"bytes=-10", 100, []RangeSpec{{90, 99}}, nil},
+		{"bytes=-200", 100, []RangeSpec{{0, 99}}, nil},
+		{"bytes=50-500", 100, []RangeSpec{{50, 99}}, nil},
+		{"bytes=0-1, 10-19", 100, []RangeSpec{{0, 1}, {10, 19}}, nil},
+		{"bytes=0-1, 200-300", 100, []RangeSpec{{0, 1}}, nil},
+		{"bytes=100-", 100, nil, ErrRangeNotSatisfiable},
+		{"bytes=-0", 100, nil, ErrRangeNotSatisfiable},
+		{"bytes=9-0", 100, nil, ErrRangeMalformed},
+		{"bytes=a-b", 100, nil, ErrRangeMalformed},
+		{"bytes=", 100, nil, ErrRangeMalformed},
+		{"items=0-1", 100, nil, ErrRangeMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Range", tt.header)
+			}
+			c := &Ctx{Request: req}
+
+			got, err := c.Range(tt.size)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if n := (RangeSpec{Start: 0, End: 1023}).Length(); n != 1024 {
+		t.Errorf("expected length 1024, got %d", n)
+	}
+}
+
+func TestCtxLocals(t *testing.T) {
+	type user struct{ Name string }
+
+	c := &Ctx{}
+	if v := c.Locals("missing"); v != nil {
+		t.Errorf("expected nil for missing key, got %v", v)
+	}
+
+	c.Locals("role", "admin")
+	if v := c.Locals("role"); v != "admin" {
+		t.Errorf("expected admin, got %v", v)
+	}
+
+	SetLocal(c, "user", &user{Name: "jeff"})
+	u, ok := Local[*user](c, "user")
+	if !ok || u.Name != "jeff" {
+		t.Errorf("expected typed user, got %v %v", u, ok)
+	}
+
+	if _, ok := Local[string](c, "user"); ok {
+		t.Error("expected ok=false for mismatched type")
+	}
+	if _, ok := Local[int](c, "missing"); ok {
+		t.Error("expected ok=false for missing key")
+	}
+
+	releaseCtx(c)
+	c = acquireCtx(nil, nil, 0)
+	defer releaseCtx(c)
+	if v := c.Locals("role"); v != nil {
+		t.Errorf("locals leaked across requests: %v", v)
+	}
+}
+
+func TestCtxFresh(t *testing.T) {
+	lastModified := "Wed, 21 Oct 2015 07:28:00 GMT"
+	tests := []struct {
+		name      string
+		method    string
+		reqHeader map[string]string
+		resHeader map[string]string
+		status    int
+		want      bool
+	}{
+		{"no conditional headers", "GET", nil, map[string]string{"ETag": `"v1"`}, 0, false},
+		{"etag match", "GET", map[string]string{"If-None-Match": `"v1"`}, map[string]string{"ETag": `"v1"`}, 0, true},
+		{"etag in list", "GET", map[string]string{"If-None-Match": `"v0", "v1"`}, map[string]string{"ETag": `"v1"`}, 0, true},
+		{"weak etag match", "HEAD", map[string]string{"If-None-Match": `W/"v1"`}, map[string]string{"ETag": `"v1"`}, 0, true},
+		{"etag mismatch", "GET", map[string]string{"If-None-Match": `"v0"`}, map[string]string{"ETag": `"v1"`}, 0, false},
+		{"etag wildcard", "GET", map[string]string{"If-None-Match": "*"}, nil, 0, true},
+		{"etag missing on response", "GET", map[string]string{"If-None-Match": `"v1"`}, nil, 0, false},
+		{"etag takes precedence over date", "GET",
+			map[string]string{"If-None-Match": `"v0"`, "If-Modified-Since": lastModified},
+			map[string]string{"ETag": `"v1"`, "Last-Modified": lastModified}, 0, false},
+		{"not modified since", "GET", map[string]string{"If-Modified-Since": lastModified}, map[string]string{"Last-Modified": lastModified}, 0, true},
+		{"modified since", "GET", map[string]string{"If-Modified-Since": "Tue, 20 Oct 2015 07:28:00 GMT"}, map[string]string{"Last-Modified": lastModified}, 0, false},
+		{"invalid date", "GET", map[string]string{"If-Modified-Since": "yesterday"}, map[string]string{"Last-Modified": lastModified}, 0, false},
+		{"no-cache", "GET", map[string]string{"If-None-Match": `"v1"`, "Cache-Control": "no-cache"}, map[string]string{"ETag": `"v1"`}, 0, false},
+		{"post is never fresh", "POST", map[string]string{"If-None-Match": `"v1"`}, map[string]string{"ETag": `"v1"`}, 0, false},
+		{"error status", "GET", map[string]string{"If-None-Match": `"v1"`}, map[string]string{"ETag": `"v1"`}, 404, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", nil)
+			for k, v := range tt.reqHeader {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			for k, v := range tt.resHeader {
+				rec.Header().Set(k, v)
+			}
+			c := &Ctx{Request: req, Response: rec, resStatus: tt.status}
+
+			if got := c.Fresh(); got != tt.want {
+				t.Errorf("Fresh() = %v, want %v", got, tt.want)
+			}
+			if c.Stale() == tt.want {
+				t.Errorf("Stale() should be the opposite of Fresh()")
+			}
+		})
+	}
+}
+
+func TestCtxSendStatus(t *testing.T) {
+	rec := httptest.NewRecorder()
+	c := &Ctx{Response: rec}
+	if err := c.SendStatus(http.StatusNotModified); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("expected empty 304, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	c = &Ctx{Response: rec}
+	_ = c.SendStatus(http.StatusNotFound)
+	if rec.Code != http.StatusNotFound || rec.Body.String() != "Not Found" {
+		t.Errorf("expected 404 Not Found, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestCtxMultipartReader(t *testing.T) {
+	const fileSize = 8 << 20 // larger than the default MaxBodySize
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		_ = mw.WriteField("name", "video")
+		part, _ := mw.CreateFormFile("file", "video.bin")
+		chunk := bytes.Repeat([]byte("x"), 32<<10)
+		for written := 0; written < fileSize; written += len(chunk) {
+			if _, err := part.Write(chunk); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		pw.CloseWithError(mw.Close())
+	}()
+
+	var buffered int
+	sizes := map[string]int64{}
+	q := New()
+	q.Post("/upload", func(c *Ctx) error {
+		buffered = len(c.bodyByte)
+		mr, err := c.MultipartReader()
+		if err != nil {
+			return c.Status(http.StatusBadRequest).SendString(err.Error())
+		}
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return c.Status(http.StatusBadRequest).SendString(err.Error())
+			}
+			n, _ := io.Copy(io.Discard, part)
+			sizes[part.FormName()] = n
+		}
+		return c.Status(http.StatusOK).SendString("ok")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", pr)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec := httptest.NewRecorder()
+	q.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if buffered != 0 {
+		t.Errorf("multipart body should not be buffered, got %d bytes", buffered)
+	}
+	if sizes["name"] != 5 || sizes["file"] != fileSize {
+		t.Errorf("unexpected part sizes: %v", sizes)
+	}
+}
+
+func TestCtxMultipartBodyOnDemand(t *testing.T) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	_ = mw.WriteField("name", "quick")
+	_ = mw.Close()
+	raw := buf.String()
+
+	var body, value string
+	q := New()
+	q.Put("/form", func(c *Ctx) error {
+		body = c.BodyString()
+		form, err := c.MultipartForm()
+		if err != nil {
+			return err
+		}
+		value = form.Value["name"][0]
+		return nil
+	})
+
+	req := httptest.NewRequest(http.MethodPut, "/form", strings.NewReader(raw))
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	q.ServeHTTP(httptest.NewRecorder(), req)
+
+	if body != raw {
+		t.Errorf("expected buffered body on demand, got %q", body)
+	}
+	if value != "quick" {
+		t.Errorf("expected form value after reading body, got %q", value)
+	}
+}
+
+func TestCtxParamFromRequestContext(t *testing.T) {
+	var got string
+	q := New()
+	q.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c := &Ctx{Response: w, Request: r}
+			got = c.Param("id")
+			next.ServeHTTP(w, r)
+		})
+	})
+	q.Get("/users/:id", func(c *Ctx) error { return nil })
+
+	q.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	if got != "42" {
+		t.Errorf("expected middleware Ctx to read param 42, got %q", got)
+	}
+}
+
+func TestCtxRouteAccessors(t *testing.T) {
+	var path, method, pattern, mwPattern string
+	q := New()
+	q.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mwPattern = (&Ctx{Request: r}).RoutePattern()
+			next.ServeHTTP(w, r)
+		})
+	})
+	q.Get("/users/:id/posts/{post:[0-9]+}", func(c *Ctx) error {
+		path, method, pattern = c.Path(), c.Method(), c.RoutePattern()
+		return nil
+	})
+	q.Get("/health", func(c *Ctx) error {
+		pattern = c.RoutePattern()
+		return nil
+	})
+
+	q.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42/posts/7", nil))
+	if path != "/users/42/posts/7" || method != http.MethodGet || pattern != "/users/:id/posts/{post:[0-9]+}" {
+		t.Errorf("unexpected accessors: path=%q method=%q pattern=%q", path, method, pattern)
+	}
+	if mwPattern != pattern {
+		t.Errorf("middleware should see pattern %q, got %q", pattern, mwPattern)
+	}
+
+	q.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	if pattern != "/health" {
+		t.Errorf("expected /health, got %q", pattern)
+	}
+
+	c := &Ctx{Request: httptest.NewRequest(http.MethodGet, "/unmatched", nil)}
+	if c.RoutePattern() != "" {
+		t.Errorf("expected empty pattern without a matched route, got %q", c.RoutePattern())
+	}
+}
+
+func TestCtxProxy(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Upstream-Path", r.URL.RequestURI())
+		w.Header().Set("X-Upstream-Forwarded-For", r.Header.Get("X-Forwarded-For"))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(body)
+	}))
+	defer up.Close()
+
+	q := New()
+	q.Put("/legacy/:id", func(c *Ctx) error {
+		return c.Proxy(up.URL + "/api")
+	})
+	q.Get("/down", func(c *Ctx) error {
+		if err := c.Proxy("http://127.0.0.1:1"); err != nil {
+			return c.Status(http.StatusBadGateway).SendString("bad gateway")
+		}
+		return nil
+	})
+
+	req := httptest.NewRequest(http.MethodPut, "/legacy/7?x=1", strings.NewReader("payload"))
+	req.Header.Set("X-Forwarded-For", "10.0.0.1")
+	rec := httptest.NewRecorder()
+	q.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusCreated || rec.Body.String() != "payload" {
+		t.Fatalf("expected 201 payload, got %d %q", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("X-Upstream-Path"); got != "/api/legacy/7?x=1" {
+		t.Errorf("expected joined upstream path, got %q", got)
+	}
+	if got := rec.Header().Get("X-Upstream-Forwarded-For"); got != "192.0.2.1" {
+		t.Errorf("expected X-Forwarded-For with client address only, got %q", got)
+	}
+
+	trusted := New(Config{TrustedProxies: []string{"192.0.2.1"}})
+	trusted.Get("/legacy", func(c *Ctx) error {
+		return c.Proxy(up.URL)
+	})
+	req = httptest.NewRequest(http.MethodGet, "/legacy", nil)
+	req.Header.Set("X-Forwarded-For", "10.0.0.1")
+	rec = httptest.NewRecorder()
+	trusted.ServeHTTP(rec, req)
+	if got := rec.Header().Get("X-Upstream-Forwarded-For"); got != "10.0.0.1, 192.0.2.1" {
+		t.Errorf("expected the chain of a trusted proxy to be kept, got %q", got)
+	}
+
+	rec = httptest.NewRecorder()
+	q.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/down", nil))
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("expected handler to answer 502, got %d", rec.Code)
+	}
+
+	c := &Ctx{Request: httptest.NewRequest(http.MethodGet, "/", nil), Response: httptest.NewRecorder()}
+	if err := c.Proxy("not a url"); err == nil {
+		t.Error("expected error for invalid target")
+	}
+}
+
+func TestCtxParamArray(t *testing.T) {
+	var got [][]string
+	q := New()
+	q.Get("/users/:ids", func(c *Ctx) error {
+		got = append(got, c.ParamArray("ids", ","))
+		return c.SendStatus(StatusNoContent)
+	})
+	q.Get("/tags/:tags/:missing?", func(c *Ctx) error {
+		got = append(got, c.ParamArray("tags", "+"), c.ParamArray("missing", ","))
+		return c.SendStatus(StatusNoContent)
+	})
+
+	for _, path := range []string{"/users/1,2,3", "/users/7,", "/tags/go+web"} {
+		if _, err := q.QuickTest(MethodGet, path, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := [][]string{{"1", "2", "3"}, {"7"}, {"go", "web"}, nil}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestCtxResponseSize(t *testing.T) {
+	var sizes []int
+	q := New()
+	// counts like a logger would, outside a middleware that shrinks the body
+	q.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+			sizes = append(sizes, (&Ctx{Request: r}).ResponseSize())
+		})
+	})
+	q.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("short") == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			rec := httptest.NewRecorder()
+			next.ServeHTTP(rec, r)
+			w.Write(rec.Body.Bytes()[:3])
+		})
+	})
+	q.Get("/data", func(c *Ctx) error {
+		c.Response.(http.Flusher).Flush()
+		return c.Status(StatusOK).SendString("0123456789")
+	})
+
+	for _, uri := range []string{"/data", "/data?short=1"} {
+		if _, err := q.QuickTest(MethodGet, uri, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !reflect.DeepEqual(sizes, []int{10, 3}) {
+		t.Errorf("expected sizes [10 3], got %v", sizes)
+	}
+	if (&Ctx{}).ResponseSize() != 0 {
+		t.Error("expected 0 outside a route")
+	}
+}
+
+func TestCtxWriteEarlyHints(t *testing.T) {
+	handler := func(c *Ctx) error {
+		if err := c.WriteEarlyHints(http.Header{"Link": {"</style.css>; rel=preload; as=style"}}); err != nil {
+			return err
+		}
+		return c.Status(StatusOK).SendString("page")
+	}
+
+	for _, cfg := range []Config{{}, {RequestTimeout: time.Second}} {
+		q := New(cfg)
+		q.Get("/page", handler)
+		ts, err := q.NewTestServer()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ts.Close()
+
+		var events []string
+		trace := &httptrace.ClientTrace{
+			Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+				events = append(events, fmt.Sprintf("%d %s", code, header.Get("Link")))
+				return nil
+			},
+		}
+		req, _ := http.NewRequestWithContext(httptrace.WithClientTrace(context.Background(), trace), MethodGet, ts.URL+"/page", nil)
+		resp, err := ts.Client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		events = append(events, fmt.Sprintf("%d %s", resp.StatusCode, body))
+
+		want := []string{"103 </style.css>; rel=preload; as=style", "200 page"}
+		if cfg.RequestTimeout > 0 {
+			// buffered responses cannot carry informational statuses
+			want = want[1:]
+		}
+		if !reflect.DeepEqual(events, want) {
+			t.Errorf("RequestTimeout %v: expected %q, got %q", cfg.RequestTimeout, want, events)
+		}
+		if resp.Header.Get("Link") == "" {
+			t.Error("expected the Link header on the final response")
+		}
+	}
+
+	c := &Ctx{}
+	if err := c.WriteEarlyHints(nil); err == nil {
+		t.Error("expected an error without a response writer")
+	}
+}
+
+type negotiateUser struct {
+	XMLName struct{} `json:"-" xml:"user"`
+	Name    string   `json:"name" xml:"name"`
+}
+
+func (u negotiateUser) String() string { return "user " + u.Name }
+
+func TestCtxNegotiate(t *testing.T) {
+	q := New()
+	q.Get("/user", func(c *Ctx) error {
+		return c.Status(StatusOK).Negotiate(negotiateUser{Name: "jeff"})
+	})
+
+	tests := []struct {
+		accept      string
+		contentType string
+		body        string
+	}{
+		{"", "application/json; charset=utf-8", `{"name":"jeff"}`},
+		{"application/json", "application/json; charset=utf-8", `{"name":"jeff"}`},
+		{"application/xml", "application/xml; charset=utf-8", `<user><name>jeff</name></user>`},
+		{"text/html, text/xml;q=0.9, */*;q=0.8", "text/xml; charset=utf-8", `<user><name>jeff</name></user>`},
+		{"application/json;q=0.5, text/plain", "text/plain; charset=utf-8", "user jeff"},
+		{"image/png", "application/json; charset=utf-8", `{"name":"jeff"}`},
+		{"application/xml;q=0, application/json;q=0.1", "application/json; charset=utf-8", `{"name":"jeff"}`},
+	}
+	for _, tt := range tests {
+		data, err := q.QuickTest(MethodGet, "/user", map[string]string{"Accept": tt.accept})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ct := data.Response().Header.Get("Content-Type"); ct != tt.contentType || data.BodyStr() != tt.body {
+			t.Errorf("Accept %q: expected %s %s, got %s %s", tt.accept, tt.contentType, tt.body, ct, data.BodyStr())
+		}
+		if data.Response().Header.Get("Vary") != "Accept" {
+			t.Errorf("Accept %q: expected Vary: Accept", tt.accept)
+		}
+	}
+}
+
+func TestCtxJSONPretty(t *testing.T) {
+	q := New()
+	q.Get("/debug/config", func(c *Ctx) error {
+		return c.Status(StatusOK).JSONPretty(map[string]interface{}{"name": "quick", "ports": []int{80}}, "\t")
+	})
+	q.Get("/debug/default", func(c *Ctx) error {
+		return c.Status(StatusOK).JSONPretty(map[string]int{"a": 1}, "")
+	})
+
+	data, err := q.QuickTest(MethodGet, "/debug/config", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n\t\"name\": \"quick\",\n\t\"ports\": [\n\t\t80\n\t]\n}"
+	if data.BodyStr() != want || data.Response().Header.Get("Content-Type") != ContentTypeAppJSON {
+		t.Errorf("expected indented JSON, got %q %v", data.BodyStr(), data.Response().Header)
+	}
+
+	data, _ = q.QuickTest(MethodGet, "/debug/default", nil)
+	if data.BodyStr() != "{\n  \"a\": 1\n}" {
+		t.Errorf("expected two space indent, got %q", data.BodyStr())
+	}
+
+	c := &Ctx{Response: httptest.NewRecorder()}
+	if err := c.JSONPretty(make(chan int), ""); err == nil {
+		t.Error("expected an error for values JSON cannot encode")
+	}
+}
+
+func TestCtxWrite(t *testing.T) {
+	var _ io.Writer = (*Ctx)(nil)
+
+	q := New()
+	q.Get("/export.csv", func(c *Ctx) error {
+		c.Set("Content-Type", "text/csv")
+		w := csv.NewWriter(c.Status(StatusCreated))
+		for _, row := range [][]string{{"id", "name"}, {"1", "quick"}, {"2", "go"}} {
+			if err := w.Write(row); err != nil {
+				return err
+			}
+			w.Flush()
+		}
+		return w.Error()
+	})
+
+	data, err := q.QuickTest(MethodGet, "/export.csv", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.StatusCode() != StatusCreated || data.BodyStr() != "id,name\n1,quick\n2,go\n" {
+		t.Errorf("expected 201 with the CSV body, got %d %q", data.StatusCode(), data.BodyStr())
+	}
+}
+
+func TestCtxJSONStream(t *testing.T) {
+	q := New()
+	q.Get("/rows", func(c *Ctx) error {
+		s := c.Status(StatusOK).JSONStream()
+		for i := 0; i < 250; i++ {
+			if err := s.Encode(map[string]int{"id": i}); err != nil {
+				return err
+			}
+		}
+		if err := s.Encode(make(chan int)); err == nil {
+			t.Error("expected an error for values JSON cannot encode")
+		}
+		return s.Close()
+	})
+	q.Get("/empty", func(c *Ctx) error {
+		return c.JSONStream().Close()
+	})
+
+	rec := httptest.NewRecorder()
+	q.ServeHTTP(rec, httptest.NewRequest(MethodGet, "/rows", nil))
+	var rows []map[string]int
+	if err := json.Unmarshal(rec.Body.Bytes(), &rows); err != nil {
+		t.Fatalf("expected a valid JSON array, got %v: %.80s", err, rec.Body.String())
+	}
+	if len(rows) != 250 || rows[249]["id"] != 249 {
+		t.Errorf("expected 250 rows in order, got %d", len(rows))
+	}
+	if !rec.Flushed || rec.Header().Get("Content-Type") != ContentTypeAppJSON {
+		t.Errorf("expected a flushed JSON response, got flushed=%v %v", rec.Flushed, rec.Header())
+	}
+
+	data, _ := q.QuickTest(MethodGet, "/empty", nil)
+	if data.BodyStr() != "[]" {
+		t.Errorf("expected an empty array, got %q", data.BodyStr())
+	}
+}
+
+func TestCtxStartTime(t *testing.T) {
+	q := New()
+	q.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if (&Ctx{Request: r}).StartTime().IsZero() {
+				t.Error("expected the start time to be visible to middlewares")
+			}
+			time.Sleep(20 * time.Millisecond)
+			next.ServeHTTP(w, r)
+		})
+	})
+	before := time.Now()
+	q.Get("/timed", func(c *Ctx) error {
+		if c.StartTime().Before(before) || c.Latency() < 20*time.Millisecond {
+			t.Errorf("expected latency to include middlewares, got start %v latency %v", c.StartTime(), c.Latency())
+		}
+		c.Set("Server-Timing", fmt.Sprintf("app;dur=%.1f", float64(c.Latency().Microseconds())/1000))
+		return c.Status(StatusOK).SendString("ok")
+	})
+
+	data, err := q.QuickTest(MethodGet, "/timed", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(data.Response().Header.Get("Server-Timing"), "app;dur=") {
+		t.Errorf("expected a Server-Timing header, got %v", data.Response().Header)
+	}
+
+	c := &Ctx{}
+	if !c.StartTime().IsZero() || c.Latency() != 0 {
+		t.Errorf("expected zero values outside a route, got %v %v", c.StartTime(), c.Latency())
+	}
+}
+
+func TestCtxAddServerTiming(t *testing.T) {
+	q := New()
+	q.Get("/page", func(c *Ctx) error {
+		c.AddServerTiming("db", 53*time.Millisecond, "Postgres")
+		c.AddServerTiming("render", 1500*time.Microsecond, "")
+		c.AddServerTiming("cache hit", -1, `say "hi"`)
+		return c.Status(StatusOK).SendString("ok")
+	})
+
+	data, err := q.QuickTest(MethodGet, "/page", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `db;dur=53;desc="Postgres", render;dur=1.5, cache_hit;desc="say \"hi\""`
+	if got := data.Response().Header.Get("Server-Timing"); got != want {
+		t.Errorf("expected Server-Timing %q, got %q", want, got)
+	}
+}
+
+func TestCtxVerifyHMAC(t *testing.T) {
+	const secret = "It's a Secret to Everybody"
+	payload := `{"action":"opened","number":1}`
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	sum := mac.Sum(nil)
+
+	type event struct {
+		Action string `json:"action"`
+	}
+	q := New()
+	q.Post("/webhook", func(c *Ctx) error {
+		ok, err := c.VerifyHMAC(c.Request.URL.Query().Get("header"), secret, sha256.New)
+		if err != nil {
+			return c.Status(StatusBadRequest).SendString(err.Error())
+		}
+		if !ok {
+			return c.Status(StatusUnauthorized).SendString("bad signature")
+		}
+		var e event
+		if err := c.BodyParser(&e); err != nil {
+			return err
+		}
+		return c.Status(StatusOK).SendString(e.Action)
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		status int
+		body   string
+	}{
+		{name: "github", header: "X-Hub-Signature-256", value: "sha256=" + hex.EncodeToString(sum), status: StatusOK, body: "opened"},
+		{name: "base64", header: "X-Shopify-Hmac-Sha256", value: base64.StdEncoding.EncodeToString(sum), status: StatusOK, body: "opened"},
+		{name: "wrong", header: "X-Hub-Signature-256", value: "sha256=" + strings.Repeat("00", 32), status: StatusUnauthorized},
+		{name: "missing", header: "X-Hub-Signature-256", status: StatusBadRequest},
+		{name: "garbage", header: "X-Hub-Signature-256", value: "sha256=!!", status: StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		headers := map[string]string{"Content-Type": ContentTypeAppJSON}
+		if tt.value != "" {
+			headers[tt.header] = tt.value
+		}
+		data, err := q.QuickTest(MethodPost, "/webhook?header="+tt.header, headers, []byte(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data.StatusCode() != tt.status || (tt.body != "" && data.BodyStr() != tt.body) {
+			t.Errorf("%s: expected %d %q, got %d %q", tt.name, tt.status, tt.body, data.StatusCode(), data.BodyStr())
+		}
+	}
+}
+
+func TestCtxParamsMap(t *testing.T) {
+	var logged []string
+	q := New()
+	q.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c := &Ctx{Request: r}
+			for _, name := range c.ParamNames() {
+				logged = append(logged, name+"="+c.Param(name))
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	q.Get("/orgs/:org/users/:id", func(c *Ctx) error {
+		params := c.ParamsMap()
+		if len(params) != 2 || params["org"] != "acme" || params["id"] != "42" {
+			t.Errorf("expected both params, got %v", params)
+		}
+		params["id"] = "changed"
+		return c.Status(StatusOK).SendString(c.Param("id"))
+	})
+
+	data, err := q.QuickTest(MethodGet, "/orgs/acme/users/42", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.BodyStr() != "42" {
+		t.Errorf("expected ParamsMap to return a copy, got %q", data.BodyStr())
+	}
+	if strings.Join(logged, " ") != "org=acme id=42" {
+		t.Errorf("expected params in path order, got %v", logged)
+	}
+
+	c := &Ctx{Params: map[string]string{"b": "2", "a": "1"}}
+	if names := c.ParamNames(); strings.Join(names, ",") != "a,b" {
+		t.Errorf("expected sorted names without a route, got %v", names)
+	}
+}
+
+func TestCtxVary(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []string
+		fields   []string
+		want     []string
+	}{
+		{"empty", nil, []string{"Accept"}, []string{"Accept"}},
+		{"several", nil, []string{"Accept", "Accept-Encoding"}, []string{"Accept", "Accept-Encoding"}},
+		{"duplicate", []string{"Accept"}, []string{"accept"}, []string{"Accept"}},
+		{"comma separated", []string{"Origin, Accept"}, []string{"Accept", "Accept-Language"}, []string{"Origin, Accept", "Accept-Language"}},
+		{"repeated argument", nil, []string{"Accept", "Accept"}, []string{"Accept"}},
+		{"star", []string{"*"}, []string{"Accept"}, []string{"*"}},
+		{"blank", nil, []string{" ", ""}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			for _, v := range tt.existing {
+				rec.Header().Add("Vary", v)
+			}
+			c := &Ctx{Response: rec}
+			c.Vary(tt.fields...)
+			if got := rec.Header().Values("Vary"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCtxLogger(t *testing.T) {
+	var buf bytes.Buffer
+	q := New(Config{Logger: slog.New(slog.NewJSONHandler(&buf, nil))})
+	q.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := WithLogAttrs(r.Context(), slog.String("tenant", "acme"))
+			ctx = WithLogAttrs(ctx, slog.Int("attempt", 2))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+	q.Get("/orders/:id", func(c *Ctx) error {
+		c.Logger().Info("order loaded")
+		return c.Status(StatusOK).SendString("ok")
+	})
+	q.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders/9", nil))
+
+	line := buf.String()
+	want := `"msg":"order loaded","method":"GET","path":"/orders/9","route":"/orders/:id","tenant":"acme","attempt":2`
+	if !strings.Contains(line, want) {
+		t.Errorf("expected %s in the log line, got %s", want, line)
+	}
+}
+
+func TestCtxSetContentLength(t *testing.T) {
+	payload := strings.Repeat("x", 64*1024)
+	q := New()
+	q.Get("/download", func(c *Ctx) error {
+		c.SetContentLength(int64(len(payload)))
+		c.Status(StatusOK)
+		for i := 0; i < len(payload); i += 8 * 1024 {
+			if _, err := c.Write([]byte(payload[i : i+8*1024])); err != nil {
+				return err
+			}
+			if err := http.NewResponseController(c.Response).Flush(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	q.Get("/unknown", func(c *Ctx) error {
+		c.SetContentLength(10)
+		c.SetContentLength(-1)
+		c.Status(StatusOK)
+		http.NewResponseController(c.Response).Flush()
+		_, err := c.Write([]byte("streamed"))
+		return err
+	})
+	ts := httptest.NewServer(q)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.ContentLength != int64(len(payload)) || len(resp.TransferEncoding) != 0 || string(body) != payload {
+		t.Errorf("expected Content-Length %d without chunking, got %d %v (%d bytes)", len(payload), resp.ContentLength, resp.TransferEncoding, len(body))
+	}
+
+	resp, err = http.Get(ts.URL + "/unknown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.ContentLength != -1 || len(resp.TransferEncoding) == 0 || resp.TransferEncoding[0] != "chunked" {
+		t.Errorf("expected a chunked response, got %d %v", resp.ContentLength, resp.TransferEncoding)
+	}
+}
+
+func TestCtxRoute(t *testing.T) {
+	q := New()
+	q.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c := &Ctx{Response: w, Request: r}
+			for _, perm := range c.Route().Requires {
+				if !strings.Contains(r.Header.Get("X-Roles"), perm) {
+					// #nosec G104
+					c.Status(StatusForbidden).SendString("forbidden")
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	q.Get("/users/:id", func(c *Ctx) error {
+		return c.Status(StatusOK).SendString(c.Route().Method + " " + c.RoutePattern())
+	})
+	q.Delete("/users/:id", func(c *Ctx) error {
+		return c.Status(StatusOK).SendString("deleted")
+	}).Requires("admin").Tags("users")
+
+	tests := []struct {
+		method string
+		roles  string
+		status int
+		body   string
+	}{
+		{MethodGet, "", StatusOK, "GET /users/:id"},
+		{MethodDelete, "", StatusForbidden, "forbidden"},
+		{MethodDelete, "admin", StatusOK, "deleted"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/users/42", nil)
+		req.Header.Set("X-Roles", tt.roles)
+		rec := httptest.NewRecorder()
+		q.ServeHTTP(rec, req)
+		if rec.Code != tt.status || rec.Body.String() != tt.body {
+			t.Errorf("%s %q: expected %d %s, got %d %s", tt.method, tt.roles, tt.status, tt.body, rec.Code, rec.Body.String())
+		}
+	}
+
+	route, _ := q.Route(MethodDelete, "/users/:id")
+	if strings.Join(route.Requires, ",") != "admin" || strings.Join(route.Tags, ",") != "users" {
+		t.Errorf("expected the declared permissions and tags, got %+v", route)
+	}
+	if (&Ctx{}).Route() != nil {
+		t.Error("expected no route outside a Quick route")
+	}
+}
+
+func TestCtxLocalsShared(t *testing.T) {
+	q := New()
+	q.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c := &Ctx{Response: w, Request: r}
+			SetLocal(c, "user", "jeff")
+			next.ServeHTTP(w, r)
+		})
+	})
+	q.Get("/me", func(c *Ctx) error {
+		user, ok := Local[string](c, "user")
+		if !ok {
+			return c.Status(StatusUnauthorized).SendString("anonymous")
+		}
+		return c.Status(StatusOK).SendString(user)
+	})
+
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		q.ServeHTTP(rec, httptest.NewRequest(MethodGet, "/me", nil))
+		if rec.Code != StatusOK || rec.Body.String() != "jeff" {
+			t.Errorf("expected the value stored by the middleware, got %d %s", rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestCtxApplyFields(t *testing.T) {
+	type address struct {
+		City    string `json:"city"`
+		Country string `json:"country"`
+	}
+	type user struct {
+		ID      int64   `json:"id"`
+		Name    string  `json:"name"`
+		Email   string  `json:"email"`
+		Address address `json:"address"`
+	}
+	users := []user{
+		{ID: 9007199254740993, Name: "jeff", Email: "jeff@example.com", Address: address{"Recife", "BR"}},
+		{ID: 2, Name: "ana", Email: "ana@example.com", Address: address{"Lisboa", "PT"}},
+	}
+
+	tests := []struct {
+		query string
+		v     interface{}
+		want  string
+	}{
+		{"?fields=id,email", users, `[{"email":"jeff@example.com","id":9007199254740993},{"email":"ana@example.com","id":2}]`},
+		{"?fields=name,address.city,unknown", users[0], `{"address":{"city":"Recife"},"name":"jeff"}`},
+		{"?fields=address.city,address", users[1], `{"address":{"city":"Lisboa","country":"PT"}}`},
+		{"?fields=id", map[string]int{"id": 1, "count": 2}, `{"id":1}`},
+		{"?fields=,", users[1], `{"id":2,"name":"ana","email":"ana@example.com","address":{"city":"Lisboa","country":"PT"}}`},
+		{"", users[1], `{"id":2,"name":"ana","email":"ana@example.com","address":{"city":"Lisboa","country":"PT"}}`},
+	}
+	for _, tt := range tests {
+		c := &Ctx{Request: httptest.NewRequest(MethodGet, "/users"+tt.query, nil)}
+		b, err := json.Marshal(c.ApplyFields(tt.v))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != tt.want {
+			t.Errorf("%q: expected %s, got %s", tt.query, tt.want, b)
+		}
+	}
+
+	c := &Ctx{Request: httptest.NewRequest(MethodGet, "/?fields=id", nil)}
+	if ch := make(chan int); c.ApplyFields(ch) != ch {
+		t.Error("expected values that cannot be encoded to be returned unchanged")
+	}
+}
+
+func TestCtxSSE(t *testing.T) {
+	rec := httptest.NewRecorder()
+	c := &Ctx{Response: rec, Request: httptest.NewRequest(MethodGet, "/events", nil)}
+	s := c.Status(StatusOK).SSE()
+	if err := s.Send(SSEvent{ID: "1\n", Event: "price", Data: "a\r\nb\rc", Retry: 3 * time.Second}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Send(SSEvent{Data: "d"}); err != nil {
+		t.Fatal(err)
+	}
+	want := "id: 1\nevent: price\nretry: 3000\ndata: a\ndata: b\ndata: c\n\ndata: d\n\n"
+	if rec.Body.String() != want || rec.Header().Get("Content-Type") != "text/event-stream" || !rec.Flushed {
+		t.Errorf("expected %q flushed as text/event-stream, got %q %q", want, rec.Body.String(), rec.Header().Get("Content-Type"))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c = &Ctx{Response: httptest.NewRecorder(), Request: httptest.NewRequest(MethodGet, "/events", nil).WithContext(ctx)}
+	if err := c.SSE().Send(SSEvent{Data: "late"}); !errors.Is(err, ErrStreamAborted) || !errors.Is(err, context.Canceled) {
+		t.Errorf("expected ErrStreamAborted wrapping context.Canceled, got %v", err)
+	}
+	js := c.JSONStream()
+	if err := js.Encode(1); !errors.Is(err, ErrStreamAborted) {
+		t.Errorf("expected the JSON stream to stop, got %v", err)
+	}
+}
+
+func TestCtxSSEDisconnect(t *testing.T) {
+	exited := make(chan struct{})
+	result := make(chan error, 1)
+	q := New()
+	q.Get("/events", func(c *Ctx) error {
+		ctx := c.Context() // c is reused once the handler returns
+		ticks := make(chan int)
+		go func() {
+			defer close(exited)
+			for i := 0; ; i++ {
+				select {
+				case <-ctx.Done():
+					return
+				case ticks <- i:
+				}
+			}
+		}()
+
+		s := c.SSE()
+		for {
+			select {
+			case <-s.Done():
+				result <- s.Send(SSEvent{Data: "bye"})
+				return nil
+			case i := <-ticks:
+				if err := s.Send(SSEvent{Data: strconv.Itoa(i)}); err != nil {
+					result <- err
+					return err
+				}
+				time.Sleep(time.Millisecond)
+			}
+		}
+	})
+	ts := httptest.NewServer(q)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil || line != "data: 0\n" {
+		t.Fatalf("expected the first event, got %q %v", line, err)
+	}
+	resp.Body.Close()
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, ErrStreamAborted) {
+			t.Errorf("expected ErrStreamAborted after the disconnect, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the handler kept streaming after the client disconnected")
+	}
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the producer goroutine did not exit")
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestCtxStatusInvalid$
+func TestCtxStatusInvalid(t *testing.T) {
+	var logs bytes.Buffer
+	q := New(Config{Logger: slog.New(slog.NewTextHandler(&logs, nil))})
+	q.Get("/typo", func(c *Ctx) error {
+		return c.Status(999).SendString("ok")
+	})
+	q.Get("/send", func(c *Ctx) error {
+		return c.SendStatus(42)
+	})
+	q.Get("/teapot", func(c *Ctx) error {
+		return c.Status(StatusTeapot).SendString("ok")
+	})
+
+	for path, want := range map[string]int{"/typo": 500, "/send": 500, "/teapot": StatusTeapot} {
+		rec := httptest.NewRecorder()
+		q.ServeHTTP(rec, httptest.NewRequest(MethodGet, path, nil))
+		if rec.Code != want {
+			t.Errorf("%s: expected %d, got %d", path, want, rec.Code)
+		}
+	}
+	if !strings.Contains(logs.String(), "status=999") || !strings.Contains(logs.String(), "status=42") {
+		t.Errorf("expected the invalid codes to be logged, got %q", logs.String())
+	}
+
+	q = New(Config{OnInvalidStatus: func(c *Ctx, status int) int {
+		panic(fmt.Sprintf("invalid status code %d", status))
+	}})
+	q.Get("/typo", func(c *Ctx) error {
+		return c.Status(999).SendString("ok")
+	})
+	defer func() {
+		if p := recover(); p != "invalid status code 999" {
+			t.Errorf("expected the hook to panic, got %v", p)
+		}
+	}()
+	q.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(MethodGet, "/typo", nil))
+}
+
+// go test -v -failfast -count=1 -run ^TestCtxWritten$
+func TestCtxWritten(t *testing.T) {
+	q := New()
+	q.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("cached") != "" {
+				c := &Ctx{Response: w, Request: r}
+				c.Status(StatusOK).Write([]byte("cached"))
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	q.Get("/item", func(c *Ctx) error {
+		if c.Written() {
+			return nil
+		}
+		return c.Status(StatusOK).SendString("fresh")
+	})
+	q.Get("/fail", func(c *Ctx) error {
+		if !c.Written() {
+			c.Status(StatusOK).SendString("fresh")
+		}
+		return errors.New("late failure")
+	})
+	q.Get("/created", func(c *Ctx) error {
+		c.Status(StatusCreated)
+		return nil
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/item", StatusOK, "fresh"},
+		{"/item?cached=1", StatusOK, "cached"},
+		{"/fail?cached=1", StatusOK, "cached"},
+		{"/fail", StatusOK, "fresh"},
+		{"/created", StatusCreated, ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		q.ServeHTTP(rec, httptest.NewRequest(MethodGet, tt.path, nil))
+		if rec.Code != tt.status || rec.Body.String() != tt.body {
+			t.Errorf("%s: expected %d %q, got %d %q", tt.path, tt.status, tt.body, rec.Code, rec.Body.String())
+		}
+	}
+
+	c := &Ctx{Response: httptest.NewRecorder()}
+	if c.Written() {
+		t.Error("expected a new response not to be written")
+	}
+	c.Status(StatusAccepted).Write([]byte("x"))
+	if !c.Written() {
+		t.Error("expected the response to be written after Write")
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestCtxWrittenOtherWriter$
+func TestCtxWrittenOtherWriter(t *testing.T) {
+	refreshed := httptest.NewRecorder()
+	q := New()
+	// like a cache refresh: the route runs again into a recorder with the
+	// request that was already answered
+	q.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("cached"))
+			next.ServeHTTP(refreshed, r.Clone(r.Context()))
+		})
+	})
+	q.Get("/item", func(c *Ctx) error {
+		if c.Written() {
+			t.Error("expected the recorder not to count as written")
+		}
+		return c.Status(StatusInternalServerError).SendString("backend down")
+	})
+
+	rec := httptest.NewRecorder()
+	q.ServeHTTP(rec, httptest.NewRequest(MethodGet, "/item", nil))
+	if rec.Code != StatusOK || rec.Body.String() != "cached" {
+		t.Errorf("expected the first response, got %d %q", rec.Code, rec.Body.String())
+	}
+	if refreshed.Code != StatusInternalServerError || refreshed.Body.String() != "backend down" {
+		t.Errorf("expected the status of the refresh, got %d %q", refreshed.Code, refreshed.Body.String())
+	}
+}
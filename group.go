@@ -16,17 +16,32 @@ const (
 // Group represents a collection of routes that share a common prefix
 type Group struct {
 	prefix      string
+	host        string
+	version     string
 	routes      []Route
 	middlewares []func(http.Handler) http.Handler
 	quick       *Quick
 }
 
-// Use adds middlewares to the group
+// Use adds middlewares to the group. They apply to the routes registered in
+// the group afterwards and run in registration order, after the global
+// middlewares added with q.Use: global first, then group, then the handler.
 // The result will Use(mw func(http.Handler) http.Handler)
 func (g *Group) Use(mw func(http.Handler) http.Handler) {
 	g.middlewares = append(g.middlewares, mw)
 }
 
+// Host constrains the routes registered afterwards in the group to requests
+// whose Host matches pattern. Labels written as {name} capture that part of
+// the host into the params, e.g. "{tenant}.example.com" matches
+// acme.example.com and sets c.Param("tenant") to "acme".
+// Routes without a host keep matching any host.
+// The result will Host(pattern string) *Group
+func (g *Group) Host(pattern string) *Group {
+	g.host = pattern
+	return g
+}
+
 // Group creates a new route group with a shared prefix
 // The result will Group(prefix string) *Group
 func (q *Quick) Group(prefix string) *Group {
@@ -39,6 +54,29 @@ func (q *Quick) Group(prefix string) *Group {
 	return g
 }
 
+// Version creates a group for an API version, prefixed with /<version>,
+// and passes it to fn to register the routes. Its routes carry the version
+// in Route.Version, see RoutesByVersion.
+//
+//	q.Version("v1", func(g *quick.Group) {
+//		g.Get("/users", listUsersV1)
+//	})
+//
+// The result will Version(version string, fn func(g *Group)) *Group
+func (q *Quick) Version(version string, fn func(g *Group)) *Group {
+	g := &Group{
+		prefix:  "/" + strings.Trim(version, "/"),
+		version: version,
+		routes:  []Route{},
+		quick:   q,
+	}
+	q.groups = append(q.groups, *g)
+	if fn != nil {
+		fn(g)
+	}
+	return g
+}
+
 // normalizePattern constructs the full path with the group prefix
 // The result will normalizePattern(prefix, pattern string) string
 func normalizePattern(prefix, pattern string) string {
@@ -58,18 +96,21 @@ func resolveParamExtractor(q *Quick, handlerFunc HandleFunc, paramExtractor inte
 	}
 }
 
-// applyMiddlewares applies all middlewares to a handler
+// applyMiddlewares applies all middlewares to a handler. They are wrapped
+// from the last to the first, so the first registered runs first, and any
+// http.Handler they return is accepted, not only http.HandlerFunc.
 // The result will applyMiddlewares(handler http.HandlerFunc, middlewares []func(http.Handler) http.Handler) http.HandlerFunc
 func applyMiddlewares(handler http.HandlerFunc, middlewares []func(http.Handler) http.Handler) http.HandlerFunc {
-	for _, mw := range middlewares {
-		handler = mw(handler).(http.HandlerFunc) // CORREÇÃO: Garante conversão correta
+	var h http.Handler = handler
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
 	}
-	return handler
+	return h.ServeHTTP
 }
 
 // createAndRegisterRoute creates a new route and registers it in the Quick router
-// The result will createAndRegisterRoute(g *Group, method, pattern, compiledPattern, params string, handler http.HandlerFunc)
-func createAndRegisterRoute(g *Group, method, pattern, compiledPattern, params string, handler http.HandlerFunc) {
+// The result will createAndRegisterRoute(g *Group, method, pattern, compiledPattern, params string, handler http.HandlerFunc) *RouteDoc
+func createAndRegisterRoute(g *Group, method, pattern, compiledPattern, params string, handler http.HandlerFunc) *RouteDoc {
 	route := Route{
 		Pattern: compiledPattern,
 		Path:    pattern,
@@ -77,8 +118,18 @@ func createAndRegisterRoute(g *Group, method, pattern, compiledPattern, params s
 		handler: handler,
 		Method:  method,
 		Group:   g.prefix,
+		Host:    g.host,
+		Version: g.version,
+	}
+	doc := g.quick.routeDoc(&route)
+	if !g.quick.appendRoute(&route) {
+		return doc
+	}
+
+	// host routes share paths with other hosts, the mux cannot tell them apart
+	if route.Host != "" {
+		return doc
 	}
-	g.quick.appendRoute(&route)
 
 	// FIX: Adjust path in mux to maintain compatibility with tests
 	if method == http.MethodGet {
@@ -86,11 +137,12 @@ func createAndRegisterRoute(g *Group, method, pattern, compiledPattern, params s
 	} else {
 		g.quick.mux.HandleFunc(concat.String(strings.ToLower(method), methodSeparator, pattern), handler)
 	}
+	return doc
 }
 
 // Handle registers a new route dynamically
-// The result will Handle(method, pattern string, handlerFunc HandleFunc, paramExtractor interface{})
-func (g *Group) Handle(method, pattern string, handlerFunc HandleFunc, paramExtractor any) {
+// The result will Handle(method, pattern string, handlerFunc HandleFunc, paramExtractor interface{}) *RouteDoc
+func (g *Group) Handle(method, pattern string, handlerFunc HandleFunc, paramExtractor any) *RouteDoc {
 	// Normalize pattern and extract parameters
 	pattern = normalizePattern(g.prefix, pattern)
 	path, params, compiledPattern := extractParamsPattern(pattern)
@@ -100,41 +152,79 @@ func (g *Group) Handle(method, pattern string, handlerFunc HandleFunc, paramExtr
 	handler = applyMiddlewares(handler, g.middlewares)
 
 	// Register route
-	createAndRegisterRoute(g, method, pattern, compiledPattern, params, handler)
+	return createAndRegisterRoute(g, method, pattern, compiledPattern, params, handler)
 }
 
 // Get registers a new GET route
-// The result will Get(pattern string, handlerFunc HandleFunc)
-func (g *Group) Get(pattern string, handlerFunc HandleFunc) {
-	g.Handle(http.MethodGet, pattern, handlerFunc, extractParamsGet)
+// The result will Get(pattern string, handlerFunc HandleFunc) *RouteDoc
+func (g *Group) Get(pattern string, handlerFunc HandleFunc) *RouteDoc {
+	return g.Handle(http.MethodGet, pattern, handlerFunc, extractParamsGet)
 }
 
 // Post registers a new POST route
-// The result will Post(pattern string, handlerFunc HandleFunc)
-func (g *Group) Post(pattern string, handlerFunc HandleFunc) {
-	g.Handle(http.MethodPost, pattern, handlerFunc, extractParamsPost)
+// The result will Post(pattern string, handlerFunc HandleFunc) *RouteDoc
+func (g *Group) Post(pattern string, handlerFunc HandleFunc) *RouteDoc {
+	return g.Handle(http.MethodPost, pattern, handlerFunc, extractParamsPost)
 }
 
 // Put registers a new PUT route
-// The result will Put(pattern string, handlerFunc HandleFunc)
-func (g *Group) Put(pattern string, handlerFunc HandleFunc) {
-	g.Handle(http.MethodPut, pattern, handlerFunc, extractParamsPut)
+// The result will Put(pattern string, handlerFunc HandleFunc) *RouteDoc
+func (g *Group) Put(pattern string, handlerFunc HandleFunc) *RouteDoc {
+	return g.Handle(http.MethodPut, pattern, handlerFunc, extractParamsPut)
 }
 
 // Delete registers a new DELETE route
-// The result will Delete(pattern string, handlerFunc HandleFunc)
-func (g *Group) Delete(pattern string, handlerFunc HandleFunc) {
-	g.Handle(http.MethodDelete, pattern, handlerFunc, extractParamsDelete)
+// The result will Delete(pattern string, handlerFunc HandleFunc) *RouteDoc
+func (g *Group) Delete(pattern string, handlerFunc HandleFunc) *RouteDoc {
+	return g.Handle(http.MethodDelete, pattern, handlerFunc, extractParamsDelete)
 }
 
 // Patch registers a new PATCH route
-// The result will Patch(pattern string, handlerFunc HandleFunc)
-func (g *Group) Patch(pattern string, handlerFunc HandleFunc) {
-	g.Handle(http.MethodPatch, pattern, handlerFunc, extractParamsPatch)
+// The result will Patch(pattern string, handlerFunc HandleFunc) *RouteDoc
+func (g *Group) Patch(pattern string, handlerFunc HandleFunc) *RouteDoc {
+	return g.Handle(http.MethodPatch, pattern, handlerFunc, extractParamsPatch)
 }
 
 // Options registers a new OPTIONS route
-// The result will Options(pattern string, handlerFunc HandleFunc)
-func (g *Group) Options(pattern string, handlerFunc HandleFunc) {
-	g.Handle(http.MethodOptions, pattern, handlerFunc, extractParamsOptions)
+// The result will Options(pattern string, handlerFunc HandleFunc) *RouteDoc
+func (g *Group) Options(pattern string, handlerFunc HandleFunc) *RouteDoc {
+	return g.Handle(http.MethodOptions, pattern, handlerFunc, extractParamsOptions)
+}
+
+// groupExtractors maps the methods accepted by Group.Match to the param
+// extractor of their route
+var groupExtractors = map[string]any{
+	MethodGet:     extractParamsGet,
+	MethodPost:    extractParamsPost,
+	MethodPut:     extractParamsPut,
+	MethodDelete:  extractParamsDelete,
+	MethodPatch:   extractParamsPatch,
+	MethodOptions: extractParamsOptions,
+}
+
+// Any registers the same handler for all standard HTTP methods in the group.
+// The returned RouteDoc documents every method at once.
+// The result will Any(pattern string, handlerFunc HandleFunc) *RouteDoc
+func (g *Group) Any(pattern string, handlerFunc HandleFunc) *RouteDoc {
+	return g.Match(anyMethods, pattern, handlerFunc)
+}
+
+// Match registers the same handler for the given HTTP methods in the group.
+// It panics if a method is not supported by the router. The returned
+// RouteDoc documents every method at once.
+// The result will Match(methods []string, pattern string, handlerFunc HandleFunc) *RouteDoc
+func (g *Group) Match(methods []string, pattern string, handlerFunc HandleFunc) *RouteDoc {
+	var doc *RouteDoc
+	for _, method := range methods {
+		method = strings.ToUpper(method)
+		extractor, ok := groupExtractors[method]
+		if !ok {
+			panic("Match: unsupported method " + method)
+		}
+		doc = doc.join(g.Handle(method, pattern, handlerFunc, extractor))
+	}
+	if doc == nil {
+		return &RouteDoc{q: g.quick}
+	}
+	return doc
 }
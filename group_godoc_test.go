@@ -182,3 +182,45 @@ func TestGroup_Delete(t *testing.T) {
 		t.Errorf("Expected body '%s', but got '%s'", expectedBody, res.BodyStr())
 	}
 }
+
+// TestQuick_Version verifies if versioned groups are prefixed and tag their routes.
+// The will test TestQuick_Version(t *testing.T)
+//
+// Run:
+//
+//	$ go test -v -run ^TestQuick_Version
+func TestQuick_Version(t *testing.T) {
+	q := New()
+
+	q.Version("v1", func(g *Group) {
+		g.Get("/users", func(c *Ctx) error { return c.Status(200).String("users v1") })
+	})
+	q.Version("v2", func(g *Group) {
+		g.Get("/users", func(c *Ctx) error { return c.Status(200).String("users v2") })
+		g.Post("/users", func(c *Ctx) error { return c.Status(201).String("created") })
+	})
+	q.Get("/health", func(c *Ctx) error { return c.Status(200).String("ok") })
+
+	// Both versions are served side by side
+	for path, want := range map[string]string{"/v1/users": "users v1", "/v2/users": "users v2"} {
+		res, err := q.QuickTest("GET", path, nil)
+		if err != nil {
+			t.Fatalf("QuickTest failed: %v", err)
+		}
+		if res.BodyStr() != want {
+			t.Errorf("Expected body '%s' for %s, but got '%s'", want, path, res.BodyStr())
+		}
+	}
+
+	// Routes carry their version
+	v2 := q.RoutesByVersion("v2")
+	if len(v2) != 2 || v2[0].Path != "/v2/users" || v2[1].Method != "POST" {
+		t.Errorf("Expected the two v2 routes, but got %+v", v2)
+	}
+	if route, ok := q.Route("GET", "/v1/users"); !ok || route.Version != "v1" {
+		t.Errorf("Expected /v1/users to be tagged v1, but got %+v", route)
+	}
+	if route, ok := q.Route("GET", "/health"); !ok || route.Version != "" {
+		t.Errorf("Expected unversioned routes to have no version, but got %+v", route)
+	}
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the server while the circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitBreakerConfig defines when the circuit breaker opens and recovers
+type CircuitBreakerConfig struct {
+	FailureThreshold int                              // Consecutive failures that open the circuit (default 5)
+	Cooldown         time.Duration                    // Time open before a trial request is allowed (default 30s)
+	IsFailure        func(status int, err error) bool // Classifies a result, default: error or status >= 500
+}
+
+// circuit states
+const (
+	circuitClosed = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// circuitBreaker tracks consecutive failures and short-circuits calls while open
+type circuitBreaker struct {
+	mu       sync.Mutex
+	cfg      CircuitBreakerConfig
+	state    int
+	failures int
+	openedAt time.Time
+	now      func() time.Time
+}
+
+// newCircuitBreaker applies the defaults to cfg
+// Method Used Internally
+// The result will newCircuitBreaker(cfg CircuitBreakerConfig) *circuitBreaker
+func newCircuitBreaker(cfg CircuitBreakerConfig) *circuitBreaker {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 5
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = 30 * time.Second
+	}
+	if cfg.IsFailure == nil {
+		cfg.IsFailure = func(status int, err error) bool {
+			return err != nil || status >= 500
+		}
+	}
+	return &circuitBreaker{cfg: cfg, now: time.Now}
+}
+
+// allow reports whether a request may be sent. Once the cooldown has passed
+// the circuit half-opens and lets a single trial request through.
+// Method Used Internally
+// The result will allow() error
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if b.now().Sub(b.openedAt) < b.cfg.Cooldown {
+			return ErrCircuitOpen
+		}
+		b.state = circuitHalfOpen
+		return nil
+	case circuitHalfOpen:
+		// a trial request is already in flight
+		return ErrCircuitOpen
+	}
+	return nil
+}
+
+// record updates the state with the result of a request
+// Method Used Internally
+// The result will record(status int, err error)
+func (b *circuitBreaker) record(status int, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.cfg.IsFailure(status, err) {
+		b.state = circuitClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == circuitHalfOpen || b.failures >= b.cfg.FailureThreshold {
+		b.state = circuitOpen
+		b.openedAt = b.now()
+	}
+}
+
+// WithCircuitBreaker stops calling a failing server: after FailureThreshold
+// consecutive failures every call fails fast with ErrCircuitOpen until the
+// Cooldown has passed, then a single trial request decides whether the
+// circuit closes again or stays open for another cooldown.
+// The result will WithCircuitBreaker(cfg CircuitBreakerConfig) Option
+func WithCircuitBreaker(cfg CircuitBreakerConfig) Option {
+	return func(c *Client) {
+		c.breaker = newCircuitBreaker(cfg)
+	}
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// go test -v -run ^TestCircuitBreaker
+func TestCircuitBreaker(t *testing.T) {
+	var calls int32
+	var failing atomic.Bool
+	failing.Store(true)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	hc := New(WithCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 3, Cooldown: time.Minute}))
+	now := time.Now()
+	hc.breaker.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		resp, err := hc.Get(ts.URL)
+		if err != nil || resp.StatusCode != http.StatusInternalServerError {
+			t.Fatalf("Expected 500 while closed, got %v %v", resp, err)
+		}
+	}
+
+	// open: calls fail fast without reaching the server
+	if _, err := hc.Get(ts.URL); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 calls to reach the server, got %d", calls)
+	}
+
+	// half-open trial fails: open again for another cooldown
+	now = now.Add(time.Minute)
+	if _, err := hc.Get(ts.URL); err != nil {
+		t.Fatalf("Expected trial request, got %v", err)
+	}
+	if _, err := hc.Get(ts.URL); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected circuit to reopen after a failed trial, got %v", err)
+	}
+
+	// half-open trial succeeds: closed again
+	failing.Store(false)
+	now = now.Add(time.Minute)
+	for i := 0; i < 3; i++ {
+		resp, err := hc.Get(ts.URL)
+		if err != nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected circuit to close, got %v %v", resp, err)
+		}
+	}
+	if calls != 7 {
+		t.Errorf("Expected 7 calls to reach the server, got %d", calls)
+	}
+}
+
+// go test -v -run ^TestCircuitBreakerHalfOpenSingleTrial
+func TestCircuitBreakerHalfOpenSingleTrial(t *testing.T) {
+	b := newCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, Cooldown: time.Second})
+	now := time.Now()
+	b.now = func() time.Time { return now }
+
+	b.record(0, errors.New("connection refused"))
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected open circuit, got %v", err)
+	}
+
+	now = now.Add(time.Second)
+	if err := b.allow(); err != nil {
+		t.Fatalf("Expected the trial request to pass, got %v", err)
+	}
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected concurrent requests to be rejected during the trial, got %v", err)
+	}
+}
@@ -2,17 +2,26 @@ package client
 
 import (
 	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
 	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/jeffotoni/quick"
+	"github.com/jeffotoni/quick/middleware/requestid"
 )
 
 // go test -v -run ^TestClient_Get
@@ -151,6 +160,28 @@ func TestPostForm(t *testing.T) {
 	}
 }
 
+// go test -v -run ^TestPostFormDefaultClient
+func TestPostFormDefaultClient(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" || r.PostForm.Get("code") != "a b&c" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"token"}`))
+	}))
+	defer ts.Close()
+
+	// the package level helpers must not depend on GetDefaultClient being called first
+	resp, err := PostForm(ts.URL, url.Values{"grant_type": {"authorization_code"}, "code": {"a b&c"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(resp.Body), "access_token") {
+		t.Errorf("Unexpected response %d %q", resp.StatusCode, resp.Body)
+	}
+}
+
 // go test -v -run ^TestTimeout
 func TestTimeout(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -695,3 +726,346 @@ func TestFailoverURLs(t *testing.T) {
 		t.Errorf("Expected status 200, got %d", resp.StatusCode)
 	}
 }
+
+// go test -v -run ^TestPostMultipart
+func TestPostMultipart(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data; boundary=") {
+			t.Errorf("unexpected Content-Type %q", r.Header.Get("Content-Type"))
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f, header, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		defer f.Close()
+		content, _ := io.ReadAll(f)
+		fmt.Fprintf(w, "%s|%s|%s", r.FormValue("name"), header.Filename, content)
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "report.txt")
+	if err := os.WriteFile(path, []byte("hello quick"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := New().PostMultipart(ts.URL,
+		map[string]string{"name": "report"},
+		map[string]string{"file": path})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || string(resp.Body) != "report|report.txt|hello quick" {
+		t.Errorf("Unexpected response %d %q", resp.StatusCode, resp.Body)
+	}
+
+	if _, err := New().PostMultipart(ts.URL, nil, map[string]string{"file": "does-not-exist.txt"}); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
+
+// go test -v -run ^TestGetStream
+func TestGetStream(t *testing.T) {
+	const size = 5 << 20
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		chunk := bytes.Repeat([]byte("q"), 64<<10)
+		for written := 0; written < size; written += len(chunk) {
+			w.Write(chunk)
+		}
+	}))
+	defer ts.Close()
+
+	resp, err := New().GetStream(ts.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer resp.BodyStream.Close()
+
+	if resp.Body != nil {
+		t.Errorf("Expected Body to stay nil in stream mode, got %d bytes", len(resp.Body))
+	}
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "download.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	n, err := io.Copy(f, resp.BodyStream)
+	if err != nil || n != size {
+		t.Errorf("Expected to stream %d bytes, got %d (%v)", size, n, err)
+	}
+}
+
+// go test -v -run ^TestDecompression
+func TestDecompression(t *testing.T) {
+	const payload = `{"message":"compressed"}`
+	encode := map[string]func(w io.Writer) io.WriteCloser{
+		"gzip":        func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		"deflate":     func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+		"raw-deflate": func(w io.Writer) io.WriteCloser { fw, _ := flate.NewWriter(w, flate.DefaultCompression); return fw },
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		kind := r.URL.Query().Get("kind")
+		var buf bytes.Buffer
+		zw := encode[kind](&buf)
+		zw.Write([]byte(payload))
+		zw.Close()
+
+		w.Header().Set("Content-Encoding", strings.TrimPrefix(kind, "raw-"))
+		w.Header().Set("Content-Length", fmt.Sprint(buf.Len()))
+		w.Write(buf.Bytes())
+	}))
+	defer ts.Close()
+
+	// an explicit Accept-Encoding stops http.Transport from decoding gzip by itself
+	headers := WithHeaders(map[string]string{"Accept-Encoding": "gzip, deflate"})
+
+	for kind := range encode {
+		t.Run(kind, func(t *testing.T) {
+			resp, err := New(headers).Get(ts.URL + "?kind=" + kind)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(resp.Body) != payload {
+				t.Errorf("Expected decoded body, got %q", resp.Body)
+			}
+			if resp.Header.Get("Content-Encoding") != "" || resp.Header.Get("Content-Length") != fmt.Sprint(len(payload)) {
+				t.Errorf("Expected headers of the decoded body, got %v", resp.Header)
+			}
+
+			stream, err := New(headers).GetStream(ts.URL + "?kind=" + kind)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			defer stream.BodyStream.Close()
+			if body, _ := io.ReadAll(stream.BodyStream); string(body) != payload {
+				t.Errorf("Expected decoded stream, got %q", body)
+			}
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		resp, err := New(headers, WithDecompression(false)).Get(ts.URL + "?kind=gzip")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if resp.Header.Get("Content-Encoding") != "gzip" || string(resp.Body) == payload {
+			t.Errorf("Expected the compressed body to be kept")
+		}
+	})
+}
+
+// go test -v -run ^TestBaseURL
+func TestBaseURL(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.RequestURI()))
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		base, path, want string
+	}{
+		{ts.URL, "/v1/users", "/v1/users"},
+		{ts.URL + "/", "/v1/users", "/v1/users"},
+		{ts.URL + "/v1/", "users?page=2", "/v1/users?page=2"},
+		{ts.URL + "/v1?key=abc", "//users?page=2", "/v1/users?key=abc&page=2"},
+		{ts.URL + "/files", "/a%2Fb", "/files/a%2Fb"},
+		{"http://unused.invalid", ts.URL + "/absolute", "/absolute"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resp, err := New(WithBaseURL(tt.base)).Get(tt.path)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(resp.Body) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, resp.Body)
+			}
+		})
+	}
+}
+
+// go test -v -run ^TestHooks
+func TestHooks(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("X-Trace-Id") != "trace-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("X-Served-By", "test")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	var order []string
+	var gotStatus int
+	var gotPath string
+	hc := New(
+		WithOnRequest(func(req *http.Request) {
+			order = append(order, "auth")
+			req.Header.Set("Authorization", "Bearer secret")
+		}),
+		WithOnRequest(func(req *http.Request) {
+			order = append(order, "trace")
+			req.Header.Set("X-Trace-Id", "trace-1")
+		}),
+		WithOnResponse(func(resp *http.Response) {
+			order = append(order, "response")
+			gotStatus = resp.StatusCode
+			gotPath = resp.Request.URL.Path
+		}),
+	)
+
+	resp, err := hc.Get(ts.URL + "/private")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected hooks to authorize the request, got %d", resp.StatusCode)
+	}
+	if strings.Join(order, ",") != "auth,trace,response" {
+		t.Errorf("Unexpected hook order %v", order)
+	}
+	if gotStatus != http.StatusOK || gotPath != "/private" {
+		t.Errorf("OnResponse got status %d path %q", gotStatus, gotPath)
+	}
+}
+
+// go test -v -run ^TestCookieJar
+func TestCookieJar(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc123", Path: "/"})
+			w.WriteHeader(http.StatusOK)
+		case "/private":
+			if cookie, err := r.Cookie("session"); err != nil || cookie.Value != "abc123" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte("welcome"))
+		}
+	}))
+	defer ts.Close()
+
+	hc := New(WithCookieJar(nil))
+	if _, err := hc.Post(ts.URL+"/login", nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp, err := hc.Get(ts.URL + "/private")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || string(resp.Body) != "welcome" {
+		t.Errorf("Expected session cookie to be sent, got %d %q", resp.StatusCode, resp.Body)
+	}
+
+	// without a jar the cookie is not kept
+	resp, _ = New().Get(ts.URL + "/private")
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a cookie jar, got %d", resp.StatusCode)
+	}
+}
+
+// go test -v -run ^TestProxy
+func TestProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// a forward proxy receives the absolute URL of the target
+		proxied = r.URL.String()
+		w.Write([]byte("via proxy"))
+	}))
+	defer proxy.Close()
+
+	resp, err := New(WithProxy(proxy.URL)).Get("http://backend.invalid/v1/users")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(resp.Body) != "via proxy" || proxied != "http://backend.invalid/v1/users" {
+		t.Errorf("Expected request to go through the proxy, got %q for %q", resp.Body, proxied)
+	}
+
+	if _, err := New(WithProxy("proxy.corp:3128")).Get("http://backend.invalid/"); err == nil || !strings.Contains(err.Error(), "invalid proxy URL") {
+		t.Errorf("Expected invalid proxy URL error, got %v", err)
+	}
+}
+
+// go test -v -run ^TestFromContext
+func TestFromContext(t *testing.T) {
+	started := make(chan struct{})
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+			w.Write([]byte("too late"))
+		}
+	}))
+	defer downstream.Close()
+
+	q := quick.New()
+	errCh := make(chan error, 1)
+	q.Get("/proxy", func(c *quick.Ctx) error {
+		_, err := FromContext(c).Get(downstream.URL)
+		errCh <- err
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/proxy", nil).WithContext(ctx)
+	go q.ServeHTTP(httptest.NewRecorder(), req)
+
+	<-started
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected downstream call to be cancelled, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("downstream call was not cancelled with the inbound request")
+	}
+}
+
+// go test -v -run ^TestFromContextRequestID
+func TestFromContextRequestID(t *testing.T) {
+	received := make(chan string, 2)
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get(requestid.HeaderName)
+	}))
+	defer downstream.Close()
+
+	q := quick.New()
+	q.Use(requestid.New())
+	q.Get("/proxy", func(c *quick.Ctx) error {
+		if _, err := FromContext(c).Get(downstream.URL); err != nil {
+			return err
+		}
+		_, err := FromContext(c, WithHeaders(map[string]string{requestid.HeaderName: "override"})).Get(downstream.URL)
+		return err
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/proxy", nil)
+	req.Header.Set(requestid.HeaderName, "req-42")
+	rec := httptest.NewRecorder()
+	q.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := <-received; got != "req-42" {
+		t.Errorf("Expected downstream request ID req-42, got %q", got)
+	}
+	if got := <-received; got != "override" {
+		t.Errorf("Expected explicit header to win, got %q", got)
+	}
+}
@@ -0,0 +1,106 @@
+// Package authz provides a middleware that enforces the permissions
+// declared on routes with Requires. The roles of the current user are read
+// from the request Locals, where the authentication middleware stored them:
+//
+//	q.Use(auth)          // c.Locals("roles", []string{"admin"})
+//	q.Use(authz.New())
+//
+//	q.Get("/admin", dashboard).Requires("admin")
+//	q.Delete("/users/:id", deleteUser).Requires("admin", "users:write")
+//
+// A route is reached only when the user has every role it requires;
+// otherwise the request is answered with 403 Forbidden. Routes without
+// requirements are not affected. Register it after the middleware that
+// authenticates the user, since middlewares run in registration order.
+package authz
+
+import (
+	"net/http"
+
+	"github.com/jeffotoni/quick"
+)
+
+// defaultRolesKey is the Locals key holding the roles of the current user
+const defaultRolesKey = "roles"
+
+// Config defines the config for the authz middleware
+type Config struct {
+	// RolesKey is the Locals key holding the roles of the current user,
+	// a []string or a single string. Default: roles
+	RolesKey string
+	// Roles, if set, returns the roles of the current user instead of
+	// reading RolesKey, e.g. from the claims of a token.
+	Roles func(c *quick.Ctx) []string
+	// Forbidden answers a request missing a required role, e.g. with a
+	// JSON error. Default: 403 Forbidden.
+	Forbidden func(c *quick.Ctx) error
+}
+
+// New creates the authz middleware
+// The result will New(config ...Config) func(http.Handler) http.Handler
+func New(config ...Config) func(http.Handler) http.Handler {
+	var cfg Config
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.RolesKey == "" {
+		cfg.RolesKey = defaultRolesKey
+	}
+	if cfg.Roles == nil {
+		cfg.Roles = func(c *quick.Ctx) []string {
+			return localRoles(c, cfg.RolesKey)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c := &quick.Ctx{Response: w, Request: r}
+			route := c.Route()
+			if route == nil || len(route.Requires) == 0 || hasAll(cfg.Roles(c), route.Requires) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if cfg.Forbidden != nil {
+				if err := cfg.Forbidden(c); err != nil {
+					// #nosec G104
+					c.HandleError(err)
+				}
+				return
+			}
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		})
+	}
+}
+
+// localRoles reads the roles stored in the Locals under key
+// Method Used Internally
+// The result will localRoles(c *quick.Ctx, key string) []string
+func localRoles(c *quick.Ctx, key string) []string {
+	switch roles := c.Locals(key).(type) {
+	case []string:
+		return roles
+	case string:
+		return []string{roles}
+	}
+	return nil
+}
+
+// hasAll reports whether roles holds every required role
+// Method Used Internally
+// The result will hasAll(roles, required []string) bool
+func hasAll(roles, required []string) bool {
+	for _, want := range required {
+		found := false
+		for _, role := range roles {
+			if role == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
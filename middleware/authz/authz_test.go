@@ -0,0 +1,98 @@
+package authz
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jeffotoni/quick"
+)
+
+// auth stores the roles sent in X-Roles, comma separated, in the Locals
+func auth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if roles := r.Header.Get("X-Roles"); roles != "" {
+			c := &quick.Ctx{Response: w, Request: r}
+			c.Locals("roles", strings.Split(roles, ","))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// go test -v -failfast -count=1 -run ^TestNew$
+func TestNew(t *testing.T) {
+	ok := func(c *quick.Ctx) error {
+		return c.Status(http.StatusOK).SendString("ok")
+	}
+	q := quick.New()
+	q.Use(auth)
+	q.Use(New())
+	q.Get("/public", ok)
+	q.Get("/admin", ok).Requires("admin")
+	q.Delete("/users/:id", ok).Requires("admin", "users:write")
+
+	tests := []struct {
+		method string
+		path   string
+		roles  string
+		status int
+	}{
+		{http.MethodGet, "/public", "", http.StatusOK},
+		{http.MethodGet, "/admin", "", http.StatusForbidden},
+		{http.MethodGet, "/admin", "user", http.StatusForbidden},
+		{http.MethodGet, "/admin", "user,admin", http.StatusOK},
+		{http.MethodDelete, "/users/42", "admin", http.StatusForbidden},
+		{http.MethodDelete, "/users/42", "admin,users:write", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.roles != "" {
+			req.Header.Set("X-Roles", tt.roles)
+		}
+		rec := httptest.NewRecorder()
+		q.ServeHTTP(rec, req)
+		if rec.Code != tt.status {
+			t.Errorf("%s %s with %q: expected %d, got %d", tt.method, tt.path, tt.roles, tt.status, rec.Code)
+		}
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNewConfig$
+func TestNewConfig(t *testing.T) {
+	q := quick.New()
+	q.Use(New(Config{
+		Roles: func(c *quick.Ctx) []string {
+			return []string{c.Request.Header.Get("X-Role")}
+		},
+		Forbidden: func(c *quick.Ctx) error {
+			if c.Request.Header.Get("X-Role") == "" {
+				return errors.New("no role")
+			}
+			return c.Status(http.StatusForbidden).JSON(map[string]string{"error": "forbidden"})
+		},
+	}))
+	q.Get("/admin", func(c *quick.Ctx) error {
+		return c.Status(http.StatusOK).SendString("ok")
+	}).Requires("admin")
+
+	tests := []struct {
+		role   string
+		status int
+		body   string
+	}{
+		{"admin", http.StatusOK, "ok"},
+		{"user", http.StatusForbidden, `{"error":"forbidden"}`},
+		{"", http.StatusInternalServerError, "no role"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		req.Header.Set("X-Role", tt.role)
+		rec := httptest.NewRecorder()
+		q.ServeHTTP(rec, req)
+		if rec.Code != tt.status || strings.TrimSpace(rec.Body.String()) != tt.body {
+			t.Errorf("%q: expected %d %s, got %d %s", tt.role, tt.status, tt.body, rec.Code, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,357 @@
+// Package cache provides a middleware that keeps successful GET and HEAD
+// responses in memory and serves them again for the same URL, for
+// semi-static endpoints:
+//
+//	docs := q.Group("/docs")
+//	docs.Use(cache.New(cache.Config{TTL: time.Minute, StaleWhileRevalidate: 10 * time.Minute}))
+//
+// A response is fresh for TTL. With StaleWhileRevalidate, for that long
+// after the TTL the stale response is still served right away while the
+// handler runs again in the background to refresh it, so clients never
+// wait for a slow handler once the entry exists. Served responses carry
+// X-Cache: HIT, STALE or MISS.
+//
+// Only 200 responses without Set-Cookie or Cache-Control: no-store or
+// private are kept. Requests with an Authorization header skip the
+// cache, and their responses are only kept when marked public or
+// s-maxage, as RFC 9111 requires of shared caches. Responses are kept
+// apart by the request headers named in their Vary header; Vary: * is
+// never kept. Misses are not coalesced; combine with the singleflight
+// middleware to protect the handler from stampedes.
+package cache
+
+import (
+	"net/http"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jeffotoni/quick"
+	"github.com/jeffotoni/quick/middleware/transform"
+)
+
+// Defaults used when the Config fields are not set
+const (
+	defaultTTL        = time.Minute
+	defaultMaxEntries = 10000
+	headerName        = "X-Cache"
+)
+
+// Config defines the config for the cache middleware
+type Config struct {
+	// TTL is how long a response is fresh. Default 1 minute.
+	TTL time.Duration
+	// StaleWhileRevalidate is how long after the TTL a stale response is
+	// still served while it is refreshed in the background. Default 0: the
+	// response is dropped once the TTL expires.
+	StaleWhileRevalidate time.Duration
+	// KeyGenerator builds the key of a response. Default is the method
+	// plus the request URI (path and query).
+	KeyGenerator func(r *http.Request) string
+	// MaxEntries bounds the number of kept responses; when full, the
+	// oldest one is dropped. Default 10000.
+	MaxEntries int
+}
+
+// entry is a kept response
+type entry struct {
+	base       string // key of the request before Vary
+	res        *transform.Response
+	stored     time.Time
+	refreshing bool
+}
+
+// store keeps the responses of one middleware
+type store struct {
+	mu         sync.Mutex
+	entries    map[string]*entry
+	vary       map[string][]string // headers named by Vary, per key before Vary
+	ttl        time.Duration
+	stale      time.Duration
+	maxEntries int
+	nextSweep  time.Time
+	now        func() time.Time
+	refreshes  sync.WaitGroup
+}
+
+// New creates the cache middleware
+// The result will New(config ...Config) func(http.Handler) http.Handler
+func New(config ...Config) func(http.Handler) http.Handler {
+	var cfg Config
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = defaultTTL
+	}
+	if cfg.StaleWhileRevalidate < 0 {
+		cfg.StaleWhileRevalidate = 0
+	}
+	if cfg.KeyGenerator == nil {
+		cfg.KeyGenerator = defaultKey
+	}
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = defaultMaxEntries
+	}
+
+	s := newStore(cfg, time.Now)
+
+	return func(next http.Handler) http.Handler {
+		return s.handler(cfg, next)
+	}
+}
+
+// newStore creates the store of a middleware reading the time from now
+// Method Used Internally
+// The result will newStore(cfg Config, now func() time.Time) *store
+func newStore(cfg Config, now func() time.Time) *store {
+	return &store{
+		entries:    make(map[string]*entry),
+		vary:       make(map[string][]string),
+		ttl:        cfg.TTL,
+		stale:      cfg.StaleWhileRevalidate,
+		maxEntries: cfg.MaxEntries,
+		now:        now,
+	}
+}
+
+// defaultKey keys responses by method and request URI
+// Method Used Internally
+// The result will defaultKey(r *http.Request) string
+func defaultKey(r *http.Request) string {
+	return r.Method + " " + r.URL.RequestURI()
+}
+
+// handler serves kept responses and keeps the new ones of next
+// Method Used Internally
+// The result will handler(cfg Config, next http.Handler) http.Handler
+func (s *store) handler(cfg Config, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		base := cfg.KeyGenerator(r)
+		// responses to authorized requests may belong to one user only
+		authorized := r.Header.Get("Authorization") != ""
+		if !authorized {
+			if key, res, state := s.lookup(base, r); res != nil {
+				if state == "STALE" {
+					s.refreshes.Add(1)
+					// the refresh outlives the request, keep its values but not its
+					// cancellation nor the state of the response being written
+					go s.refresh(base, key, next, quick.DetachRequest(r))
+				}
+				write(w, res, state)
+				return
+			}
+		}
+
+		rec := transform.NewRecorder()
+		next.ServeHTTP(rec, r)
+		res := rec.Response()
+		if !authorized || sharedPublic(res) {
+			s.store(base, r, res)
+		}
+		write(w, res, "MISS")
+	})
+}
+
+// lookup returns the key of r, the response kept for it and whether it
+// is a fresh HIT or a STALE one the caller must refresh; a single caller
+// is asked to refresh at a time
+// Method Used Internally
+// The result will lookup(base string, r *http.Request) (string, *transform.Response, string)
+func (s *store) lookup(base string, r *http.Request) (string, *transform.Response, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	key := base + varyKey(r, s.vary[base])
+	e, ok := s.entries[key]
+	if !ok {
+		return key, nil, ""
+	}
+	age := now.Sub(e.stored)
+	switch {
+	case age < s.ttl:
+		return key, e.res, "HIT"
+	case age < s.ttl+s.stale:
+		if e.refreshing {
+			return key, e.res, "HIT"
+		}
+		e.refreshing = true
+		return key, e.res, "STALE"
+	}
+	delete(s.entries, key)
+	return key, nil, ""
+}
+
+// refresh runs next again for a stale key. The stale response is kept
+// when the new one cannot be cached or the handler panics.
+// Method Used Internally
+// The result will refresh(base, key string, next http.Handler, r *http.Request)
+func (s *store) refresh(base, key string, next http.Handler, r *http.Request) {
+	defer s.refreshes.Done()
+	defer func() {
+		s.mu.Lock()
+		if e, ok := s.entries[key]; ok {
+			e.refreshing = false
+		}
+		s.mu.Unlock()
+		// #nosec G104
+		recover()
+	}()
+
+	rec := transform.NewRecorder()
+	next.ServeHTTP(rec, r)
+	s.store(base, r, rec.Response())
+}
+
+// store keeps res for the request r when it can be cached, under base
+// plus the values of the request headers named by its Vary header
+// Method Used Internally
+// The result will store(base string, r *http.Request, res *transform.Response)
+func (s *store) store(base string, r *http.Request, res *transform.Response) {
+	if !cacheable(res) {
+		return
+	}
+	names, ok := varyNames(res.Header)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := base + varyKey(r, names)
+	if _, ok := s.entries[key]; !ok && len(s.entries) >= s.maxEntries && !s.evict() {
+		return
+	}
+	s.vary[base] = names
+	s.entries[key] = &entry{base: base, res: res, stored: s.now()}
+}
+
+// evict drops the oldest response that is not being refreshed, reporting
+// whether there was one
+// Method Used Internally
+// The result will evict() bool
+func (s *store) evict() bool {
+	var oldest string
+	var found bool
+	for key, e := range s.entries {
+		if !e.refreshing && (!found || e.stored.Before(s.entries[oldest].stored)) {
+			oldest, found = key, true
+		}
+	}
+	if found {
+		delete(s.entries, oldest)
+	}
+	return found
+}
+
+// sweep drops the responses past their stale period at most once per TTL
+// Method Used Internally
+// The result will sweep(now time.Time)
+func (s *store) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	bases := make(map[string]bool, len(s.vary))
+	for key, e := range s.entries {
+		if now.Sub(e.stored) >= s.ttl+s.stale && !e.refreshing {
+			delete(s.entries, key)
+			continue
+		}
+		bases[e.base] = true
+	}
+	for base := range s.vary {
+		if !bases[base] {
+			delete(s.vary, base)
+		}
+	}
+	s.nextSweep = now.Add(s.ttl)
+}
+
+// varyNames returns the request headers named by the Vary header of a
+// response, or false for Vary: *, which matches no later request
+// Method Used Internally
+// The result will varyNames(h http.Header) ([]string, bool)
+func varyNames(h http.Header) ([]string, bool) {
+	var names []string
+	for _, v := range h.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			name = strings.TrimSpace(name)
+			switch name {
+			case "":
+				continue
+			case "*":
+				return nil, false
+			}
+			names = append(names, textproto.CanonicalMIMEHeaderKey(name))
+		}
+	}
+	return names, true
+}
+
+// varyKey returns the values of the named request headers, appended to
+// the key so each variant of a response is kept apart
+// Method Used Internally
+// The result will varyKey(r *http.Request, names []string) string
+func varyKey(r *http.Request, names []string) string {
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString("\x00")
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(strings.Join(r.Header.Values(name), ","))
+	}
+	return b.String()
+}
+
+// sharedPublic reports whether res explicitly allows shared caches to
+// keep a response to an authorized request, with public or s-maxage
+// Method Used Internally
+// The result will sharedPublic(res *transform.Response) bool
+func sharedPublic(res *transform.Response) bool {
+	for _, v := range res.Header.Values("Cache-Control") {
+		for _, directive := range strings.Split(v, ",") {
+			directive = strings.ToLower(strings.TrimSpace(directive))
+			if directive == "public" || strings.HasPrefix(directive, "s-maxage=") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cacheable reports whether res is a 200 that may be shared between clients
+// Method Used Internally
+// The result will cacheable(res *transform.Response) bool
+func cacheable(res *transform.Response) bool {
+	if res.Status != http.StatusOK || len(res.Header.Values("Set-Cookie")) > 0 {
+		return false
+	}
+	for _, v := range res.Header.Values("Cache-Control") {
+		for _, directive := range strings.Split(v, ",") {
+			switch strings.ToLower(strings.TrimSpace(directive)) {
+			case "no-store", "private":
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// write sends a copy of res marked with its cache state
+// Method Used Internally
+// The result will write(w http.ResponseWriter, res *transform.Response, state string)
+func write(w http.ResponseWriter, res *transform.Response, state string) {
+	header := res.Header.Clone()
+	header.Set(headerName, state)
+	// #nosec G104
+	transform.WriteTo(w, &transform.Response{Status: res.Status, Header: header, Body: res.Body})
+}
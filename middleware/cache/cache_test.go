@@ -0,0 +1,289 @@
+package cache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jeffotoni/quick"
+)
+
+// clock is a settable time source for the store
+type clock struct {
+	now atomic.Int64
+}
+
+func newClock(t time.Time) *clock {
+	c := &clock{}
+	c.now.Store(t.UnixNano())
+	return c
+}
+
+func (c *clock) Now() time.Time {
+	return time.Unix(0, c.now.Load())
+}
+
+func (c *clock) Add(d time.Duration) {
+	c.now.Add(int64(d))
+}
+
+// get sends a GET request for target to h
+func get(h http.Handler, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+// go test -v -failfast -count=1 -run ^TestNewStaleWhileRevalidate$
+func TestNewStaleWhileRevalidate(t *testing.T) {
+	var calls atomic.Int32
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Write([]byte("v" + strconv.Itoa(int(n))))
+	})
+
+	clk := newClock(time.Now())
+	cfg := Config{TTL: time.Minute, StaleWhileRevalidate: time.Minute, KeyGenerator: defaultKey, MaxEntries: defaultMaxEntries}
+	s := newStore(cfg, clk.Now)
+	h := s.handler(cfg, next)
+
+	steps := []struct {
+		advance time.Duration
+		state   string
+		body    string
+	}{
+		{0, "MISS", "v1"},
+		{30 * time.Second, "HIT", "v1"},
+		{time.Minute, "STALE", "v1"}, // after the TTL: stale content, refreshed in the background
+		{0, "HIT", "v2"},
+		{3 * time.Minute, "MISS", "v3"}, // past the stale period
+	}
+	for i, step := range steps {
+		clk.Add(step.advance)
+		rec := get(h, "/docs")
+		s.refreshes.Wait()
+		if rec.Header().Get("X-Cache") != step.state || rec.Body.String() != step.body {
+			t.Errorf("step %d: expected %s %s, got %s %s", i, step.state, step.body, rec.Header().Get("X-Cache"), rec.Body.String())
+		}
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected the handler to run 3 times, got %d", calls.Load())
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNewStaleRefreshFails$
+func TestNewStaleRefreshFails(t *testing.T) {
+	fail := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			panic("backend down")
+		}
+		w.Write([]byte("ok"))
+	})
+
+	clk := newClock(time.Now())
+	cfg := Config{TTL: time.Minute, StaleWhileRevalidate: time.Hour, KeyGenerator: defaultKey, MaxEntries: defaultMaxEntries}
+	s := newStore(cfg, clk.Now)
+	h := s.handler(cfg, next)
+
+	get(h, "/docs")
+	fail = true
+	clk.Add(2 * time.Minute)
+	for i := 0; i < 2; i++ {
+		rec := get(h, "/docs")
+		s.refreshes.Wait()
+		if rec.Header().Get("X-Cache") != "STALE" || rec.Body.String() != "ok" {
+			t.Errorf("expected the stale response to be kept, got %s %s", rec.Header().Get("X-Cache"), rec.Body.String())
+		}
+	}
+}
+
+// go test -v -failfast -race -count=1 -run ^TestNewStaleRefreshRoute$
+func TestNewStaleRefreshRoute(t *testing.T) {
+	var calls atomic.Int32
+	clk := newClock(time.Now())
+	cfg := Config{TTL: time.Minute, StaleWhileRevalidate: time.Hour, KeyGenerator: defaultKey, MaxEntries: defaultMaxEntries}
+	s := newStore(cfg, clk.Now)
+
+	q := quick.New()
+	q.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			(&quick.Ctx{Response: w, Request: r}).Locals("tenant", "acme")
+			next.ServeHTTP(w, r)
+		})
+	})
+	q.Use(func(next http.Handler) http.Handler {
+		return s.handler(cfg, next)
+	})
+	q.Get("/docs/:page", func(c *quick.Ctx) error {
+		if calls.Add(1) > 1 {
+			return c.Status(http.StatusInternalServerError).SendString("backend down")
+		}
+		return c.Status(http.StatusOK).SendString(c.Param("page") + " " + c.Locals("tenant").(string))
+	})
+
+	get(q, "/docs/a")
+	clk.Add(2 * time.Minute)
+	for i := 0; i < 2; i++ {
+		rec := get(q, "/docs/a")
+		s.refreshes.Wait()
+		if rec.Code != http.StatusOK || rec.Header().Get("X-Cache") != "STALE" || rec.Body.String() != "a acme" {
+			t.Errorf("expected the stale response to be kept, got %d %s %s", rec.Code, rec.Header().Get("X-Cache"), rec.Body.String())
+		}
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected the handler to be refreshed twice, got %d calls", calls.Load())
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNew$
+func TestNew(t *testing.T) {
+	var calls atomic.Int32
+	q := quick.New()
+	q.Use(New())
+	q.Get("/docs/:page", func(c *quick.Ctx) error {
+		calls.Add(1)
+		return c.Status(http.StatusOK).SendString(c.Param("page"))
+	})
+	q.Get("/private", func(c *quick.Ctx) error {
+		calls.Add(1)
+		c.Set("Cache-Control", "private")
+		return c.Status(http.StatusOK).SendString("me")
+	})
+	q.Get("/missing", func(c *quick.Ctx) error {
+		calls.Add(1)
+		return c.Status(http.StatusNotFound).SendString("missing")
+	})
+
+	tests := []struct {
+		target string
+		state  string
+		body   string
+	}{
+		{"/docs/a", "MISS", "a"},
+		{"/docs/a", "HIT", "a"},
+		{"/docs/a?v=2", "MISS", "a"},
+		{"/docs/b", "MISS", "b"},
+		{"/private", "MISS", "me"},
+		{"/private", "MISS", "me"},
+		{"/missing", "MISS", "missing"},
+		{"/missing", "MISS", "missing"},
+	}
+	for _, tt := range tests {
+		rec := get(q, tt.target)
+		if rec.Header().Get("X-Cache") != tt.state || rec.Body.String() != tt.body {
+			t.Errorf("%s: expected %s %s, got %s %s", tt.target, tt.state, tt.body, rec.Header().Get("X-Cache"), rec.Body.String())
+		}
+	}
+	if calls.Load() != 7 {
+		t.Errorf("expected the handler to run 7 times, got %d", calls.Load())
+	}
+
+	rec := httptest.NewRecorder()
+	q.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/docs/a", nil))
+	if rec.Header().Get("X-Cache") != "" {
+		t.Errorf("expected POST to bypass the cache, got %s", rec.Header().Get("X-Cache"))
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNewAuthorization$
+func TestNewAuthorization(t *testing.T) {
+	var calls atomic.Int32
+	h := New()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Path == "/public" {
+			w.Header().Set("Cache-Control", "public, max-age=60")
+		}
+		w.Write([]byte(r.URL.Path + " " + r.Header.Get("Authorization")))
+	}))
+	send := func(target, auth string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		target, auth, state, body string
+	}{
+		{"/me", "Bearer alice", "MISS", "/me Bearer alice"},
+		{"/me", "Bearer bob", "MISS", "/me Bearer bob"},
+		{"/me", "", "MISS", "/me "},
+		{"/public", "Bearer alice", "MISS", "/public Bearer alice"},
+		{"/public", "", "HIT", "/public Bearer alice"},
+	}
+	for i, tt := range tests {
+		rec := send(tt.target, tt.auth)
+		if rec.Header().Get("X-Cache") != tt.state || rec.Body.String() != tt.body {
+			t.Errorf("step %d: expected %s %q, got %s %q", i, tt.state, tt.body, rec.Header().Get("X-Cache"), rec.Body.String())
+		}
+	}
+	if calls.Load() != 4 {
+		t.Errorf("expected the handler to run 4 times, got %d", calls.Load())
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNewVary$
+func TestNewVary(t *testing.T) {
+	h := New()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/any" {
+			w.Header().Set("Vary", "*")
+		} else {
+			w.Header().Set("Vary", "accept-language")
+		}
+		w.Write([]byte(r.Header.Get("Accept-Language")))
+	}))
+	send := func(target, lang string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Accept-Language", lang)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		target, lang, state string
+	}{
+		{"/docs", "pt", "MISS"},
+		{"/docs", "en", "MISS"},
+		{"/docs", "pt", "HIT"},
+		{"/docs", "en", "HIT"},
+		{"/any", "pt", "MISS"},
+		{"/any", "pt", "MISS"},
+	}
+	for i, tt := range tests {
+		rec := send(tt.target, tt.lang)
+		if rec.Header().Get("X-Cache") != tt.state || rec.Body.String() != tt.lang {
+			t.Errorf("step %d: expected %s %q, got %s %q", i, tt.state, tt.lang, rec.Header().Get("X-Cache"), rec.Body.String())
+		}
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNewMaxEntries$
+func TestNewMaxEntries(t *testing.T) {
+	clk := newClock(time.Now())
+	cfg := Config{TTL: time.Hour, KeyGenerator: defaultKey, MaxEntries: 2}
+	s := newStore(cfg, clk.Now)
+	h := s.handler(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.RawQuery))
+	}))
+
+	for _, q := range []string{"a", "b", "c"} {
+		clk.Add(time.Second)
+		get(h, "/search?"+q)
+	}
+	if len(s.entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(s.entries))
+	}
+	for q, state := range map[string]string{"a": "MISS", "c": "HIT"} {
+		if rec := get(h, "/search?"+q); rec.Header().Get("X-Cache") != state {
+			t.Errorf("%s: expected %s, got %s", q, state, rec.Header().Get("X-Cache"))
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Package charset provides a middleware that transcodes request bodies
+// sent in a legacy charset to UTF-8 before they are parsed, based on the
+// charset parameter of the Content-Type, e.g. a form posted by an old
+// client as "application/x-www-form-urlencoded; charset=iso-8859-1":
+//
+//	q.Use(charset.New())
+//
+//	// name=Jos%E9 (latin1) => c.FormValue("name") == "José"
+//
+// Charsets are decoded with golang.org/x/text/encoding, looked up by the
+// labels of the WHATWG Encoding Standard as browsers do, so ISO-8859-1
+// and US-ASCII are read as Windows-1252. Config.Encodings adds or
+// replaces labels:
+//
+//	q.Use(charset.New(charset.Config{Encodings: map[string]encoding.Encoding{
+//		"x-mac-roman": charmap.Macintosh,
+//	}}))
+//
+// The Content-Type is rewritten with charset=utf-8. Requests without a
+// charset or in UTF-8 pass through, multipart bodies too since each part
+// carries its own charset, and unknown charsets get 415 Unsupported
+// Media Type.
+package charset
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// maxFormSize caps urlencoded forms, which are decoded in memory, as
+// net/http does when parsing them
+const maxFormSize = 10 << 20
+
+// Config defines the config for the charset middleware
+type Config struct {
+	// Encodings adds encodings by charset label, lowercase, or replaces
+	// the ones of the WHATWG Encoding Standard.
+	Encodings map[string]encoding.Encoding
+}
+
+// New creates the charset middleware
+// The result will New(config ...Config) func(http.Handler) http.Handler
+func New(config ...Config) func(http.Handler) http.Handler {
+	custom := make(map[string]encoding.Encoding)
+	if len(config) > 0 {
+		for label, enc := range config[0].Encodings {
+			custom[strings.ToLower(label)] = enc
+		}
+	}
+	lookup := func(label string) (encoding.Encoding, bool) {
+		if enc, ok := custom[label]; ok {
+			return enc, true
+		}
+		enc, err := htmlindex.Get(label)
+		return enc, err == nil
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			label := strings.ToLower(strings.TrimSpace(params["charset"]))
+			if err != nil || label == "" || label == "utf-8" || label == "utf8" ||
+				strings.HasPrefix(mediaType, "multipart/") || r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+			enc, ok := lookup(label)
+			if !ok {
+				http.Error(w, "Unsupported charset "+strconv.Quote(label), http.StatusUnsupportedMediaType)
+				return
+			}
+			if enc == unicode.UTF8 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// decoders keep state, each request gets its own
+			dec := enc.NewDecoder()
+			if mediaType == "application/x-www-form-urlencoded" {
+				if status := transcodeForm(r, dec); status != 0 {
+					http.Error(w, http.StatusText(status), status)
+					return
+				}
+			} else {
+				r.Body = struct {
+					io.Reader
+					io.Closer
+				}{dec.Reader(r.Body), r.Body}
+				r.ContentLength = -1
+				r.Header.Del("Content-Length")
+			}
+
+			params["charset"] = "utf-8"
+			r.Header.Set("Content-Type", mime.FormatMediaType(mediaType, params))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// transcodeForm replaces an urlencoded body with its UTF-8 form. Keys and
+// values are decoded after unescaping, since clients percent-encode the
+// bytes of the legacy charset, e.g. é as %E9. It returns the status of
+// the error response, or 0.
+// Method Used Internally
+// The result will transcodeForm(r *http.Request, dec *encoding.Decoder) int
+func transcodeForm(r *http.Request, dec *encoding.Decoder) int {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxFormSize+1))
+	r.Body.Close()
+	if err != nil {
+		return http.StatusBadRequest
+	}
+	if len(body) > maxFormSize {
+		return http.StatusRequestEntityTooLarge
+	}
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return http.StatusBadRequest
+	}
+
+	out := make(url.Values, len(values))
+	for key, vals := range values {
+		k, err := dec.String(key)
+		if err != nil {
+			return http.StatusBadRequest
+		}
+		for _, v := range vals {
+			if v, err = dec.String(v); err != nil {
+				return http.StatusBadRequest
+			}
+			out[k] = append(out[k], v)
+		}
+	}
+
+	encoded := out.Encode()
+	r.Body = io.NopCloser(strings.NewReader(encoded))
+	r.ContentLength = int64(len(encoded))
+	r.Header.Set("Content-Length", strconv.Itoa(len(encoded)))
+	return 0
+}
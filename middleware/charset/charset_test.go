@@ -0,0 +1,73 @@
+package charset
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jeffotoni/quick"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// go test -v -failfast -count=1 -run ^TestNew$
+func TestNew(t *testing.T) {
+	q := quick.New(quick.Config{MaxBodySize: 1 << 20})
+	q.Use(New())
+	q.Post("/form", func(c *quick.Ctx) error {
+		return c.Status(http.StatusOK).SendString(c.FormValue("name") + "|" + c.FormValue("city") + "|" + c.Request.Header.Get("Content-Type"))
+	})
+	q.Post("/json", func(c *quick.Ctx) error {
+		var v struct{ Name string }
+		if err := c.BodyParser(&v); err != nil {
+			return err
+		}
+		return c.Status(http.StatusOK).SendString(v.Name)
+	})
+
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+		status      int
+		want        string
+	}{
+		{"latin1 form", "/form", "application/x-www-form-urlencoded; charset=ISO-8859-1", "name=Jos%E9&city=S\xe3o+Paulo",
+			http.StatusOK, "José|São Paulo|application/x-www-form-urlencoded; charset=utf-8"},
+		{"utf-8 form", "/form", "application/x-www-form-urlencoded; charset=utf-8", "name=Jos%C3%A9",
+			http.StatusOK, "José||application/x-www-form-urlencoded; charset=utf-8"},
+		{"latin1 json", "/json", "application/json; charset=latin1", "{\"Name\":\"Andr\xe9 \x80\"}", http.StatusOK, "André €"},
+		{"no charset", "/json", "application/json", `{"Name":"Zoë"}`, http.StatusOK, "Zoë"},
+		{"koi8-r json", "/json", "application/json; charset=koi8-r", "{\"Name\":\"\xf0\xd2\xc9\xd7\xc5\xd4\"}", http.StatusOK, "Привет"},
+		{"shift_jis form", "/form", "application/x-www-form-urlencoded; charset=Shift_JIS", "name=%93%FA%96%7B", http.StatusOK, "日本||application/x-www-form-urlencoded; charset=utf-8"},
+		{"unknown charset", "/json", "application/json; charset=x-unknown", `{}`, http.StatusUnsupportedMediaType, "Unsupported charset \"x-unknown\"\n"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", tt.contentType)
+		rec := httptest.NewRecorder()
+		q.ServeHTTP(rec, req)
+		if rec.Code != tt.status || rec.Body.String() != tt.want {
+			t.Errorf("%s: expected %d %q, got %d %q", tt.name, tt.status, tt.want, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNewEncodings$
+func TestNewEncodings(t *testing.T) {
+	h := New(Config{Encodings: map[string]encoding.Encoding{"X-DOS": charmap.CodePage437}})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		w.Write(b)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("caf\x82"))
+	req.Header.Set("Content-Type", "text/plain; charset=x-dos")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Body.String() != "café" || req.Header.Get("Content-Type") != "text/plain; charset=utf-8" {
+		t.Errorf("expected the configured encoding, got %q %q", rec.Body.String(), req.Header.Get("Content-Type"))
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"io"
 	"net/http"
 	"strings"
+
+	"github.com/jeffotoni/quick"
 )
 
 // write in gzip and Header() from http
@@ -43,10 +45,24 @@ import (
 type gzipResponseWriter struct {
 	io.Writer
 	http.ResponseWriter
+	wroteHeader bool
+}
+
+// WriteHeader drops the Content-Length set by the handler, e.g. with
+// c.SetContentLength, since it counts the uncompressed bytes
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.ResponseWriter.Header().Del("Content-Length")
+	}
+	w.ResponseWriter.WriteHeader(status)
 }
 
 // We ensure that writing is directed to gzip.Writer
-func (w gzipResponseWriter) Write(b []byte) (int, error) {
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
 	return w.Writer.Write(b)
 }
 
@@ -54,6 +70,10 @@ func (w gzipResponseWriter) Write(b []byte) (int, error) {
 func Gzip() func(h http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The response depends on Accept-Encoding whether or not it is
+			// compressed, so caches must key on it in both cases
+			(&quick.Ctx{Response: w, Request: r}).Vary("Accept-Encoding")
+
 			// Checks if the client supports gzip
 			if !clientSupportsGzip(r) {
 				next.ServeHTTP(w, r)
@@ -65,17 +85,20 @@ func Gzip() func(h http.Handler) http.Handler {
 
 			// Adjust headers to indicate that the response will be gzipped
 			w.Header().Set("Content-Encoding", "gzip")
-			w.Header().Add("Vary", "Accept-Encoding")
 
 			gz := gzip.NewWriter(w)
+			gzr := &gzipResponseWriter{Writer: gz, ResponseWriter: w}
 			defer func() {
+				// the gzip footer is written even when the handler wrote nothing
+				if !gzr.wroteHeader {
+					w.Header().Del("Content-Length")
+				}
 				if err := gz.Close(); err != nil {
 					// If an error occurs when closing, we send 500
 					http.Error(w, fmt.Sprintf("error closing gzip: %v", err), http.StatusInternalServerError)
 				}
 			}()
 
-			gzr := gzipResponseWriter{Writer: gz, ResponseWriter: w}
 			next.ServeHTTP(gzr, r)
 		})
 	}
@@ -44,6 +44,49 @@ func TestGzipMiddleware(t *testing.T) {
 		if !strings.Contains(w.Body.String(), "Hello, World!") {
 			t.Errorf("Response body mismatch, got '%s'", w.Body.String())
 		}
+		if vary := w.Header().Values("Vary"); len(vary) != 1 || vary[0] != "Accept-Encoding" {
+			t.Errorf("Expected Vary=Accept-Encoding, got %v", vary)
+		}
+	})
+
+	t.Run("Content-Length of the handler is dropped", func(t *testing.T) {
+		handler := Gzip()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Length", "14")
+			w.Write([]byte("Hello, Gopher!"))
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if cl := w.Header().Get("Content-Length"); cl != "" {
+			t.Errorf("Expected no Content-Length, got '%s'", cl)
+		}
+		gzReader, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatalf("Failed to create gzip reader: %v", err)
+		}
+		defer gzReader.Close()
+		if unzipped, _ := io.ReadAll(gzReader); string(unzipped) != "Hello, Gopher!" {
+			t.Errorf("Unzipped content mismatch, got '%s'", string(unzipped))
+		}
+	})
+
+	t.Run("Vary is not duplicated", func(t *testing.T) {
+		handler := Gzip()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("Hello, World!"))
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		w.Header().Set("Vary", "Accept, accept-encoding")
+		handler.ServeHTTP(w, req)
+
+		if vary := w.Header().Values("Vary"); len(vary) != 1 || vary[0] != "Accept, accept-encoding" {
+			t.Errorf("Expected Vary unchanged, got %v", vary)
+		}
 	})
 
 	t.Run("Accept-Encoding => compressed with gzip", func(t *testing.T) {
@@ -0,0 +1,145 @@
+// Package contenttype provides middlewares that restrict the media types a
+// route works with. Consumes rejects request bodies of other types with
+// 415 Unsupported Media Type and Produces rejects clients whose Accept
+// header excludes what the route produces with 406 Not Acceptable:
+//
+//	api := q.Group("/api")
+//	api.Use(contenttype.Consumes("application/json"))
+//	api.Use(contenttype.Produces("application/json"))
+//
+// Parameters such as charset are ignored and types may use wildcards,
+// e.g. "text/*".
+package contenttype
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jeffotoni/quick"
+)
+
+// Consumes creates a middleware that only lets through requests whose
+// body has one of the given media types. Requests without a body, e.g.
+// most GET requests, are not checked.
+// The result will Consumes(types ...string) func(http.Handler) http.Handler
+func Consumes(types ...string) func(http.Handler) http.Handler {
+	allowed := normalize(types)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if hasBody(r) {
+				mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+				if err != nil || !matchAny(allowed, mediaType) {
+					http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Produces creates a middleware that answers 406 Not Acceptable when the
+// Accept header of the request excludes every given media type, honoring
+// ranges such as "application/*" and q=0. Requests without Accept accept anything.
+// Vary: Accept is added to every response, since its outcome depends on it.
+// The result will Produces(types ...string) func(http.Handler) http.Handler
+func Produces(types ...string) func(http.Handler) http.Handler {
+	produced := normalize(types)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			(&quick.Ctx{Response: w, Request: r}).Vary("Accept")
+			if accept := r.Header.Values("Accept"); len(accept) > 0 && !accepts(strings.Join(accept, ","), produced) {
+				http.Error(w, http.StatusText(http.StatusNotAcceptable), http.StatusNotAcceptable)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// accepts reports whether the Accept header allows one of the produced types.
+// A type excluded with q=0 stays excluded even when a wider range allows it.
+func accepts(accept string, produced []string) bool {
+	type mediaRange struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if qs, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(qs, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType, q})
+	}
+
+	for _, p := range produced {
+		// the most specific range matching p decides
+		best, specificity := -1.0, -1
+		for _, r := range ranges {
+			if !match(r.mediaType, p) {
+				continue
+			}
+			s := 0
+			if r.mediaType == p {
+				s = 2
+			} else if !strings.HasPrefix(r.mediaType, "*/") {
+				s = 1
+			}
+			if s > specificity {
+				best, specificity = r.q, s
+			}
+		}
+		if best > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// hasBody reports whether the request carries a body
+func hasBody(r *http.Request) bool {
+	return r.ContentLength > 0 || (r.ContentLength < 0 && r.Body != nil && r.Body != http.NoBody) || len(r.TransferEncoding) > 0
+}
+
+// normalize lowercases the media types and drops their parameters
+func normalize(types []string) []string {
+	out := make([]string, 0, len(types))
+	for _, t := range types {
+		mediaType, _, err := mime.ParseMediaType(t)
+		if err != nil {
+			mediaType = strings.ToLower(strings.TrimSpace(t))
+		}
+		out = append(out, mediaType)
+	}
+	return out
+}
+
+// matchAny reports whether mediaType matches one of the patterns,
+// which may be "*/*" or "type/*"
+func matchAny(patterns []string, mediaType string) bool {
+	for _, p := range patterns {
+		if match(p, mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// match compares two media types, either of which may be a wildcard range
+func match(a, b string) bool {
+	if a == b || a == "*/*" || b == "*/*" {
+		return true
+	}
+	aType, aSub, _ := strings.Cut(a, "/")
+	bType, bSub, _ := strings.Cut(b, "/")
+	return aType == bType && (aSub == "*" || bSub == "*")
+}
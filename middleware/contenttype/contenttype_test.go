@@ -0,0 +1,88 @@
+package contenttype
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jeffotoni/quick"
+)
+
+// go test -v -failfast -count=1 -run ^TestConsumes$
+func TestConsumes(t *testing.T) {
+	q := quick.New()
+	api := q.Group("/api")
+	api.Use(Consumes("application/json", "text/*"))
+	api.Post("/users", func(c *quick.Ctx) error {
+		return c.Status(http.StatusCreated).SendString("created")
+	})
+	api.Get("/users", func(c *quick.Ctx) error {
+		return c.Status(http.StatusOK).SendString("users")
+	})
+
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		want        int
+	}{
+		{"json", http.MethodPost, "application/json", `{}`, http.StatusCreated},
+		{"json with charset", http.MethodPost, "Application/JSON; charset=utf-8", `{}`, http.StatusCreated},
+		{"wildcard", http.MethodPost, "text/csv", "a,b", http.StatusCreated},
+		{"xml rejected", http.MethodPost, "application/xml", "<user/>", http.StatusUnsupportedMediaType},
+		{"missing content type", http.MethodPost, "", `{}`, http.StatusUnsupportedMediaType},
+		{"no body", http.MethodGet, "", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/users", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			q.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestProduces$
+func TestProduces(t *testing.T) {
+	q := quick.New()
+	api := q.Group("/api")
+	api.Use(Produces("application/json"))
+	api.Get("/users", func(c *quick.Ctx) error {
+		return c.Status(http.StatusOK).JSON([]string{"jeff"})
+	})
+
+	tests := []struct {
+		accept string
+		want   int
+	}{
+		{"", http.StatusOK},
+		{"application/json", http.StatusOK},
+		{"text/html, application/*;q=0.5", http.StatusOK},
+		{"*/*", http.StatusOK},
+		{"text/html", http.StatusNotAcceptable},
+		{"application/json;q=0, */*", http.StatusNotAcceptable},
+		{"application/xml, text/*", http.StatusNotAcceptable},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		rec := httptest.NewRecorder()
+		q.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("Accept %q: expected %d, got %d", tt.accept, tt.want, rec.Code)
+		}
+		if vary := rec.Header().Values("Vary"); len(vary) != 1 || vary[0] != "Accept" {
+			t.Errorf("Accept %q: expected Vary: Accept, got %v", tt.accept, vary)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Package decompress provides a middleware that transparently decompresses
+// request bodies sent with Content-Encoding gzip or deflate, so handlers
+// and BodyParser receive the plain payload.
+//
+// The decompressed size is limited to protect the server from zip bombs.
+package decompress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// defaultMaxSize is the default limit for a decompressed body (10MB)
+const defaultMaxSize int64 = 10 * 1024 * 1024
+
+// Config defines the config for the decompress middleware
+type Config struct {
+	// MaxSize is the maximum size in bytes of the decompressed body.
+	// Requests exceeding it receive 413 Request Entity Too Large.
+	MaxSize int64
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	MaxSize: defaultMaxSize,
+}
+
+// New creates the decompress middleware
+// The result will New(config ...Config) func(http.Handler) http.Handler
+func New(config ...Config) func(http.Handler) http.Handler {
+	cfg := ConfigDefault
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = defaultMaxSize
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+			if r.Body == nil || (encoding != "gzip" && encoding != "deflate") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			zr, err := newReader(encoding, r.Body)
+			if err != nil {
+				http.Error(w, "invalid "+encoding+" request body", http.StatusBadRequest)
+				return
+			}
+			defer zr.Close()
+
+			// read one extra byte to detect bodies above the limit
+			body, err := io.ReadAll(io.LimitReader(zr, cfg.MaxSize+1))
+			if err != nil {
+				http.Error(w, "invalid "+encoding+" request body", http.StatusBadRequest)
+				return
+			}
+			if int64(len(body)) > cfg.MaxSize {
+				http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+			r.Header.Set("Content-Length", strconv.Itoa(len(body)))
+			r.Header.Del("Content-Encoding")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// newReader returns the decompressing reader for the encoding
+func newReader(encoding string, body io.Reader) (io.ReadCloser, error) {
+	if encoding == "gzip" {
+		return gzip.NewReader(body)
+	}
+	return zlib.NewReader(body)
+}
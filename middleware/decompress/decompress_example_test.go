@@ -0,0 +1,48 @@
+package decompress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+
+	"github.com/jeffotoni/quick"
+)
+
+// This function is named ExampleNew()
+// it with the Examples type.
+func ExampleNew() {
+	//starting Quick
+	q := quick.New()
+
+	// calling middleware
+	q.Use(New(Config{MaxSize: 1 << 20}))
+
+	q.Post("/user", func(c *quick.Ctx) error {
+		var u struct {
+			Name string `json:"name"`
+		}
+		if err := c.BodyParser(&u); err != nil {
+			return err
+		}
+		return c.SendString("hello " + u.Name)
+	})
+
+	// gzip the JSON body as a client would
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(`{"name":"quick"}`))
+	zw.Close()
+
+	res, _ := q.Qtest(quick.QuickTestOptions{
+		Method: quick.MethodPost,
+		URI:    "/user",
+		Headers: map[string]string{
+			"Content-Type":     "application/json",
+			"Content-Encoding": "gzip",
+		},
+		Body: buf.Bytes(),
+	})
+	fmt.Println(res.BodyStr())
+
+	// Output: hello quick
+}
@@ -0,0 +1,73 @@
+package decompress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func gzipBytes(b []byte) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(b)
+	zw.Close()
+	return buf.Bytes()
+}
+
+func zlibBytes(b []byte) []byte {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(b)
+	zw.Close()
+	return buf.Bytes()
+}
+
+// go test -v -failfast -count=1 -run ^TestNew$
+func TestNew(t *testing.T) {
+	payload := []byte(`{"name":"quick"}`)
+
+	tests := []struct {
+		name     string
+		config   []Config
+		encoding string
+		body     []byte
+		wantCode int
+		wantBody string
+	}{
+		{"gzip", nil, "gzip", gzipBytes(payload), 200, string(payload)},
+		{"deflate", nil, "deflate", zlibBytes(payload), 200, string(payload)},
+		{"identity", nil, "", payload, 200, string(payload)},
+		{"invalid gzip", nil, "gzip", payload, 400, "invalid gzip request body\n"},
+		{"zip bomb", []Config{{MaxSize: 1024}}, "gzip", gzipBytes(make([]byte, 1<<20)), 413, "Request body too large\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := New(tt.config...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Content-Encoding") != "" {
+					t.Errorf("expected Content-Encoding to be removed")
+				}
+				b, _ := io.ReadAll(r.Body)
+				if r.ContentLength != int64(len(b)) {
+					t.Errorf("expected ContentLength %d, got %d", len(b), r.ContentLength)
+				}
+				w.Write(b)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tt.body))
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode || rec.Body.String() != tt.wantBody {
+				t.Errorf("expected %d %q, got %d %q", tt.wantCode, tt.wantBody, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+// Package dump provides a debugging middleware that writes a readable dump
+// of every request and response: method, URL, headers, body and the
+// response status, headers and body.
+//
+// It is meant for development only and does nothing unless Config.Enabled
+// is set. ConfigDefault enables it when the QUICK_DEV environment variable
+// is "true", so the same code can ship to production:
+//
+//	q.Use(dump.New())
+//
+// Bodies are truncated to Config.MaxBodySize and sensitive headers such as
+// Authorization and Cookie are redacted.
+package dump
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults used when the Config fields are not set
+const (
+	defaultMaxBodySize = 4 * 1024
+	redacted           = "[REDACTED]"
+)
+
+// defaultRedactHeaders lists the headers hidden by default
+var defaultRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// Config defines the config for the dump middleware
+type Config struct {
+	// Enabled turns the dumps on. When false New returns the next handler
+	// untouched. Default is true when QUICK_DEV is "true".
+	Enabled bool
+	// Output receives the dumps. Default os.Stderr.
+	Output io.Writer
+	// MaxBodySize is the number of body bytes shown for requests and
+	// responses, longer bodies are truncated. Default 4KB.
+	MaxBodySize int
+	// RedactHeaders are shown as [REDACTED] in requests and responses.
+	// Default Authorization, Proxy-Authorization, Cookie and Set-Cookie.
+	RedactHeaders []string
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Enabled:       os.Getenv("QUICK_DEV") == "true",
+	Output:        os.Stderr,
+	MaxBodySize:   defaultMaxBodySize,
+	RedactHeaders: defaultRedactHeaders,
+}
+
+// New creates the dump middleware
+// The result will New(config ...Config) func(http.Handler) http.Handler
+func New(config ...Config) func(http.Handler) http.Handler {
+	cfg := ConfigDefault
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if !cfg.Enabled {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	if cfg.Output == nil {
+		cfg.Output = os.Stderr
+	}
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = defaultMaxBodySize
+	}
+	if cfg.RedactHeaders == nil {
+		cfg.RedactHeaders = defaultRedactHeaders
+	}
+	redact := make(map[string]bool, len(cfg.RedactHeaders))
+	for _, h := range cfg.RedactHeaders {
+		redact[http.CanonicalHeaderKey(h)] = true
+	}
+
+	var mu sync.Mutex
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			var reqBody []byte
+			if r.Body != nil && r.Body != http.NoBody {
+				reqBody, _ = io.ReadAll(r.Body)
+				r.Body = io.NopCloser(bytes.NewReader(reqBody))
+			}
+
+			dw := &dumpWriter{ResponseWriter: w, limit: cfg.MaxBodySize}
+			next.ServeHTTP(dw, r)
+			if dw.status == 0 {
+				dw.status = http.StatusOK
+			}
+
+			var b strings.Builder
+			fmt.Fprintf(&b, "---- dump %s %s ----\n", r.Method, r.URL.Path)
+			fmt.Fprintf(&b, "> %s %s %s\n", r.Method, r.URL.RequestURI(), r.Proto)
+			fmt.Fprintf(&b, "> Host: %s\n", r.Host)
+			writeHeaders(&b, "> ", r.Header, redact)
+			writeBody(&b, "> ", reqBody, len(reqBody), cfg.MaxBodySize)
+			fmt.Fprintf(&b, "< %d %s (%v)\n", dw.status, http.StatusText(dw.status), time.Since(start))
+			writeHeaders(&b, "< ", w.Header(), redact)
+			writeBody(&b, "< ", dw.body.Bytes(), dw.size, cfg.MaxBodySize)
+
+			mu.Lock()
+			_, _ = io.WriteString(cfg.Output, b.String())
+			mu.Unlock()
+		})
+	}
+}
+
+// writeHeaders writes the headers sorted by name, hiding the redacted ones
+func writeHeaders(b *strings.Builder, prefix string, h http.Header, redact map[string]bool) {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		value := strings.Join(h[k], ", ")
+		if redact[http.CanonicalHeaderKey(k)] {
+			value = redacted
+		}
+		fmt.Fprintf(b, "%s%s: %s\n", prefix, k, value)
+	}
+}
+
+// writeBody writes the first max bytes of body, noting the truncation
+func writeBody(b *strings.Builder, prefix string, body []byte, size, max int) {
+	if size == 0 {
+		return
+	}
+	b.WriteString(prefix + "\n")
+	if len(body) > max {
+		body = body[:max]
+	}
+	for _, line := range strings.Split(string(body), "\n") {
+		b.WriteString(prefix + line + "\n")
+	}
+	if size > len(body) {
+		fmt.Fprintf(b, "%s... (%d bytes truncated)\n", prefix, size-len(body))
+	}
+}
+
+// dumpWriter passes the response through, recording the status, the
+// number of bytes written and the first bytes of the body
+type dumpWriter struct {
+	http.ResponseWriter
+	status int
+	size   int
+	limit  int
+	body   bytes.Buffer
+}
+
+func (w *dumpWriter) WriteHeader(status int) {
+	// informational responses, e.g. 103 Early Hints, precede the final status
+	if w.status == 0 && (status < 100 || status > 199) {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *dumpWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if room := w.limit - w.body.Len(); room > 0 {
+		if room > len(p) {
+			room = len(p)
+		}
+		w.body.Write(p[:room])
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.size += n
+	return n, err
+}
+
+// Flush keeps streaming responses working through the dump
+func (w *dumpWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
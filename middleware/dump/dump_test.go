@@ -0,0 +1,81 @@
+package dump
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jeffotoni/quick"
+)
+
+// go test -v -failfast -count=1 -run ^TestNew$
+func TestNew(t *testing.T) {
+	var out bytes.Buffer
+	q := quick.New()
+	q.Use(New(Config{Enabled: true, Output: &out, MaxBodySize: 10}))
+	q.Post("/login", func(c *quick.Ctx) error {
+		c.Set("Set-Cookie", "session=secret")
+		c.Set("Content-Type", "text/plain")
+		return c.Status(http.StatusCreated).SendString("welcome back, jeff")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/login?next=home", strings.NewReader(`{"user":"jeff"}`))
+	req.Header.Set("Authorization", "Bearer token")
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	q.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusCreated || rec.Body.String() != "welcome back, jeff" {
+		t.Fatalf("expected the response to pass through, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	dump := out.String()
+	for _, want := range []string{
+		"---- dump POST /login ----",
+		"> POST /login?next=home HTTP/1.1",
+		"> Authorization: [REDACTED]",
+		"> Content-Type: application/json",
+		`> {"user":"j`,
+		"> ... (5 bytes truncated)",
+		"< 201 Created",
+		"< Set-Cookie: [REDACTED]",
+		"< welcome ba",
+		"< ... (8 bytes truncated)",
+	} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("expected the dump to contain %q, got:\n%s", want, dump)
+		}
+	}
+	if strings.Contains(dump, "Bearer token") || strings.Contains(dump, "session=secret") {
+		t.Errorf("expected sensitive headers to be redacted, got:\n%s", dump)
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNewRequestBody$
+func TestNewRequestBody(t *testing.T) {
+	var got string
+	h := New(Config{Enabled: true, Output: io.Discard})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got = string(b)
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/", strings.NewReader("payload")))
+	if got != "payload" {
+		t.Errorf("expected the handler to read the full body, got %q", got)
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNewDisabled$
+func TestNewDisabled(t *testing.T) {
+	var out bytes.Buffer
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	h := New(Config{Output: &out})(next)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if out.Len() != 0 {
+		t.Errorf("expected no dump when disabled, got %q", out.String())
+	}
+}
@@ -0,0 +1,133 @@
+// Package envelope provides a middleware that wraps every JSON response in
+// a uniform envelope, so clients always find the payload in the same place:
+//
+//	q.Use(envelope.New(envelope.Config{
+//		Meta: func(c *quick.Ctx) interface{} {
+//			return map[string]string{"request_id": c.Request.Header.Get("X-Request-ID")}
+//		},
+//	}))
+//
+//	// c.JSON(user) => {"data":{"name":"jeff"},"meta":{"request_id":"..."}}
+//
+// Only responses with a JSON Content-Type are wrapped. Error responses
+// (status 400 and above) are left as they are unless Config.Error gives
+// them their own shape. The response is buffered until the handler
+// returns, so skip streaming routes with Config.Skip.
+package envelope
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/jeffotoni/quick"
+	"github.com/jeffotoni/quick/middleware/transform"
+)
+
+// Default keys of the envelope
+const (
+	defaultDataKey = "data"
+	defaultMetaKey = "meta"
+)
+
+// Config defines the config for the envelope middleware
+type Config struct {
+	// DataKey holds the original body. Default: data
+	DataKey string
+	// MetaKey holds the value returned by Meta. Default: meta
+	MetaKey string
+	// Meta, if set, returns the value of MetaKey for the request, e.g.
+	// pagination or the request ID. A nil result leaves MetaKey out.
+	Meta func(c *quick.Ctx) interface{}
+	// Error, if set, returns the value sent instead of a JSON error
+	// response, e.g. {"error": body}. By default error responses are
+	// sent unchanged.
+	Error func(c *quick.Ctx, status int, body json.RawMessage) interface{}
+	// Skip, if set, leaves the requests it returns true for untouched,
+	// without buffering their response.
+	Skip func(c *quick.Ctx) bool
+}
+
+// New creates the envelope middleware
+// The result will New(config ...Config) func(http.Handler) http.Handler
+func New(config ...Config) func(http.Handler) http.Handler {
+	var cfg Config
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.DataKey == "" {
+		cfg.DataKey = defaultDataKey
+	}
+	if cfg.MetaKey == "" {
+		cfg.MetaKey = defaultMetaKey
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c := &quick.Ctx{Response: w, Request: r}
+			if cfg.Skip != nil && cfg.Skip(c) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rec := transform.NewRecorder()
+			next.ServeHTTP(rec, r)
+
+			res := rec.Response()
+			if err := cfg.wrap(c, res); err != nil {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			// #nosec G104
+			transform.WriteTo(w, res)
+		})
+	}
+}
+
+// wrap replaces the body of a JSON response with its envelope
+// Method Used Internally
+// The result will wrap(c *quick.Ctx, res *transform.Response) error
+func (cfg Config) wrap(c *quick.Ctx, res *transform.Response) error {
+	body := bytes.TrimSpace(res.Body)
+	if !isJSON(res.Header.Get("Content-Type")) || len(body) == 0 || !json.Valid(body) {
+		return nil
+	}
+
+	var v interface{}
+	if res.Status >= http.StatusBadRequest {
+		if cfg.Error == nil {
+			return nil
+		}
+		v = cfg.Error(c, res.Status, json.RawMessage(body))
+	} else {
+		env := map[string]interface{}{cfg.DataKey: json.RawMessage(body)}
+		if cfg.Meta != nil {
+			if meta := cfg.Meta(c); meta != nil {
+				env[cfg.MetaKey] = meta
+			}
+		}
+		v = env
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	res.Body = bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	return nil
+}
+
+// isJSON reports whether the content type is application/json or a +json type
+// Method Used Internally
+// The result will isJSON(contentType string) bool
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,85 @@
+package envelope
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jeffotoni/quick"
+)
+
+// go test -v -failfast -count=1 -run ^TestNew$
+func TestNew(t *testing.T) {
+	q := quick.New()
+	q.Use(New(Config{
+		Meta: func(c *quick.Ctx) interface{} {
+			return map[string]string{"path": c.Path()}
+		},
+	}))
+	q.Get("/users/:id", func(c *quick.Ctx) error {
+		return c.Status(http.StatusOK).JSON(map[string]string{"id": c.Param("id"), "html": "<b>"})
+	})
+	q.Get("/text", func(c *quick.Ctx) error {
+		return c.Status(http.StatusOK).SendString("plain")
+	})
+	q.Get("/missing", func(c *quick.Ctx) error {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{"error": "not found"})
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/users/42", http.StatusOK, `{"data":{"html":"\u003cb\u003e","id":"42"},"meta":{"path":"/users/42"}}`},
+		{"/text", http.StatusOK, "plain"},
+		{"/missing", http.StatusNotFound, `{"error":"not found"}`},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		q.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.status || rec.Body.String() != tt.body {
+			t.Errorf("%s: expected %d %s, got %d %s", tt.path, tt.status, tt.body, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNewConfig$
+func TestNewConfig(t *testing.T) {
+	q := quick.New()
+	q.Use(New(Config{
+		DataKey: "result",
+		Error: func(c *quick.Ctx, status int, body json.RawMessage) interface{} {
+			return map[string]interface{}{"status": status, "error": body}
+		},
+		Skip: func(c *quick.Ctx) bool {
+			return c.Path() == "/raw"
+		},
+	}))
+	q.Get("/items", func(c *quick.Ctx) error {
+		return c.Status(http.StatusOK).JSON([]int{1, 2})
+	})
+	q.Get("/raw", func(c *quick.Ctx) error {
+		return c.Status(http.StatusOK).JSON([]int{3})
+	})
+	q.Get("/fail", func(c *quick.Ctx) error {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{"field": "name"})
+	})
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/items", `{"result":[1,2]}`},
+		{"/raw", `[3]`},
+		{"/fail", `{"error":{"field":"name"},"status":400}`},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		q.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Body.String() != tt.body {
+			t.Errorf("%s: expected %s, got %s", tt.path, tt.body, rec.Body.String())
+		}
+	}
+}
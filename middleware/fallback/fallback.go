@@ -0,0 +1,161 @@
+// Package fallback wraps another middleware so that, when it fails, a
+// fallback answers the request instead of the failure response. It lets an
+// app degrade rather than hard-fail when a dependency is down, e.g. serve
+// anonymous content while the auth service is unavailable:
+//
+//	q.Use(fallback.New(auth, fallback.Config{
+//		Fallback: func(c *quick.Ctx, next http.Handler) error {
+//			c.Request.Header.Del("X-User")
+//			next.ServeHTTP(c.Response, c.Request) // continue as anonymous
+//			return nil
+//		},
+//	}))
+//
+// The middleware fails when it panics, or answers with a 5xx status, before
+// calling the next handler. Until then its response is buffered; once it
+// calls the next handler everything is passed through, so errors of the
+// handler itself are not affected.
+package fallback
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/jeffotoni/quick"
+	"github.com/jeffotoni/quick/middleware/transform"
+)
+
+// Config defines the config for the fallback middleware
+type Config struct {
+	// Fallback answers the request when the middleware fails. It receives
+	// the handler the middleware protects, so it can serve the route anyway
+	// or answer on its own, e.g. with cached content. A returned error is
+	// written by c.HandleError. Required.
+	Fallback func(c *quick.Ctx, next http.Handler) error
+	// Failed reports whether the status answered by the middleware is a
+	// failure. Default: 500 and above.
+	Failed func(status int) bool
+}
+
+type ctxKey struct{}
+
+// guard is the http.ResponseWriter handed to the middleware. It buffers
+// until the middleware calls the next handler, then writes through.
+type guard struct {
+	w         http.ResponseWriter
+	rec       *transform.Recorder
+	passed    bool
+	isFailure func(status int) bool
+}
+
+// New returns mw wrapped so that its failures are answered by
+// config.Fallback
+// The result will New(mw func(http.Handler) http.Handler, config Config) func(http.Handler) http.Handler
+func New(mw func(http.Handler) http.Handler, config Config) func(http.Handler) http.Handler {
+	if config.Fallback == nil {
+		panic("fallback: Config.Fallback is required")
+	}
+	if config.Failed == nil {
+		config.Failed = func(status int) bool { return status >= http.StatusInternalServerError }
+	}
+
+	return func(next http.Handler) http.Handler {
+		wrapped := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if g, ok := r.Context().Value(ctxKey{}).(*guard); ok {
+				g.pass()
+			}
+			next.ServeHTTP(w, r)
+		}))
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			g := &guard{w: w, rec: transform.NewRecorder(), isFailure: config.Failed}
+			if !g.serve(wrapped, r.WithContext(context.WithValue(r.Context(), ctxKey{}, g))) {
+				return
+			}
+
+			c := &quick.Ctx{Response: w, Request: r}
+			if err := config.Fallback(c, next); err != nil {
+				// #nosec G104
+				c.HandleError(err)
+			}
+		})
+	}
+}
+
+// serve runs the middleware and reports whether it failed before calling
+// the next handler. Otherwise its buffered response, if any, is sent.
+// Method Used Internally
+// The result will serve(h http.Handler, r *http.Request) (failed bool)
+func (g *guard) serve(h http.Handler, r *http.Request) (failed bool) {
+	defer func() {
+		if g.passed {
+			return
+		}
+		if p := recover(); p != nil {
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+			failed = true
+		}
+	}()
+	h.ServeHTTP(g, r)
+	if g.passed {
+		return false
+	}
+	res := g.rec.Response()
+	if failed = g.isFailure(res.Status); !failed {
+		// #nosec G104
+		transform.WriteTo(g.w, res)
+	}
+	return failed
+}
+
+// pass switches the guard to write through, keeping the headers the
+// middleware set before calling the next handler
+// Method Used Internally
+// The result will pass()
+func (g *guard) pass() {
+	if g.passed {
+		return
+	}
+	g.passed = true
+	dst := g.w.Header()
+	for k, v := range g.rec.Header() {
+		dst[k] = v
+	}
+}
+
+// Header returns the buffered headers until the next handler runs
+// The result will Header() http.Header
+func (g *guard) Header() http.Header {
+	if g.passed {
+		return g.w.Header()
+	}
+	return g.rec.Header()
+}
+
+// WriteHeader records the status until the next handler runs
+// The result will WriteHeader(status int)
+func (g *guard) WriteHeader(status int) {
+	if g.passed {
+		g.w.WriteHeader(status)
+		return
+	}
+	g.rec.WriteHeader(status)
+}
+
+// Write buffers the body until the next handler runs
+// The result will Write(b []byte) (int, error)
+func (g *guard) Write(b []byte) (int, error) {
+	if g.passed {
+		return g.w.Write(b)
+	}
+	return g.rec.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g.
+// to flush a streamed response
+// The result will Unwrap() http.ResponseWriter
+func (g *guard) Unwrap() http.ResponseWriter {
+	return g.w
+}
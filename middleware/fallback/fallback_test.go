@@ -0,0 +1,128 @@
+package fallback
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jeffotoni/quick"
+)
+
+// auth answers 503 while its dependency is down, 401 without a token and
+// otherwise sets X-User for the handler
+func auth(down *bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if *down {
+				w.Header().Set("Retry-After", "5")
+				http.Error(w, "auth unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			if r.Header.Get("Authorization") == "" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			r.Header.Set("X-User", "jeff")
+			w.Header().Set("X-Auth", "ok")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNew$
+func TestNew(t *testing.T) {
+	down := false
+	q := quick.New()
+	q.Use(New(auth(&down), Config{
+		Fallback: func(c *quick.Ctx, next http.Handler) error {
+			c.Request.Header.Set("X-User", "anonymous")
+			next.ServeHTTP(c.Response, c.Request)
+			return nil
+		},
+	}))
+	q.Get("/feed", func(c *quick.Ctx) error {
+		return c.Status(http.StatusOK).SendString("feed for " + c.Request.Header.Get("X-User"))
+	})
+
+	get := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/feed", nil)
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		rec := httptest.NewRecorder()
+		q.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("token")
+	if rec.Code != http.StatusOK || rec.Body.String() != "feed for jeff" || rec.Header().Get("X-Auth") != "ok" {
+		t.Errorf("Expected the authenticated feed, got %d %q %v", rec.Code, rec.Body.String(), rec.Header())
+	}
+
+	rec = get("")
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("Content-Length") == "" {
+		t.Errorf("Expected the 401 of the middleware, got %d %v", rec.Code, rec.Header())
+	}
+
+	down = true
+	rec = get("token")
+	if rec.Code != http.StatusOK || rec.Body.String() != "feed for anonymous" || rec.Header().Get("Retry-After") != "" {
+		t.Errorf("Expected the anonymous feed, got %d %q %v", rec.Code, rec.Body.String(), rec.Header())
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNewPanic$
+func TestNewPanic(t *testing.T) {
+	panicking := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("auth client is nil")
+		})
+	}
+	q := quick.New()
+	q.Use(New(panicking, Config{
+		Fallback: func(c *quick.Ctx, next http.Handler) error {
+			return errors.New("degraded")
+		},
+	}))
+	q.Get("/feed", func(c *quick.Ctx) error {
+		return c.Status(http.StatusOK).SendString("feed")
+	})
+
+	rec := httptest.NewRecorder()
+	q.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feed", nil))
+	if rec.Code != http.StatusInternalServerError || rec.Body.String() != "degraded" {
+		t.Errorf("Expected the fallback error, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNewFailed$
+func TestNewFailed(t *testing.T) {
+	down := true
+	q := quick.New()
+	q.Use(New(auth(&down), Config{
+		Failed: func(status int) bool { return status == http.StatusServiceUnavailable },
+		Fallback: func(c *quick.Ctx, next http.Handler) error {
+			return c.Status(http.StatusOK).SendString("cached")
+		},
+	}))
+	q.Get("/feed", func(c *quick.Ctx) error {
+		return c.Status(http.StatusInternalServerError).SendString("handler failed")
+	})
+
+	rec := httptest.NewRecorder()
+	q.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feed", nil))
+	if rec.Body.String() != "cached" {
+		t.Errorf("Expected the cached response, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	// errors of the handler itself are not the middleware failing
+	down = false
+	req := httptest.NewRequest(http.MethodGet, "/feed", nil)
+	req.Header.Set("Authorization", "token")
+	rec = httptest.NewRecorder()
+	q.ServeHTTP(rec, req)
+	if rec.Code != http.StatusInternalServerError || rec.Body.String() != "handler failed" {
+		t.Errorf("Expected the handler response, got %d %q", rec.Code, rec.Body.String())
+	}
+}
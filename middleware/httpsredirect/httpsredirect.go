@@ -0,0 +1,80 @@
+// Package httpsredirect provides a middleware that forces TLS: requests
+// made over plain http are redirected to https, or rejected with 403.
+//
+// The protocol is read with c.Protocol(), so behind a TLS terminating
+// proxy the X-Forwarded-Proto header is honored for the addresses listed in
+// quick.Config.TrustedProxies:
+//
+//	q := quick.New(quick.Config{TrustedProxies: []string{"10.0.0.0/8"}})
+//	q.Use(httpsredirect.New())
+//
+//	// GET http://example.com/docs?page=2 => 301 Location: https://example.com/docs?page=2
+package httpsredirect
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/jeffotoni/quick"
+)
+
+// Config defines the config for the httpsredirect middleware
+type Config struct {
+	// Reject answers plain http requests with 403 Forbidden instead of
+	// redirecting them, e.g. for APIs whose clients must not retry over http.
+	Reject bool
+	// Host, if set, is the host of the redirect, e.g. "example.com:8443".
+	// Default: the host of the request without its port.
+	Host string
+	// Skip, if set, lets the requests it returns true for through over
+	// http, e.g. health checks of the load balancer.
+	Skip func(c *quick.Ctx) bool
+}
+
+// New creates the httpsredirect middleware
+// The result will New(config ...Config) func(http.Handler) http.Handler
+func New(config ...Config) func(http.Handler) http.Handler {
+	var cfg Config
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c := &quick.Ctx{Response: w, Request: r}
+			if c.Protocol() == "https" || (cfg.Skip != nil && cfg.Skip(c)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if cfg.Reject {
+				http.Error(w, "HTTPS Required", http.StatusForbidden)
+				return
+			}
+
+			// 308 keeps the method and body of non GET requests
+			status := http.StatusPermanentRedirect
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				status = http.StatusMovedPermanently
+			}
+			http.Redirect(w, r, "https://"+cfg.host(r)+r.URL.RequestURI(), status)
+		})
+	}
+}
+
+// host returns the host of the redirect
+// Method Used Internally
+// The result will host(r *http.Request) string
+func (cfg Config) host(r *http.Request) string {
+	if cfg.Host != "" {
+		return cfg.Host
+	}
+	if host, _, err := net.SplitHostPort(r.Host); err == nil {
+		if strings.Contains(host, ":") {
+			return "[" + host + "]"
+		}
+		return host
+	}
+	return r.Host
+}
@@ -0,0 +1,90 @@
+package httpsredirect
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jeffotoni/quick"
+)
+
+// go test -v -failfast -count=1 -run ^TestNew$
+func TestNew(t *testing.T) {
+	q := quick.New(quick.Config{TrustedProxies: []string{"10.0.0.1"}, MaxBodySize: 1 << 20})
+	q.Use(New(Config{Skip: func(c *quick.Ctx) bool { return c.Path() == "/health" }}))
+	q.Get("/docs", func(c *quick.Ctx) error {
+		return c.Status(http.StatusOK).SendString("docs")
+	})
+	q.Post("/users", func(c *quick.Ctx) error {
+		return c.Status(http.StatusCreated).SendString("created")
+	})
+	q.Get("/health", func(c *quick.Ctx) error {
+		return c.Status(http.StatusOK).SendString("ok")
+	})
+
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		remote   string
+		proto    string
+		tls      bool
+		status   int
+		location string
+	}{
+		{"http", http.MethodGet, "http://example.com:8080/docs?page=2", "192.0.2.1:1234", "", false, http.StatusMovedPermanently, "https://example.com/docs?page=2"},
+		{"post keeps the method", http.MethodPost, "http://example.com/users", "192.0.2.1:1234", "", false, http.StatusPermanentRedirect, "https://example.com/users"},
+		{"ipv6", http.MethodGet, "http://[::1]:8080/docs", "192.0.2.1:1234", "", false, http.StatusMovedPermanently, "https://[::1]/docs"},
+		{"tls", http.MethodGet, "https://example.com/docs", "192.0.2.1:1234", "", true, http.StatusOK, ""},
+		{"trusted proxy https", http.MethodGet, "http://example.com/docs", "10.0.0.1:1234", "https", false, http.StatusOK, ""},
+		{"trusted proxy http", http.MethodGet, "http://example.com/docs", "10.0.0.1:1234", "http", false, http.StatusMovedPermanently, "https://example.com/docs"},
+		{"spoofed header", http.MethodGet, "http://example.com/docs", "192.0.2.1:1234", "https", false, http.StatusMovedPermanently, "https://example.com/docs"},
+		{"skip", http.MethodGet, "http://example.com/health", "192.0.2.1:1234", "", false, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.target, nil)
+		req.RemoteAddr = tt.remote
+		if tt.proto != "" {
+			req.Header.Set("X-Forwarded-Proto", tt.proto)
+		}
+		if !tt.tls {
+			req.TLS = nil
+		} else if req.TLS == nil {
+			req.TLS = &tls.ConnectionState{}
+		}
+		rec := httptest.NewRecorder()
+		q.ServeHTTP(rec, req)
+		if rec.Code != tt.status || rec.Header().Get("Location") != tt.location {
+			t.Errorf("%s: expected %d %q, got %d %q", tt.name, tt.status, tt.location, rec.Code, rec.Header().Get("Location"))
+		}
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNewReject$
+func TestNewReject(t *testing.T) {
+	q := quick.New()
+	q.Use(New(Config{Reject: true}))
+	q.Get("/docs", func(c *quick.Ctx) error {
+		return c.Status(http.StatusOK).SendString("docs")
+	})
+
+	rec := httptest.NewRecorder()
+	q.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://example.com/docs", nil))
+	if rec.Code != http.StatusForbidden || rec.Header().Get("Location") != "" {
+		t.Errorf("expected 403 without redirect, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNewHost$
+func TestNewHost(t *testing.T) {
+	h := New(Config{Host: "example.com:8443"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, "http://localhost:8080/a?b=c", nil))
+	if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "https://example.com:8443/a?b=c" {
+		t.Errorf("expected a redirect to the configured host, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+}
@@ -0,0 +1,189 @@
+// Package idempotency provides a middleware that makes retries of unsafe
+// requests, e.g. a POST creating a payment, safe: the response to a request
+// carrying an Idempotency-Key header is kept for a TTL and replayed for
+// every later request with the same key, so the handler runs only once.
+//
+//	q.Use(idempotency.New(idempotency.Config{TTL: 24 * time.Hour}))
+//
+// Keys are scoped by method and path. While the first request is still
+// running, duplicates get 409 Conflict. Reusing a key with a different body
+// gets 422 Unprocessable Entity. Replayed responses carry the header
+// Idempotent-Replayed: true.
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jeffotoni/quick/middleware/transform"
+)
+
+// Defaults used when the Config fields are not set
+const (
+	defaultHeader = "Idempotency-Key"
+	defaultTTL    = 24 * time.Hour
+	maxKeyLength  = 255
+)
+
+// Config defines the config for the idempotency middleware
+type Config struct {
+	// Header carrying the key. Default: Idempotency-Key
+	Header string
+	// TTL is how long a response is replayed. Default 24 hours.
+	TTL time.Duration
+	// Methods the middleware applies to. Default POST and PATCH;
+	// requests with other methods, or without the header, pass through.
+	Methods []string
+}
+
+// entry is the state of one key
+type entry struct {
+	done    bool
+	sum     [sha256.Size]byte
+	res     *transform.Response
+	expires time.Time
+}
+
+// store keeps the responses of one middleware
+type store struct {
+	mu        sync.Mutex
+	entries   map[string]*entry
+	ttl       time.Duration
+	nextSweep time.Time
+	now       func() time.Time
+}
+
+// New creates the idempotency middleware
+// The result will New(config ...Config) func(http.Handler) http.Handler
+func New(config ...Config) func(http.Handler) http.Handler {
+	var cfg Config
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Header == "" {
+		cfg.Header = defaultHeader
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = defaultTTL
+	}
+	if len(cfg.Methods) == 0 {
+		cfg.Methods = []string{http.MethodPost, http.MethodPatch}
+	}
+	methods := make(map[string]bool, len(cfg.Methods))
+	for _, m := range cfg.Methods {
+		methods[m] = true
+	}
+
+	s := &store{entries: make(map[string]*entry), ttl: cfg.TTL, now: time.Now}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(cfg.Header)
+			if id == "" || !methods[r.Method] {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(id) > maxKeyLength {
+				http.Error(w, "Idempotency key too long", http.StatusBadRequest)
+				return
+			}
+
+			var body []byte
+			if r.Body != nil {
+				var err error
+				if body, err = io.ReadAll(r.Body); err != nil {
+					http.Error(w, "Bad Request", http.StatusBadRequest)
+					return
+				}
+				r.Body = io.NopCloser(bytes.NewReader(body))
+			}
+			sum := sha256.Sum256(body)
+			key := r.Method + " " + r.URL.Path + " " + id
+
+			e, created := s.begin(key, sum)
+			switch {
+			case e.sum != sum:
+				http.Error(w, "Idempotency key reused with a different request", http.StatusUnprocessableEntity)
+				return
+			case !created && !e.done:
+				http.Error(w, "A request with this idempotency key is in progress", http.StatusConflict)
+				return
+			case !created:
+				w.Header().Set("Idempotent-Replayed", "true")
+				_ = transform.WriteTo(w, &transform.Response{Status: e.res.Status, Header: e.res.Header.Clone(), Body: e.res.Body})
+				return
+			}
+
+			rec := transform.NewRecorder()
+			func() {
+				// forget the key if the handler panics, so it can be retried
+				defer func() {
+					if e.res == nil {
+						s.forget(key)
+					}
+				}()
+				next.ServeHTTP(rec, r)
+				s.finish(e, rec.Response())
+			}()
+			_ = transform.WriteTo(w, e.res)
+		})
+	}
+}
+
+// begin returns the live entry for key, creating an in-progress one when
+// there is none. created reports whether the caller must run the handler.
+// Method Used Internally
+// The result will begin(key string, sum [sha256.Size]byte) (*entry, bool)
+func (s *store) begin(key string, sum [sha256.Size]byte) (*entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	if e, ok := s.entries[key]; ok && (!e.done || now.Before(e.expires)) {
+		return e, false
+	}
+	e := &entry{sum: sum}
+	s.entries[key] = e
+	return e, true
+}
+
+// finish stores the response of e and starts its TTL
+// Method Used Internally
+// The result will finish(e *entry, res *transform.Response)
+func (s *store) finish(e *entry, res *transform.Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e.res = res
+	e.done = true
+	e.expires = s.now().Add(s.ttl)
+}
+
+// forget drops key
+// Method Used Internally
+// The result will forget(key string)
+func (s *store) forget(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+}
+
+// sweep drops expired responses at most once per TTL
+// Method Used Internally
+// The result will sweep(now time.Time)
+func (s *store) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	for key, e := range s.entries {
+		if e.done && !now.Before(e.expires) {
+			delete(s.entries, key)
+		}
+	}
+	s.nextSweep = now.Add(s.ttl)
+}
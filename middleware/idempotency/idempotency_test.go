@@ -0,0 +1,121 @@
+package idempotency
+
+import (
+	"crypto/sha256"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jeffotoni/quick"
+	"github.com/jeffotoni/quick/middleware/transform"
+)
+
+// go test -v -failfast -count=1 -run ^TestNew$
+func TestNew(t *testing.T) {
+	var charges int32
+	q := quick.New()
+	q.Use(New())
+	q.Post("/charges", func(c *quick.Ctx) error {
+		n := atomic.AddInt32(&charges, 1)
+		c.Set("Content-Type", "application/json")
+		return c.Status(http.StatusCreated).SendString(`{"charge":` + strconv.Itoa(int(n)) + `}`)
+	})
+
+	post := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/charges", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		q.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := post("k1", `{"amount":10}`)
+	second := post("k1", `{"amount":10}`)
+	if atomic.LoadInt32(&charges) != 1 {
+		t.Fatalf("Expected the handler to run once, ran %d times", charges)
+	}
+	if second.Code != first.Code || second.Body.String() != first.Body.String() || second.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Expected identical responses, got %d %q and %d %q", first.Code, first.Body, second.Code, second.Body)
+	}
+	if first.Header().Get("Idempotent-Replayed") != "" || second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("Expected only the replay to be marked, got %v and %v", first.Header(), second.Header())
+	}
+
+	if rec := post("k1", `{"amount":99}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for a reused key with another body, got %d", rec.Code)
+	}
+	if rec := post("k2", `{"amount":10}`); rec.Code != http.StatusCreated || atomic.LoadInt32(&charges) != 2 {
+		t.Errorf("Expected a new key to run the handler, got %d after %d charges", rec.Code, charges)
+	}
+	post("", `{"amount":10}`)
+	if atomic.LoadInt32(&charges) != 3 {
+		t.Errorf("Expected requests without a key to pass through, got %d charges", charges)
+	}
+	if rec := post(strings.Repeat("k", maxKeyLength+1), ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a long key, got %d", rec.Code)
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNewInProgress$
+func TestNewInProgress(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	h := New()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.Write([]byte("done"))
+	}))
+
+	newReq := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/pay", nil)
+		req.Header.Set("Idempotency-Key", "k1")
+		return req
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, newReq())
+		done <- rec
+	}()
+	<-started
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newReq())
+	if rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 while the first request runs, got %d", rec.Code)
+	}
+
+	close(release)
+	if first := <-done; first.Body.String() != "done" {
+		t.Errorf("Expected the first request to complete, got %q", first.Body)
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestStoreExpiration$
+func TestStoreExpiration(t *testing.T) {
+	now := time.Now()
+	s := &store{entries: make(map[string]*entry), ttl: time.Minute, now: func() time.Time { return now }}
+	sum := sha256.Sum256(nil)
+
+	e, created := s.begin("k", sum)
+	if !created {
+		t.Fatal("Expected the first call to create the entry")
+	}
+	s.finish(e, &transform.Response{Status: http.StatusOK})
+
+	now = now.Add(59 * time.Second)
+	if _, created := s.begin("k", sum); created {
+		t.Error("Expected the response to be kept within the TTL")
+	}
+
+	now = now.Add(2 * time.Second)
+	if _, created := s.begin("k", sum); !created {
+		t.Error("Expected the key to be usable again after the TTL")
+	}
+}
@@ -0,0 +1,169 @@
+// Package limiter provides a fixed window rate limiting middleware.
+//
+// Every call to New owns its own counters, so a limiter applied to a group
+// counts independently from one applied globally with q.Use:
+//
+//	q.Use(limiter.New(limiter.Config{Max: 100, Expiration: time.Minute}))
+//
+//	auth := q.Group("/auth")
+//	auth.Use(limiter.New(limiter.Config{Max: 5, Expiration: time.Minute}))
+//
+// Requests over the limit are answered with 429 Too Many Requests.
+package limiter
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jeffotoni/quick"
+)
+
+// Defaults used when the Config fields are not set
+const (
+	defaultMax        = 5
+	defaultExpiration = time.Minute
+)
+
+// Config defines the config for the limiter middleware
+type Config struct {
+	// Max is the number of requests allowed per key in each window. Default 5.
+	Max int
+	// Expiration is the length of the window. Default 1 minute.
+	Expiration time.Duration
+	// KeyGenerator returns the key requests are counted by.
+	// Default is the client IP.
+	KeyGenerator func(c *quick.Ctx) string
+	// LimitReached writes the response sent when the limit is exceeded.
+	// Default is 429 with the body "Too Many Requests".
+	LimitReached func(c *quick.Ctx) error
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Max:          defaultMax,
+	Expiration:   defaultExpiration,
+	KeyGenerator: defaultKeyGenerator,
+	LimitReached: defaultLimitReached,
+}
+
+// defaultKeyGenerator keys requests by the client IP
+func defaultKeyGenerator(c *quick.Ctx) string {
+	ip, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+	if err != nil {
+		return c.Request.RemoteAddr
+	}
+	return ip
+}
+
+// defaultLimitReached answers 429 Too Many Requests
+func defaultLimitReached(c *quick.Ctx) error {
+	c.Set("Content-Type", "text/plain; charset=utf-8")
+	return c.Status(http.StatusTooManyRequests).SendString("Too Many Requests")
+}
+
+// New creates the limiter middleware
+// The result will New(config ...Config) func(http.Handler) http.Handler
+func New(config ...Config) func(http.Handler) http.Handler {
+	cfg := ConfigDefault
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Max <= 0 {
+		cfg.Max = defaultMax
+	}
+	if cfg.Expiration <= 0 {
+		cfg.Expiration = defaultExpiration
+	}
+	if cfg.KeyGenerator == nil {
+		cfg.KeyGenerator = defaultKeyGenerator
+	}
+	if cfg.LimitReached == nil {
+		cfg.LimitReached = defaultLimitReached
+	}
+
+	s := newStore(cfg.Expiration)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c := &quick.Ctx{Response: w, Request: r}
+			hits, reset := s.hit(cfg.KeyGenerator(c))
+
+			remaining := cfg.Max - hits
+			if remaining < 0 {
+				remaining = 0
+			}
+			resetSeconds := strconv.Itoa(int((reset + time.Second - 1) / time.Second))
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(cfg.Max))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+			w.Header().Set("X-RateLimit-Reset", resetSeconds)
+
+			if hits > cfg.Max {
+				w.Header().Set("Retry-After", resetSeconds)
+				if err := cfg.LimitReached(c); err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+				}
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// entry counts the hits of a key in the current window
+type entry struct {
+	hits    int
+	expires time.Time
+}
+
+// store keeps the counters of one limiter
+type store struct {
+	mu         sync.Mutex
+	entries    map[string]*entry
+	expiration time.Duration
+	nextSweep  time.Time
+	now        func() time.Time
+}
+
+// newStore creates an empty store
+func newStore(expiration time.Duration) *store {
+	return &store{
+		entries:    make(map[string]*entry),
+		expiration: expiration,
+		now:        time.Now,
+	}
+}
+
+// hit records a request for key and returns the hits in the current
+// window and the time left until it resets
+func (s *store) hit(key string) (int, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	e, ok := s.entries[key]
+	if !ok || !now.Before(e.expires) {
+		e = &entry{expires: now.Add(s.expiration)}
+		s.entries[key] = e
+	}
+	e.hits++
+	return e.hits, e.expires.Sub(now)
+}
+
+// sweep drops expired entries at most once per window so that
+// keys that stop sending requests do not accumulate
+func (s *store) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	for key, e := range s.entries {
+		if !now.Before(e.expires) {
+			delete(s.entries, key)
+		}
+	}
+	s.nextSweep = now.Add(s.expiration)
+}
@@ -0,0 +1,100 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jeffotoni/quick"
+)
+
+// go test -v -failfast -count=1 -run ^TestNew$
+func TestNew(t *testing.T) {
+	h := New(Config{Max: 2, Expiration: time.Minute})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(addr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = addr
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		if rec := send("10.0.0.1:1234"); rec.Code != want {
+			t.Fatalf("request %d: expected %d, got %d", i+1, want, rec.Code)
+		}
+	}
+
+	rec := send("10.0.0.1:4321")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected the same IP on another port to be limited, got %d", rec.Code)
+	}
+	if rec.Header().Get("X-RateLimit-Remaining") != "0" || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("unexpected headers: %v", rec.Header())
+	}
+
+	if rec := send("10.0.0.2:1234"); rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Remaining") != "1" {
+		t.Errorf("expected another IP to have its own counter, got %d %v", rec.Code, rec.Header())
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestPerRoute$
+func TestPerRoute(t *testing.T) {
+	q := quick.New()
+	q.Use(New(Config{Max: 100, Expiration: time.Minute}))
+
+	auth := q.Group("/auth")
+	auth.Use(New(Config{
+		Max:        5,
+		Expiration: time.Minute,
+		LimitReached: func(c *quick.Ctx) error {
+			return c.Status(http.StatusTooManyRequests).JSON(map[string]string{"error": "too many login attempts"})
+		},
+	}))
+	auth.Post("/login", func(c *quick.Ctx) error { return c.Status(http.StatusOK).SendString("ok") })
+	q.Get("/users", func(c *quick.Ctx) error { return c.Status(http.StatusOK).SendString("users") })
+
+	for i := 1; i <= 6; i++ {
+		rec := httptest.NewRecorder()
+		q.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/auth/login", nil))
+		if i <= 5 && rec.Code != http.StatusOK {
+			t.Fatalf("login %d: expected 200, got %d", i, rec.Code)
+		}
+		if i == 6 && (rec.Code != http.StatusTooManyRequests || rec.Header().Get("X-RateLimit-Limit") != "5") {
+			t.Fatalf("login %d: expected 429 from the route limiter, got %d %v", i, rec.Code, rec.Header())
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	q.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Limit") != "100" {
+		t.Errorf("expected /users to use the global limit, got %d %v", rec.Code, rec.Header())
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestStoreExpiration$
+func TestStoreExpiration(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := newStore(time.Minute)
+	s.now = func() time.Time { return now }
+
+	s.hit("a")
+	if hits, reset := s.hit("a"); hits != 2 || reset != time.Minute {
+		t.Fatalf("expected 2 hits resetting in 1m, got %d %v", hits, reset)
+	}
+
+	now = now.Add(time.Minute)
+	if hits, _ := s.hit("b"); hits != 1 {
+		t.Fatalf("expected 1 hit, got %d", hits)
+	}
+	if _, ok := s.entries["a"]; ok {
+		t.Error("expected the expired key to be swept")
+	}
+	if hits, _ := s.hit("a"); hits != 1 {
+		t.Errorf("expected a new window for the expired key, got %d hits", hits)
+	}
+}
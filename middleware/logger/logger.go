@@ -4,12 +4,28 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"time"
+
+	"github.com/jeffotoni/quick"
 )
 
+// Config defines the config for the logger middleware
+type Config struct {
+	// Logger receives one record per request. By default it is the
+	// Config.Logger of the Quick instance serving the route.
+	Logger *slog.Logger
+
+	// SkipStatus lists response statuses that are not logged, e.g. 404 to
+	// keep scanners out of the logs. The response is still served.
+	SkipStatus []int
+
+	// SkipPaths lists request paths that are not logged, e.g. "/healthz"
+	SkipPaths []string
+}
+
 type loggerRespWriter struct {
 	http.ResponseWriter
 	status int
@@ -26,13 +42,39 @@ func (w *loggerRespWriter) Write(b []byte) (int, error) {
 }
 
 func (w *loggerRespWriter) WriteHeader(status int) {
-	w.status = status
+	if status < 100 || status > 199 {
+		w.status = status
+	}
 	w.ResponseWriter.WriteHeader(status)
 }
 
-func New() func(http.Handler) http.Handler {
+// New creates the logger middleware, which logs the client address, status,
+// method, path, latency, request body size and response size of every request.
+// Inside a Quick route the response size is counted as sent, after compression.
+// Unknown paths are answered 404 before Use middlewares run, so to log or skip
+// them wrap the app instead: q.Listen(":8080", logger.New(cfg)(q)).
+// The result will New(config ...Config) func(http.Handler) http.Handler
+func New(config ...Config) func(http.Handler) http.Handler {
+	var cfg Config
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	skipStatus := make(map[int]bool, len(cfg.SkipStatus))
+	for _, status := range cfg.SkipStatus {
+		skipStatus[status] = true
+	}
+	skipPaths := make(map[string]bool, len(cfg.SkipPaths))
+	for _, path := range cfg.SkipPaths {
+		skipPaths[path] = true
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if skipPaths[req.URL.Path] {
+				next.ServeHTTP(w, req)
+				return
+			}
+
 			start := time.Now()
 			ip, port, err := net.SplitHostPort(req.RemoteAddr)
 			if err != nil {
@@ -55,9 +97,33 @@ func New() func(http.Handler) http.Handler {
 
 			lrw := &loggerRespWriter{ResponseWriter: w}
 			next.ServeHTTP(lrw, req)
+			status := lrw.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if skipStatus[status] {
+				return
+			}
 
-			elapsed := time.Since(start)
-			log.Printf("[%s]:%s %d - %s %s %v %d\n", ip, port, lrw.status, req.Method, req.URL.Path, elapsed, bodySize)
+			c := &quick.Ctx{Request: req}
+			responseSize := lrw.size
+			if size := c.ResponseSize(); size > 0 {
+				responseSize = size
+			}
+			args := []any{
+				"ip", ip,
+				"port", port,
+				"status", lrw.status,
+				"latency", time.Since(start),
+				"bytes", bodySize,
+				"response_bytes", responseSize,
+			}
+			// c.Logger() is already tagged with the method and path
+			logger := c.Logger()
+			if cfg.Logger != nil {
+				logger = cfg.Logger.With("method", req.Method, "path", req.URL.Path)
+			}
+			logger.Info("request", args...)
 		})
 	}
 }
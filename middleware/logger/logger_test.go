@@ -1,10 +1,17 @@
 package logger
 
 import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
+
+	"github.com/jeffotoni/quick"
+	"github.com/jeffotoni/quick/middleware/compress"
 )
 
 // go test -v -failfast -count=1 -run ^TestNew$
@@ -129,3 +136,91 @@ func BenchmarkNew(b *testing.B) {
 // 		New2()
 // 	}
 // }
+
+// go test -v -failfast -count=1 -run ^TestNewQuickLogger$
+func TestNewQuickLogger(t *testing.T) {
+	var buf bytes.Buffer
+	q := quick.New(quick.Config{Logger: slog.New(slog.NewJSONHandler(&buf, nil))})
+	q.Use(New())
+	q.Get("/users/:id", func(c *quick.Ctx) error {
+		return c.Status(http.StatusCreated).SendString("ok")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	q.ServeHTTP(httptest.NewRecorder(), req)
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("expected a JSON record, got %q: %v", buf.String(), err)
+	}
+	if record["msg"] != "request" || record["path"] != "/users/1" || record["status"] != float64(http.StatusCreated) {
+		t.Errorf("unexpected record: %v", record)
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNewResponseSize$
+func TestNewResponseSize(t *testing.T) {
+	var buf bytes.Buffer
+	q := quick.New(quick.Config{Logger: slog.New(slog.NewJSONHandler(&buf, nil))})
+	q.Use(New())
+	q.Use(compress.Gzip())
+	q.Get("/big", func(c *quick.Ctx) error {
+		return c.Status(http.StatusOK).SendString(strings.Repeat("quick ", 1000))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/big", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	q.ServeHTTP(rec, req)
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("expected a JSON record, got %q: %v", buf.String(), err)
+	}
+	if got := record["response_bytes"]; got != float64(rec.Body.Len()) || rec.Body.Len() >= 6000 {
+		t.Errorf("expected the compressed size %d, got %v", rec.Body.Len(), got)
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNewSkip$
+func TestNewSkip(t *testing.T) {
+	var buf bytes.Buffer
+	q := quick.New()
+	q.Get("/ok", func(c *quick.Ctx) error {
+		return c.Status(http.StatusOK).SendString("ok")
+	})
+	q.Get("/fail", func(c *quick.Ctx) error {
+		return c.Status(http.StatusInternalServerError).SendString("fail")
+	})
+	q.Get("/healthz", func(c *quick.Ctx) error {
+		return c.Status(http.StatusOK).SendString("up")
+	})
+	h := New(Config{
+		Logger:     slog.New(slog.NewJSONHandler(&buf, nil)),
+		SkipStatus: []int{http.StatusNotFound},
+		SkipPaths:  []string{"/healthz"},
+	})(q)
+
+	tests := []struct {
+		path   string
+		status int
+		logged bool
+	}{
+		{path: "/wp-admin.php", status: http.StatusNotFound, logged: false},
+		{path: "/healthz", status: http.StatusOK, logged: false},
+		{path: "/fail", status: http.StatusInternalServerError, logged: true},
+		{path: "/ok", status: http.StatusOK, logged: true},
+	}
+
+	for _, tt := range tests {
+		buf.Reset()
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.status, rec.Code)
+		}
+		if logged := strings.Contains(buf.String(), `"path":"`+tt.path+`"`); logged != tt.logged {
+			t.Errorf("%s: expected logged=%v, got %q", tt.path, tt.logged, buf.String())
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Package methodoverride provides a middleware that lets clients which can
+// only send POST (e.g. HTML forms) reach PUT, PATCH and DELETE routes.
+//
+// The effective method is read from the X-HTTP-Method-Override header or
+// from the "_method" field of an application/x-www-form-urlencoded body.
+//
+// Quick resolves the route before running middlewares registered with
+// Use, so this middleware must wrap the Quick instance itself:
+//
+//	q := quick.New()
+//	q.Delete("/users/:id", handler)
+//	q.Listen("0.0.0.0:8080", methodoverride.New()(q))
+package methodoverride
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	KeyHeader    = "X-HTTP-Method-Override"
+	KeyFormField = "_method"
+	// MaxFormSize matches the default MaxBodySize of Quick
+	MaxFormSize = 2 << 20
+)
+
+// Config defines the config for the methodoverride middleware
+type Config struct {
+	// Header is the request header holding the method. Default X-HTTP-Method-Override.
+	Header string
+	// FormField is the urlencoded form field holding the method. Default _method.
+	FormField string
+	// AllowedMethods lists the methods a POST may be overridden to.
+	// Default PUT, PATCH and DELETE.
+	AllowedMethods []string
+	// MaxFormSize caps the urlencoded body read to find FormField. The
+	// middleware runs before Quick checks MaxBodySize, so larger bodies
+	// are answered with 413 here. Default 2MB.
+	MaxFormSize int64
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Header:         KeyHeader,
+	FormField:      KeyFormField,
+	AllowedMethods: []string{http.MethodPut, http.MethodPatch, http.MethodDelete},
+	MaxFormSize:    MaxFormSize,
+}
+
+// New creates the methodoverride middleware
+// The result will New(config ...Config) func(http.Handler) http.Handler
+func New(config ...Config) func(http.Handler) http.Handler {
+	cfg := ConfigDefault
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Header == "" {
+		cfg.Header = KeyHeader
+	}
+	if cfg.FormField == "" {
+		cfg.FormField = KeyFormField
+	}
+	if len(cfg.AllowedMethods) == 0 {
+		cfg.AllowedMethods = ConfigDefault.AllowedMethods
+	}
+	if cfg.MaxFormSize <= 0 {
+		cfg.MaxFormSize = MaxFormSize
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				method := r.Header.Get(cfg.Header)
+				if method == "" {
+					var err error
+					method, err = formMethod(w, r, cfg.FormField, cfg.MaxFormSize)
+					var tooLarge *http.MaxBytesError
+					if errors.As(err, &tooLarge) {
+						http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+						return
+					}
+				}
+				method = strings.ToUpper(strings.TrimSpace(method))
+				if allowed(cfg.AllowedMethods, method) {
+					r.Method = method
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// formMethod reads the override field from an urlencoded body of at most
+// limit bytes, restoring the body so handlers can still read it. Larger
+// bodies return *http.MaxBytesError.
+func formMethod(w http.ResponseWriter, r *http.Request, field string, limit int64) (string, error) {
+	if r.Body == nil {
+		return "", nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/x-www-form-urlencoded" {
+		return "", nil
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return "", nil
+	}
+	return values.Get(field), nil
+}
+
+// allowed reports whether method is in the list
+func allowed(methods []string, method string) bool {
+	for _, m := range methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
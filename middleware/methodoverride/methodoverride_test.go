@@ -0,0 +1,100 @@
+package methodoverride
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jeffotoni/quick"
+)
+
+// go test -v -failfast -count=1 -run ^TestNew$
+func TestNew(t *testing.T) {
+	q := quick.New()
+	q.Post("/users/1", func(c *quick.Ctx) error {
+		return c.SendString("POST " + c.BodyString())
+	})
+	q.Delete("/users/1", func(c *quick.Ctx) error {
+		return c.SendString("DELETE")
+	})
+	q.Put("/users/1", func(c *quick.Ctx) error {
+		return c.SendString("PUT " + c.BodyString())
+	})
+	h := New()(q)
+
+	tests := []struct {
+		name        string
+		header      string
+		contentType string
+		body        string
+		want        string
+	}{
+		{"form field", "", "application/x-www-form-urlencoded", "_method=DELETE", "DELETE"},
+		{"header", "put", "", "data", "PUT data"},
+		{"body preserved", "", "application/x-www-form-urlencoded", "_method=PUT&name=quick", "PUT _method=PUT&name=quick"},
+		{"not allowed method", "TRACE", "", "data", "POST data"},
+		{"no override", "", "application/json", `{"_method":"DELETE"}`, `POST {"_method":"DELETE"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/users/1", strings.NewReader(tt.body))
+			if tt.header != "" {
+				req.Header.Set(KeyHeader, tt.header)
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Body.String() != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNewOnlyPost$
+func TestNewOnlyPost(t *testing.T) {
+	var got string
+	h := New()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Method
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(KeyHeader, http.MethodDelete)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got != http.MethodGet {
+		t.Errorf("expected GET to be kept, got %s", got)
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNewMaxFormSize$
+func TestNewMaxFormSize(t *testing.T) {
+	var got string
+	h := New(Config{MaxFormSize: 64})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Method
+	}))
+
+	tests := []struct {
+		body   string
+		status int
+		method string
+	}{
+		{"_method=DELETE", http.StatusOK, http.MethodDelete},
+		{"_method=DELETE&note=" + strings.Repeat("x", 64), http.StatusRequestEntityTooLarge, ""},
+	}
+	for _, tt := range tests {
+		got = ""
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tt.status || got != tt.method {
+			t.Errorf("body of %d bytes: expected %d %q, got %d %q", len(tt.body), tt.status, tt.method, rec.Code, got)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+// Package proxy provides a middleware that forwards every request under a
+// path prefix to an upstream service, streaming the response back.
+// Hop-by-hop headers are stripped and X-Forwarded-For/-Host/-Proto are set;
+// an incoming X-Forwarded-For chain is only kept from Config.TrustedProxies.
+//
+// Routing happens before Use middlewares, so it must wrap the Quick instance
+// to see paths without a route, e.g. q.Listen(":8080", proxy.New(cfg)(q)).
+// For a single route use c.Proxy instead.
+package proxy
+
+import (
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+
+	"github.com/jeffotoni/quick"
+)
+
+// Config defines the config for the proxy middleware
+type Config struct {
+	// Target is the upstream base URL, e.g. http://legacy:8080. Required.
+	Target string
+	// Prefix selects the requests to forward, e.g. /legacy. Default "/" (all).
+	Prefix string
+	// StripPrefix removes Prefix from the path sent upstream.
+	StripPrefix bool
+	// ModifyResponse, if set, can change the upstream response.
+	ModifyResponse func(*http.Response) error
+	// TrustedProxies lists the IPs and CIDR ranges, e.g. "10.0.0.0/8", of
+	// the proxies in front of this one, as in quick.Config.TrustedProxies.
+	// Their X-Forwarded-For chain is extended; from any other peer it is
+	// dropped, so clients cannot spoof it. Default none.
+	TrustedProxies []string
+}
+
+// New creates the proxy middleware. It panics if Target is not an absolute
+// URL or a TrustedProxies entry is not an IP or CIDR range.
+// The result will New(config Config) func(http.Handler) http.Handler
+func New(config Config) func(http.Handler) http.Handler {
+	target, err := url.Parse(config.Target)
+	if err != nil || target.Scheme == "" || target.Host == "" {
+		panic("proxy: invalid Target " + config.Target)
+	}
+	trusted := make([]*net.IPNet, 0, len(config.TrustedProxies))
+	for _, entry := range config.TrustedProxies {
+		ipNet, ok := parseNet(strings.TrimSpace(entry))
+		if !ok {
+			panic("proxy: invalid TrustedProxies entry " + entry)
+		}
+		trusted = append(trusted, ipNet)
+	}
+	prefix := "/" + strings.Trim(config.Prefix, "/")
+
+	rp := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			if config.StripPrefix && prefix != "/" {
+				pr.Out.URL.Path = "/" + strings.TrimLeft(strings.TrimPrefix(pr.Out.URL.Path, prefix), "/")
+				pr.Out.URL.RawPath = ""
+			}
+			pr.SetURL(target)
+			// keep the chain of the trusted proxies in front of us
+			if trustedPeer(trusted, pr.In.RemoteAddr) {
+				pr.Out.Header["X-Forwarded-For"] = pr.In.Header["X-Forwarded-For"]
+			}
+			pr.SetXForwarded()
+		},
+		FlushInterval:  -1,
+		ModifyResponse: config.ModifyResponse,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			// the request logger follows quick.Config.Logger inside a route,
+			// slog.Default() when the middleware wraps the Quick instance
+			c := &quick.Ctx{Response: w, Request: r}
+			c.Logger().Error("proxy error", "target", target.String(), "error", err)
+			http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+		},
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !matchPrefix(r.URL.Path, prefix) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			rp.ServeHTTP(w, r)
+		})
+	}
+}
+
+// matchPrefix reports whether path is prefix or below it, by whole segments
+func matchPrefix(path, prefix string) bool {
+	if prefix == "/" {
+		return true
+	}
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// parseNet parses an IP or CIDR range; a single IP becomes a /32 or /128 network
+func parseNet(entry string) (*net.IPNet, bool) {
+	if strings.Contains(entry, "/") {
+		_, ipNet, err := net.ParseCIDR(entry)
+		return ipNet, err == nil
+	}
+	ip := net.ParseIP(entry)
+	if ip == nil {
+		return nil, false
+	}
+	bits := 128
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, 32
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, true
+}
+
+// trustedPeer reports whether the peer at remoteAddr belongs to one of the
+// trusted networks
+func trustedPeer(nets []*net.IPNet, remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package proxy
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jeffotoni/quick"
+)
+
+// upstream echoes what it received
+func upstream(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Path", r.URL.RequestURI())
+		w.Header().Set("X-Got-Forwarded-For", r.Header.Get("X-Forwarded-For"))
+		w.Header().Set("X-Got-Connection-Token", r.Header.Get("X-Hop"))
+		w.WriteHeader(http.StatusAccepted)
+		w.Write(body)
+	}))
+}
+
+// go test -v -failfast -count=1 -run ^TestNew$
+func TestNew(t *testing.T) {
+	up := upstream(t)
+	defer up.Close()
+
+	q := quick.New()
+	q.Get("/local", func(c *quick.Ctx) error {
+		return c.Status(http.StatusOK).SendString("local")
+	})
+
+	tests := []struct {
+		name     string
+		config   Config
+		path     string
+		wantCode int
+		wantPath string
+		wantFor  string
+	}{
+		{"forwards prefix", Config{Target: up.URL, Prefix: "/legacy", TrustedProxies: []string{"192.0.2.0/24"}}, "/legacy/users?id=1", 202, "/legacy/users?id=1", "10.0.0.1, 192.0.2.1"},
+		{"strips prefix", Config{Target: up.URL, Prefix: "/legacy/", StripPrefix: true, TrustedProxies: []string{"192.0.2.1"}}, "/legacy/users?id=1", 202, "/users?id=1", "10.0.0.1, 192.0.2.1"},
+		{"untrusted peer starts the chain", Config{Target: up.URL, Prefix: "/legacy"}, "/legacy/users", 202, "/legacy/users", "192.0.2.1"},
+		{"other paths reach quick", Config{Target: up.URL, Prefix: "/legacy"}, "/local", 200, "", ""},
+		{"prefix matches whole segments", Config{Target: up.URL, Prefix: "/legacy"}, "/legacyx", 404, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("X-Forwarded-For", "10.0.0.1")
+			req.Header.Set("Connection", "X-Hop")
+			req.Header.Set("X-Hop", "secret")
+			rec := httptest.NewRecorder()
+			New(tt.config)(q).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d", tt.wantCode, rec.Code)
+			}
+			if rec.Header().Get("X-Path") != tt.wantPath {
+				t.Errorf("expected upstream path %q, got %q", tt.wantPath, rec.Header().Get("X-Path"))
+			}
+			if tt.wantCode != 202 {
+				return
+			}
+			if got := rec.Header().Get("X-Got-Forwarded-For"); got != tt.wantFor {
+				t.Errorf("expected X-Forwarded-For %q, got %q", tt.wantFor, got)
+			}
+			if got := rec.Header().Get("X-Got-Connection-Token"); got != "" {
+				t.Errorf("hop-by-hop header should be stripped, got %q", got)
+			}
+		})
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestBadGateway$
+func TestBadGateway(t *testing.T) {
+	up := upstream(t)
+	up.Close()
+
+	var logs bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	defer slog.SetDefault(prev)
+
+	rec := httptest.NewRecorder()
+	New(Config{Target: up.URL})(http.NotFoundHandler()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("expected 502, got %d", rec.Code)
+	}
+	if !strings.Contains(logs.String(), `msg="proxy error"`) || !strings.Contains(logs.String(), "target="+up.URL) {
+		t.Errorf("expected the error in the request logger, got %q", logs.String())
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestInvalidTarget$
+func TestInvalidTarget(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for invalid target")
+		}
+	}()
+	New(Config{Target: "legacy:8080/path"})
+}
+
+// go test -v -failfast -count=1 -run ^TestInvalidTrustedProxies$
+func TestInvalidTrustedProxies(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for invalid trusted proxy")
+		}
+	}()
+	New(Config{Target: "http://legacy:8080", TrustedProxies: []string{"10.0.0.0/33"}})
+}
@@ -0,0 +1,104 @@
+// Package queue provides a middleware that bounds how many requests run at
+// once, to shield a slow backend. Requests over MaxConcurrent wait in a
+// queue of at most MaxQueue requests; when the queue is full the request is
+// rejected right away with 503 Service Unavailable.
+//
+//	reports := q.Group("/reports")
+//	reports.Use(queue.New(queue.Config{MaxConcurrent: 4, MaxQueue: 20}))
+//
+// Every call to New owns its own slots and queue.
+package queue
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/jeffotoni/quick"
+)
+
+// Defaults used when the Config fields are not set
+const (
+	defaultMaxConcurrent = 100
+)
+
+// Config defines the config for the queue middleware
+type Config struct {
+	// MaxConcurrent is the number of requests running at once. Default 100.
+	MaxConcurrent int
+	// MaxQueue is the number of requests waiting for a slot. Zero rejects
+	// every request that cannot run immediately.
+	MaxQueue int
+	// QueueTimeout, when set, rejects requests that waited longer for a slot.
+	// Requests also leave the queue when the client goes away.
+	QueueTimeout time.Duration
+	// Overflow writes the response sent when a request is rejected.
+	// Default is 503 with the body "Service Unavailable".
+	Overflow func(c *quick.Ctx) error
+}
+
+// defaultOverflow answers 503 Service Unavailable
+func defaultOverflow(c *quick.Ctx) error {
+	c.Set("Content-Type", "text/plain; charset=utf-8")
+	return c.Status(http.StatusServiceUnavailable).SendString("Service Unavailable")
+}
+
+// New creates the queue middleware
+// The result will New(config ...Config) func(http.Handler) http.Handler
+func New(config ...Config) func(http.Handler) http.Handler {
+	var cfg Config
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = defaultMaxConcurrent
+	}
+	if cfg.MaxQueue < 0 {
+		cfg.MaxQueue = 0
+	}
+	if cfg.Overflow == nil {
+		cfg.Overflow = defaultOverflow
+	}
+
+	// admitted holds a token for every running or waiting request,
+	// running one for every running request
+	admitted := make(chan struct{}, cfg.MaxConcurrent+cfg.MaxQueue)
+	running := make(chan struct{}, cfg.MaxConcurrent)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c := &quick.Ctx{Response: w, Request: r}
+			overflow := func() {
+				if err := cfg.Overflow(c); err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+				}
+			}
+
+			select {
+			case admitted <- struct{}{}:
+			default:
+				overflow()
+				return
+			}
+			defer func() { <-admitted }()
+
+			var timeout <-chan time.Time
+			if cfg.QueueTimeout > 0 {
+				timer := time.NewTimer(cfg.QueueTimeout)
+				defer timer.Stop()
+				timeout = timer.C
+			}
+
+			select {
+			case running <- struct{}{}:
+			case <-timeout:
+				overflow()
+				return
+			case <-r.Context().Done():
+				return
+			}
+			defer func() { <-running }()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
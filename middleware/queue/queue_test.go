@@ -0,0 +1,80 @@
+package queue
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jeffotoni/quick"
+)
+
+// go test -v -failfast -count=1 -run ^TestNew$
+func TestNew(t *testing.T) {
+	release := make(chan struct{})
+	var running, peak int32
+	q := quick.New()
+	q.Use(New(Config{MaxConcurrent: 1, MaxQueue: 1}))
+	q.Get("/slow", func(c *quick.Ctx) error {
+		n := atomic.AddInt32(&running, 1)
+		if n > atomic.LoadInt32(&peak) {
+			atomic.StoreInt32(&peak, n)
+		}
+		<-release
+		atomic.AddInt32(&running, -1)
+		return c.Status(http.StatusOK).SendString("ok")
+	})
+
+	serve := func() chan int {
+		done := make(chan int, 1)
+		go func() {
+			rec := httptest.NewRecorder()
+			q.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slow", nil))
+			done <- rec.Code
+		}()
+		return done
+	}
+
+	first := serve()
+	time.Sleep(50 * time.Millisecond)
+	queued := serve()
+	time.Sleep(50 * time.Millisecond)
+
+	select {
+	case code := <-serve():
+		if code != http.StatusServiceUnavailable {
+			t.Errorf("Expected 503 when the queue is full, got %d", code)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the overflow request to be rejected without waiting")
+	}
+
+	close(release)
+	for name, done := range map[string]chan int{"first": first, "queued": queued} {
+		if code := <-done; code != http.StatusOK {
+			t.Errorf("Expected the %s request to succeed, got %d", name, code)
+		}
+	}
+	if atomic.LoadInt32(&peak) != 1 {
+		t.Errorf("Expected at most 1 request running, got %d", peak)
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNewQueueTimeout$
+func TestNewQueueTimeout(t *testing.T) {
+	release := make(chan struct{})
+	h := New(Config{MaxConcurrent: 1, MaxQueue: 5, QueueTimeout: 20 * time.Millisecond})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+
+	go h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	time.Sleep(20 * time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	close(release)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 after waiting QueueTimeout, got %d", rec.Code)
+	}
+}
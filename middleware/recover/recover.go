@@ -0,0 +1,79 @@
+// Package recover provides a middleware that turns panics raised by handlers
+// into a 500 Internal Server Error instead of dropping the connection.
+// Each panic is handed to Config.Reporter together with the request context
+// and the stack trace, so it can be forwarded to a monitoring service, and
+// the response is written by the quick.Config.ErrorHandler of the app.
+//
+// It can be applied globally with q.Use or per route through a Group.
+package recover
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/jeffotoni/quick"
+)
+
+// Config defines the config for the recover middleware
+type Config struct {
+	// Reporter receives every recovered panic with the request context
+	// (method, path and params through c), the panic value and the stack.
+	// Default logs the panic and the stack with c.Logger(), which follows
+	// the quick.Config.Logger of the app.
+	Reporter func(c *quick.Ctx, recovered interface{}, stack []byte)
+	// OnPanic writes the response sent after a panic. By default the panic
+	// is passed as a *quick.PanicError to the quick.Config.ErrorHandler of
+	// the app, which answers 500 "Internal Server Error" when none is set.
+	OnPanic func(c *quick.Ctx) error
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Reporter: defaultReporter,
+}
+
+// defaultReporter logs the panic through the request logger, which adds
+// the method and path
+func defaultReporter(c *quick.Ctx, recovered interface{}, stack []byte) {
+	c.Logger().Error("panic recovered", "panic", fmt.Sprint(recovered), "stack", string(stack))
+}
+
+// New creates the recover middleware
+// The result will New(config ...Config) func(http.Handler) http.Handler
+func New(config ...Config) func(http.Handler) http.Handler {
+	cfg := ConfigDefault
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Reporter == nil {
+		cfg.Reporter = defaultReporter
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				p := recover()
+				if p == nil {
+					return
+				}
+				// http.ErrAbortHandler is the way to abort a response on purpose
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+
+				c := &quick.Ctx{Response: w, Request: r}
+				stack := debug.Stack()
+				cfg.Reporter(c, p, stack)
+				if cfg.OnPanic != nil {
+					// #nosec G104
+					cfg.OnPanic(c)
+					return
+				}
+				// #nosec G104
+				c.HandleError(&quick.PanicError{Value: p, Stack: stack})
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
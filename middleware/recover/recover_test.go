@@ -0,0 +1,152 @@
+package recover
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jeffotoni/quick"
+)
+
+// go test -v -failfast -count=1 -run ^TestNew$
+func TestNew(t *testing.T) {
+	type report struct {
+		method, path, id string
+		recovered        interface{}
+		stack            string
+	}
+	var got *report
+
+	q := quick.New()
+	q.Use(New(Config{
+		Reporter: func(c *quick.Ctx, recovered interface{}, stack []byte) {
+			got = &report{c.Request.Method, c.Request.URL.Path, c.Param("id"), recovered, string(stack)}
+		},
+	}))
+	q.Get("/users/:id", func(c *quick.Ctx) error {
+		if c.Param("id") == "0" {
+			panic("boom")
+		}
+		return c.Status(http.StatusOK).SendString("ok")
+	})
+
+	t.Run("no panic", func(t *testing.T) {
+		got = nil
+		rec := httptest.NewRecorder()
+		q.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+		if rec.Code != http.StatusOK || got != nil {
+			t.Errorf("expected 200 without report, got %d %+v", rec.Code, got)
+		}
+	})
+
+	t.Run("panic is reported with request context", func(t *testing.T) {
+		got = nil
+		rec := httptest.NewRecorder()
+		q.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/0", nil))
+
+		if rec.Code != http.StatusInternalServerError || rec.Body.String() != "Internal Server Error" {
+			t.Errorf("expected 500, got %d %q", rec.Code, rec.Body.String())
+		}
+		if got == nil {
+			t.Fatal("expected reporter to be called")
+		}
+		if got.method != http.MethodGet || got.path != "/users/0" || got.id != "0" || got.recovered != "boom" {
+			t.Errorf("unexpected report: %+v", got)
+		}
+		if !strings.Contains(got.stack, "recover_test.go") {
+			t.Errorf("expected stack to point at the panicking handler, got:\n%s", got.stack)
+		}
+	})
+}
+
+// go test -v -failfast -count=1 -run ^TestOnPanic$
+func TestOnPanic(t *testing.T) {
+	h := New(Config{
+		Reporter: func(c *quick.Ctx, recovered interface{}, stack []byte) {},
+		OnPanic: func(c *quick.Ctx) error {
+			return c.Status(http.StatusServiceUnavailable).JSON(map[string]string{"error": "unavailable"})
+		},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Body.String() != `{"error":"unavailable"}` {
+		t.Errorf("expected custom response, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestAbortHandler$
+func TestAbortHandler(t *testing.T) {
+	h := New()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler {
+			t.Errorf("expected http.ErrAbortHandler to be re-raised, got %v", p)
+		}
+	}()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+// go test -v -failfast -count=1 -run ^TestErrorHandler$
+func TestErrorHandler(t *testing.T) {
+	q := quick.New(quick.Config{
+		ErrorHandler: func(c *quick.Ctx, err error) error {
+			status := http.StatusBadRequest
+			var pe *quick.PanicError
+			if errors.As(err, &pe) {
+				status = http.StatusInternalServerError
+				err = errors.New("internal error")
+			}
+			return c.Status(status).JSON(map[string]string{"error": err.Error()})
+		},
+	})
+	q.Use(New(Config{Reporter: func(c *quick.Ctx, recovered interface{}, stack []byte) {}}))
+	q.Get("/panic", func(c *quick.Ctx) error {
+		panic("boom")
+	})
+	q.Get("/error", func(c *quick.Ctx) error {
+		return errors.New("invalid id")
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{path: "/panic", status: http.StatusInternalServerError, body: `{"error":"internal error"}`},
+		{path: "/error", status: http.StatusBadRequest, body: `{"error":"invalid id"}`},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		q.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.status || rec.Body.String() != tt.body {
+			t.Errorf("%s: expected %d %s, got %d %q", tt.path, tt.status, tt.body, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestDefaultReporter$
+func TestDefaultReporter(t *testing.T) {
+	var logs bytes.Buffer
+	q := quick.New(quick.Config{Logger: slog.New(slog.NewTextHandler(&logs, nil))})
+	q.Use(New())
+	q.Get("/users/:id", func(c *quick.Ctx) error {
+		panic("boom")
+	})
+
+	q.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/0", nil))
+	out := logs.String()
+	for _, want := range []string{"level=ERROR", `msg="panic recovered"`, "panic=boom", "path=/users/0", "recover_test.go"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in the configured logger, got %q", want, out)
+		}
+	}
+}
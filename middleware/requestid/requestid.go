@@ -0,0 +1,90 @@
+package requestid
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/jeffotoni/quick"
+	"github.com/jeffotoni/quick/internal/uuid"
+)
+
+// HeaderName is the default header carrying the request ID
+const HeaderName = "X-Request-ID"
+
+// maxLen bounds the length of an incoming ID that is reused as is
+const maxLen = 128
+
+type ctxKey struct{}
+
+// Config defines the config for the requestid middleware
+type Config struct {
+	// Header read from the request and set on the response.
+	// Default: X-Request-ID
+	Header string
+
+	// Generator creates the ID when the request has none.
+	// Default: a random UUID v4
+	Generator func() string
+}
+
+// New creates the requestid middleware. It reuses the ID sent by the client,
+// or generates one, sets it on the request and response headers and stores
+// it in the request context, where FromContext reads it, e.g. so that
+// client.FromContext(c) forwards it to downstream services. Lines logged
+// through c.Logger() carry it as request_id.
+// The result will New(config ...Config) func(http.Handler) http.Handler
+func New(config ...Config) func(http.Handler) http.Handler {
+	var cfg Config
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Header == "" {
+		cfg.Header = HeaderName
+	}
+	if cfg.Generator == nil {
+		cfg.Generator = uuid.NewString
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(cfg.Header)
+			if !valid(id) {
+				id = cfg.Generator()
+			}
+			r.Header.Set(cfg.Header, id)
+			w.Header().Set(cfg.Header, id)
+			ctx := quick.WithLogAttrs(NewContext(r.Context(), id), slog.String("request_id", id))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// NewContext returns a copy of ctx carrying the request ID
+// The result will NewContext(ctx context.Context, id string) context.Context
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the request ID stored by New, or "" when there is none
+// The result will FromContext(ctx context.Context) string
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// valid reports whether an incoming ID can be reused: non-empty, bounded
+// and made of printable ASCII, so it is safe to echo and to log.
+// Method Used Internally
+// The result will valid(id string) bool
+func valid(id string) bool {
+	if id == "" || len(id) > maxLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,99 @@
+package requestid
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jeffotoni/quick"
+)
+
+// go test -v -failfast -count=1 -run ^TestNew$
+func TestNew(t *testing.T) {
+	q := quick.New()
+	q.Use(New())
+	q.Get("/", func(c *quick.Ctx) error {
+		return c.String(FromContext(c.Context()))
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		reuse  bool
+	}{
+		{name: "generated", header: "", reuse: false},
+		{name: "reused", header: "abc-123", reuse: true},
+		{name: "too long", header: strings.Repeat("a", maxLen+1), reuse: false},
+		{name: "not printable", header: "abc 123", reuse: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(HeaderName, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			q.ServeHTTP(rec, req)
+
+			id := rec.Header().Get(HeaderName)
+			if id == "" {
+				t.Fatal("Expected a request ID on the response")
+			}
+			if rec.Body.String() != id {
+				t.Errorf("Expected context ID %q, got %q", id, rec.Body.String())
+			}
+			if tt.reuse != (id == tt.header) {
+				t.Errorf("Expected reuse=%v, got ID %q for header %q", tt.reuse, id, tt.header)
+			}
+		})
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNewConfig$
+func TestNewConfig(t *testing.T) {
+	h := New(Config{
+		Header:    "X-Trace-ID",
+		Generator: func() string { return "fixed" },
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := FromContext(r.Context()); got != "fixed" {
+			t.Errorf("Expected context ID fixed, got %q", got)
+		}
+		if got := r.Header.Get("X-Trace-ID"); got != "fixed" {
+			t.Errorf("Expected request header fixed, got %q", got)
+		}
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got := rec.Header().Get("X-Trace-ID"); got != "fixed" {
+		t.Errorf("Expected response header fixed, got %q", got)
+	}
+	if got := FromContext(httptest.NewRequest(http.MethodGet, "/", nil).Context()); got != "" {
+		t.Errorf("Expected empty ID without the middleware, got %q", got)
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNewLogger$
+func TestNewLogger(t *testing.T) {
+	var buf bytes.Buffer
+	q := quick.New(quick.Config{Logger: slog.New(slog.NewJSONHandler(&buf, nil))})
+	q.Use(New())
+	q.Post("/users/:id", func(c *quick.Ctx) error {
+		c.Logger().Info("created user")
+		return c.Status(http.StatusCreated).SendString("ok")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/users/7", nil)
+	req.Header.Set(HeaderName, "req-123")
+	q.ServeHTTP(httptest.NewRecorder(), req)
+
+	for _, want := range []string{`"msg":"created user"`, `"method":"POST"`, `"path":"/users/7"`, `"route":"/users/:id"`, `"request_id":"req-123"`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected %s in the log line, got %s", want, buf.String())
+		}
+	}
+}
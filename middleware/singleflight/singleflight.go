@@ -0,0 +1,153 @@
+// Package singleflight provides a middleware that coalesces concurrent
+// identical GET requests: while a request for a given key is in flight,
+// other requests with the same key wait for it and receive a copy of
+// its response instead of running the handler again.
+//
+// This protects slow backends from cache stampedes. Requests are grouped
+// per user by default: the key includes the Authorization and Cookie
+// headers, so a custom KeyGenerator that drops them shares one user's
+// response with others and is only safe for public routes.
+package singleflight
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Config defines the config for the singleflight middleware
+type Config struct {
+	// KeyGenerator builds the key used to group identical requests.
+	// Default is the method, the request URI (path and query) and the
+	// Authorization and Cookie headers, so users never share a response.
+	KeyGenerator func(r *http.Request) string
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	KeyGenerator: defaultKey,
+}
+
+// defaultKey groups requests by method and request URI, and by the
+// credentials they carry
+func defaultKey(r *http.Request) string {
+	return r.Method + " " + r.URL.RequestURI() +
+		"\x00" + strings.Join(r.Header.Values("Authorization"), "\x00") +
+		"\x00" + strings.Join(r.Header.Values("Cookie"), "\x00")
+}
+
+// call is an in-flight or completed request shared by its followers
+type call struct {
+	wg     sync.WaitGroup
+	failed bool // the handler panicked, followers get 500
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+// group tracks the in-flight calls by key
+type group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// recorder captures the response written by the handler
+type recorder struct {
+	c *call
+}
+
+func (r *recorder) Header() http.Header {
+	return r.c.header
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	if r.c.status == 0 {
+		r.c.status = http.StatusOK
+	}
+	return r.c.body.Write(b)
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.c.status == 0 {
+		r.c.status = status
+	}
+}
+
+// New creates the singleflight middleware.
+// Only GET and HEAD requests are coalesced; other methods pass through.
+// The result will New(config ...Config) func(http.Handler) http.Handler
+func New(config ...Config) func(http.Handler) http.Handler {
+	cfg := ConfigDefault
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.KeyGenerator == nil {
+		cfg.KeyGenerator = defaultKey
+	}
+
+	g := &group{calls: make(map[string]*call)}
+
+	return func(next http.Handler) http.Handler {
+		return g.handler(cfg, next)
+	}
+}
+
+// handler runs next once per key and replays the result to every waiter
+func (g *group) handler(cfg Config, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := cfg.KeyGenerator(r)
+
+		g.mu.Lock()
+		if c, ok := g.calls[key]; ok {
+			g.mu.Unlock()
+			c.wg.Wait()
+			c.writeTo(w)
+			return
+		}
+		c := &call{header: make(http.Header)}
+		c.wg.Add(1)
+		g.calls[key] = c
+		g.mu.Unlock()
+
+		func() {
+			// release followers even if the handler panics; the panic goes
+			// on to the leader's recover middleware
+			completed := false
+			defer func() {
+				c.failed = !completed
+				g.mu.Lock()
+				delete(g.calls, key)
+				g.mu.Unlock()
+				c.wg.Done()
+			}()
+			next.ServeHTTP(&recorder{c: c}, r)
+			completed = true
+		}()
+
+		c.writeTo(w)
+	})
+}
+
+// writeTo copies the shared response to w, or answers 500 when the
+// handler failed before completing it
+func (c *call) writeTo(w http.ResponseWriter) {
+	if c.failed {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	for k, v := range c.header {
+		w.Header()[k] = append([]string(nil), v...)
+	}
+	status := c.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(c.body.Bytes())
+}
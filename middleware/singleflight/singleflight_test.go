@@ -0,0 +1,158 @@
+package singleflight
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// go test -v -failfast -count=1 -run ^TestNew$
+func TestNew(t *testing.T) {
+	const total = 100
+
+	var runs int32
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&runs, 1)
+		<-release
+		w.Header().Set("X-Shared", "yes")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("payload"))
+	})
+
+	var arrived int32
+	cfg := Config{KeyGenerator: func(r *http.Request) string {
+		atomic.AddInt32(&arrived, 1)
+		return defaultKey(r)
+	}}
+	g := &group{calls: make(map[string]*call)}
+	h := g.handler(cfg, handler)
+
+	var wg sync.WaitGroup
+	recs := make([]*httptest.ResponseRecorder, total)
+	for i := 0; i < total; i++ {
+		recs[i] = httptest.NewRecorder()
+		wg.Add(1)
+		go func(rec *httptest.ResponseRecorder) {
+			defer wg.Done()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/items", nil))
+		}(recs[i])
+	}
+
+	// wait until every request has its key, then give the followers
+	// time to park on the leader's call
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&arrived) < total {
+		if time.Now().After(deadline) {
+			t.Fatalf("requests did not arrive, got %d", atomic.LoadInt32(&arrived))
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&runs); n != 1 {
+		t.Fatalf("expected handler to run once, ran %d times", n)
+	}
+	for i, rec := range recs {
+		if rec.Code != http.StatusCreated || rec.Body.String() != "payload" || rec.Header().Get("X-Shared") != "yes" {
+			t.Fatalf("response %d not shared: %d %q %v", i, rec.Code, rec.Body.String(), rec.Header())
+		}
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNewPassThrough$
+func TestNewPassThrough(t *testing.T) {
+	var runs int32
+	h := New()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&runs, 1)
+	}))
+
+	for i := 0; i < 3; i++ {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/items", nil))
+	}
+	if runs != 3 {
+		t.Errorf("expected POST requests to pass through, ran %d times", runs)
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNewCustomKey$
+func TestNewCustomKey(t *testing.T) {
+	h := New(Config{KeyGenerator: func(r *http.Request) string { return r.URL.Path }})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(r.URL.RawQuery))
+		}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/items?page=2", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "page=2" {
+		t.Errorf("unexpected response: %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNewLeaderPanics$
+func TestNewLeaderPanics(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	g := &group{calls: make(map[string]*call)}
+	h := g.handler(ConfigDefault, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		panic("backend down")
+	}))
+
+	leader := make(chan interface{})
+	go func() {
+		defer func() { leader <- recover() }()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items", nil))
+	}()
+	<-started
+
+	follower := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.ServeHTTP(follower, httptest.NewRequest(http.MethodGet, "/items", nil))
+	}()
+	// give the follower time to park on the leader's call
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	if p := <-leader; p != "backend down" {
+		t.Errorf("expected the panic to reach the leader, got %v", p)
+	}
+	<-done
+	if follower.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500 for the follower, got %d %q", follower.Code, follower.Body.String())
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNewPerUser$
+func TestNewPerUser(t *testing.T) {
+	get := func(header, value string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		return req
+	}
+	tests := []struct {
+		a, b  *http.Request
+		share bool
+	}{
+		{get("", ""), get("", ""), true},
+		{get("Authorization", "Bearer alice"), get("Authorization", "Bearer alice"), true},
+		{get("Authorization", "Bearer alice"), get("Authorization", "Bearer bob"), false},
+		{get("Cookie", "session=alice"), get("Cookie", "session=bob"), false},
+		{get("Authorization", "Bearer alice"), get("", ""), false},
+	}
+	for i, tt := range tests {
+		if share := defaultKey(tt.a) == defaultKey(tt.b); share != tt.share {
+			t.Errorf("case %d: expected share %v, got %v", i, tt.share, share)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+// Package skip wraps another middleware so it is bypassed for the requests
+// a predicate selects, e.g. to keep health checks out of the request log:
+//
+//	q.Use(skip.New(logger.New(), skip.Paths("/healthz")))
+//
+// The wrapped middleware still runs for every other request.
+package skip
+
+import (
+	"net/http"
+
+	"github.com/jeffotoni/quick"
+)
+
+// New returns mw wrapped so that requests for which predicate returns true
+// go straight to the next handler
+// The result will New(mw func(http.Handler) http.Handler, predicate func(c *quick.Ctx) bool) func(http.Handler) http.Handler
+func New(mw func(http.Handler) http.Handler, predicate func(c *quick.Ctx) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		wrapped := mw(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if predicate(&quick.Ctx{Response: w, Request: r}) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			wrapped.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Paths returns a predicate matching requests for any of the given paths
+// The result will Paths(paths ...string) func(c *quick.Ctx) bool
+func Paths(paths ...string) func(c *quick.Ctx) bool {
+	set := make(map[string]struct{}, len(paths))
+	for _, p := range paths {
+		set[p] = struct{}{}
+	}
+	return func(c *quick.Ctx) bool {
+		_, ok := set[c.Path()]
+		return ok
+	}
+}
+
+// Methods returns a predicate matching requests with any of the given methods
+// The result will Methods(methods ...string) func(c *quick.Ctx) bool
+func Methods(methods ...string) func(c *quick.Ctx) bool {
+	return func(c *quick.Ctx) bool {
+		for _, m := range methods {
+			if c.Method() == m {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,69 @@
+package skip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jeffotoni/quick"
+)
+
+// counter is a middleware that counts the requests it sees
+func counter(n *int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*n++
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNew$
+func TestNew(t *testing.T) {
+	var seen int
+	q := quick.New()
+	q.Use(New(counter(&seen), Paths("/healthz")))
+	q.Get("/healthz", func(c *quick.Ctx) error { return c.Status(http.StatusOK).SendString("ok") })
+	q.Get("/users", func(c *quick.Ctx) error { return c.Status(http.StatusOK).SendString("users") })
+
+	for _, path := range []string{"/healthz", "/users", "/healthz", "/users"} {
+		rec := httptest.NewRecorder()
+		q.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200 for %s, got %d", path, rec.Code)
+		}
+	}
+	if seen != 2 {
+		t.Errorf("expected the middleware to run only for /users, ran %d times", seen)
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestMethods$
+func TestMethods(t *testing.T) {
+	var seen int
+	h := New(counter(&seen), Methods(http.MethodOptions, http.MethodHead))(http.NotFoundHandler())
+
+	for _, m := range []string{http.MethodOptions, http.MethodHead, http.MethodGet} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(m, "/", nil))
+	}
+	if seen != 1 {
+		t.Errorf("expected the middleware to run only for GET, ran %d times", seen)
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestRouteAwarePredicate$
+func TestRouteAwarePredicate(t *testing.T) {
+	var seen int
+	q := quick.New()
+	q.Use(New(counter(&seen), func(c *quick.Ctx) bool {
+		return c.RoutePattern() == "/internal/:name"
+	}))
+	q.Get("/internal/:name", func(c *quick.Ctx) error { return nil })
+	q.Get("/public/:name", func(c *quick.Ctx) error { return nil })
+
+	q.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/internal/metrics", nil))
+	q.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/public/docs", nil))
+	if seen != 1 {
+		t.Errorf("expected the middleware to be skipped for the internal route, ran %d times", seen)
+	}
+}
@@ -0,0 +1,150 @@
+// Package timeout provides a middleware that limits how long a handler may
+// run. The request context carries the deadline, so handlers can observe
+// the cancellation, and when the deadline fires the response is replaced
+// by the one written by Config.OnTimeout (503 Service Unavailable by default).
+//
+// It can be applied globally with q.Use or per route through a Group.
+package timeout
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jeffotoni/quick"
+)
+
+// defaultTimeout is used when Config.Timeout is not set
+const defaultTimeout = 10 * time.Second
+
+// Config defines the config for the timeout middleware
+type Config struct {
+	// Timeout is the maximum duration of the handler. Default 10s.
+	Timeout time.Duration
+	// OnTimeout writes the response sent when the deadline fires. It is
+	// not called when the client cancels the request first. Default is 503 with the body "Service Unavailable".
+	OnTimeout func(c *quick.Ctx) error
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Timeout:   defaultTimeout,
+	OnTimeout: defaultOnTimeout,
+}
+
+// defaultOnTimeout answers 503 Service Unavailable
+func defaultOnTimeout(c *quick.Ctx) error {
+	c.Set("Content-Type", "text/plain; charset=utf-8")
+	return c.Status(http.StatusServiceUnavailable).SendString("Service Unavailable")
+}
+
+// New creates the timeout middleware
+// The result will New(config ...Config) func(http.Handler) http.Handler
+func New(config ...Config) func(http.Handler) http.Handler {
+	cfg := ConfigDefault
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.OnTimeout == nil {
+		cfg.OnTimeout = defaultOnTimeout
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), cfg.Timeout)
+			defer cancel()
+			r = r.WithContext(ctx)
+
+			tw := &timeoutWriter{header: make(http.Header)}
+			done := make(chan struct{})
+			panicChan := make(chan any, 1)
+
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicChan <- p
+					}
+				}()
+				next.ServeHTTP(tw, r)
+				close(done)
+			}()
+
+			select {
+			case p := <-panicChan:
+				panic(p)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.writeTo(w)
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.timedOut = true
+				// a request cancelled by the client did not time out and
+				// there is nobody left to answer
+				if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					return
+				}
+				c := &quick.Ctx{Response: w, Request: r}
+				if err := cfg.OnTimeout(c); err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+				}
+			}
+		})
+	}
+}
+
+// timeoutWriter buffers the handler response until it is known whether
+// the handler finished before the deadline
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	body     bytes.Buffer
+	status   int
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	return tw.body.Write(b)
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	// informational responses, e.g. 103 Early Hints, cannot be buffered
+	if tw.timedOut || tw.status != 0 || (status >= 100 && status <= 199) {
+		return
+	}
+	tw.status = status
+}
+
+// writeTo copies the buffered response to w
+func (tw *timeoutWriter) writeTo(w http.ResponseWriter) {
+	dst := w.Header()
+	for k, v := range tw.header {
+		dst[k] = v
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	w.WriteHeader(tw.status)
+	_, _ = w.Write(tw.body.Bytes())
+}
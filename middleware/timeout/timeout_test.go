@@ -0,0 +1,150 @@
+package timeout
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jeffotoni/quick"
+)
+
+// go test -v -failfast -count=1 -run ^TestNew$
+func TestNew(t *testing.T) {
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+			w.Write([]byte("too late"))
+		}
+	})
+	fast := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Errorf("expected request context to carry a deadline")
+		}
+		w.Header().Set("X-Fast", "1")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("done"))
+	})
+
+	tests := []struct {
+		name       string
+		config     []Config
+		handler    http.Handler
+		wantCode   int
+		wantBody   string
+		wantHeader string
+	}{
+		{"finishes in time", []Config{{Timeout: time.Second}}, fast, 201, "done", "1"},
+		{"default response", []Config{{Timeout: 20 * time.Millisecond}}, slow, 503, "Service Unavailable", ""},
+		{"custom response", []Config{{
+			Timeout: 20 * time.Millisecond,
+			OnTimeout: func(c *quick.Ctx) error {
+				return c.Status(http.StatusGatewayTimeout).JSON(map[string]string{"error": "timeout"})
+			},
+		}}, slow, 504, `{"error":"timeout"}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			New(tt.config...)(tt.handler).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if rec.Code != tt.wantCode || rec.Body.String() != tt.wantBody {
+				t.Errorf("expected %d %q, got %d %q", tt.wantCode, tt.wantBody, rec.Code, rec.Body.String())
+			}
+			if rec.Header().Get("X-Fast") != tt.wantHeader {
+				t.Errorf("expected X-Fast %q, got %q", tt.wantHeader, rec.Header().Get("X-Fast"))
+			}
+		})
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNewPerRoute$
+func TestNewPerRoute(t *testing.T) {
+	q := quick.New()
+	q.Get("/fast", func(c *quick.Ctx) error {
+		time.Sleep(30 * time.Millisecond)
+		return c.SendString("ok")
+	})
+	g := q.Group("/strict")
+	g.Use(New(Config{Timeout: 10 * time.Millisecond}))
+	g.Get("/slow", func(c *quick.Ctx) error {
+		time.Sleep(30 * time.Millisecond)
+		return c.SendString("ok")
+	})
+
+	if res, _ := q.QuickTest("GET", "/fast", nil); res.StatusCode() != 200 {
+		t.Errorf("expected 200 outside the group, got %d", res.StatusCode())
+	}
+	if res, _ := q.QuickTest("GET", "/strict/slow", nil); res.StatusCode() != 503 {
+		t.Errorf("expected 503 inside the group, got %d", res.StatusCode())
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNewEarlyHints$
+func TestNewEarlyHints(t *testing.T) {
+	h := New()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", "</app.js>; rel=preload; as=script")
+		w.WriteHeader(http.StatusEarlyHints)
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusCreated || rec.Header().Get("Link") == "" {
+		t.Errorf("expected 201 with the Link header, got %d %v", rec.Code, rec.Header())
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNewDeadline$
+func TestNewDeadline(t *testing.T) {
+	q := quick.New()
+	q.Use(New(Config{Timeout: 30 * time.Millisecond}))
+	q.Get("/query", func(c *quick.Ctx) error {
+		deadline, ok := c.Context().Deadline()
+		if !ok || time.Until(deadline) > 30*time.Millisecond {
+			t.Errorf("Expected a deadline within 30ms, got %v %v", deadline, ok)
+		}
+		return c.Status(http.StatusOK).SendString("ok")
+	})
+
+	rec := httptest.NewRecorder()
+	q.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/query", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d", rec.Code)
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNewClientCancel$
+func TestNewClientCancel(t *testing.T) {
+	called := false
+	started := make(chan struct{})
+	mw := New(Config{
+		Timeout: time.Second,
+		OnTimeout: func(c *quick.Ctx) error {
+			called = true
+			return c.Status(http.StatusServiceUnavailable).SendString("Service Unavailable")
+		},
+	})
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+
+	if called {
+		t.Errorf("expected OnTimeout not to run when the client cancels")
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("expected no response body, got %q", rec.Body.String())
+	}
+}
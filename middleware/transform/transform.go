@@ -0,0 +1,120 @@
+// Package transform provides a middleware that buffers the response written
+// by the handler so it can be read and rewritten before it is sent, e.g. to
+// inject a nonce or append a footer to HTML pages.
+// Content-Length is recalculated from the final body.
+//
+// It can be applied globally with q.Use or per route through a Group.
+package transform
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+)
+
+// Response is the buffered response handed to the transform function
+type Response struct {
+	Status int         // status code, 200 if the handler did not set one
+	Header http.Header // response headers, may be changed
+	Body   []byte      // response body, may be replaced
+}
+
+// Config defines the config for the transform middleware
+type Config struct {
+	// Transform rewrites the buffered response. Returning an error sends
+	// 500 Internal Server Error instead. Required.
+	Transform func(r *http.Request, res *Response) error
+	// Skip, if set, leaves responses it returns true for untouched, e.g. to
+	// transform only text/html. Their body is still buffered.
+	Skip func(r *http.Request, res *Response) bool
+}
+
+// Recorder is an http.ResponseWriter that keeps the response in memory.
+// Middlewares can use it directly to inspect what the handler wrote.
+type Recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+// NewRecorder creates an empty Recorder
+// The result will NewRecorder() *Recorder
+func NewRecorder() *Recorder {
+	return &Recorder{header: make(http.Header)}
+}
+
+// Header returns the buffered response headers
+// The result will Header() http.Header
+func (rec *Recorder) Header() http.Header {
+	return rec.header
+}
+
+// WriteHeader records the status code, only the first call counts.
+// Informational statuses, e.g. 103 Early Hints, cannot be buffered and are dropped.
+// The result will WriteHeader(status int)
+func (rec *Recorder) WriteHeader(status int) {
+	if rec.status == 0 && (status < 100 || status > 199) {
+		rec.status = status
+	}
+}
+
+// Write buffers the body
+// The result will Write(b []byte) (int, error)
+func (rec *Recorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return rec.body.Write(b)
+}
+
+// Flush is a no-op: the response is sent once the handler returns
+// The result will Flush()
+func (rec *Recorder) Flush() {}
+
+// Response returns the recorded response
+// The result will Response() *Response
+func (rec *Recorder) Response() *Response {
+	status := rec.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return &Response{Status: status, Header: rec.header, Body: rec.body.Bytes()}
+}
+
+// WriteTo sends res to w, setting Content-Length from the final body
+// The result will WriteTo(w http.ResponseWriter, res *Response) error
+func WriteTo(w http.ResponseWriter, res *Response) error {
+	dst := w.Header()
+	for k, v := range res.Header {
+		dst[k] = v
+	}
+	dst.Set("Content-Length", strconv.Itoa(len(res.Body)))
+	w.WriteHeader(res.Status)
+	_, err := w.Write(res.Body)
+	return err
+}
+
+// New creates the transform middleware
+// The result will New(config Config) func(http.Handler) http.Handler
+func New(config Config) func(http.Handler) http.Handler {
+	if config.Transform == nil {
+		panic("transform: Config.Transform is required")
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := NewRecorder()
+			next.ServeHTTP(rec, r)
+
+			res := rec.Response()
+			if config.Skip == nil || !config.Skip(r, res) {
+				if err := config.Transform(r, res); err != nil {
+					http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+					return
+				}
+			}
+			// #nosec G104
+			WriteTo(w, res)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package transform
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jeffotoni/quick"
+)
+
+// htmlFooter appends a footer to HTML pages only
+var htmlFooter = Config{
+	Transform: func(r *http.Request, res *Response) error {
+		res.Body = bytes.Replace(res.Body, []byte("</body>"), []byte("<footer>quick</footer></body>"), 1)
+		return nil
+	},
+	Skip: func(r *http.Request, res *Response) bool {
+		return !strings.HasPrefix(res.Header.Get("Content-Type"), "text/html")
+	},
+}
+
+// go test -v -failfast -count=1 -run ^TestNew$
+func TestNew(t *testing.T) {
+	q := quick.New()
+	q.Use(New(htmlFooter))
+	q.Get("/page", func(c *quick.Ctx) error {
+		c.Set("Content-Type", "text/html; charset=utf-8")
+		c.Set("Content-Length", "26")
+		return c.Status(http.StatusCreated).SendString("<html><body>hi</body></html>")
+	})
+	q.Get("/api", func(c *quick.Ctx) error {
+		c.Set("Content-Type", "text/plain")
+		return c.Status(http.StatusOK).SendString("</body>")
+	})
+
+	rec := httptest.NewRecorder()
+	q.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/page", nil))
+	want := "<html><body>hi<footer>quick</footer></body></html>"
+	if rec.Code != http.StatusCreated || rec.Body.String() != want {
+		t.Errorf("expected 201 %q, got %d %q", want, rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("Content-Length") != strconv.Itoa(len(want)) {
+		t.Errorf("expected recalculated Content-Length %d, got %q", len(want), rec.Header().Get("Content-Length"))
+	}
+
+	rec = httptest.NewRecorder()
+	q.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api", nil))
+	if rec.Body.String() != "</body>" {
+		t.Errorf("expected skipped text response to be untouched, got %q", rec.Body.String())
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestTransformError$
+func TestTransformError(t *testing.T) {
+	h := New(Config{
+		Transform: func(r *http.Request, res *Response) error { return errors.New("boom") },
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("secret"))
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusInternalServerError || strings.Contains(rec.Body.String(), "secret") {
+		t.Errorf("expected 500 without the original body, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestRecorder$
+func TestRecorder(t *testing.T) {
+	rec := NewRecorder()
+	if res := rec.Response(); res.Status != http.StatusOK || len(res.Body) != 0 {
+		t.Errorf("expected empty 200, got %d %q", res.Status, res.Body)
+	}
+
+	rec.WriteHeader(http.StatusNoContent)
+	rec.WriteHeader(http.StatusOK)
+	rec.Flush()
+	if rec.Response().Status != http.StatusNoContent {
+		t.Errorf("expected first status to win, got %d", rec.Response().Status)
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestNewWithoutTransform$
+func TestNewWithoutTransform(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic without Transform")
+		}
+	}()
+	New(Config{})
+}
@@ -0,0 +1,447 @@
+package quick
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenAPIOperation documents a route in the document built by q.OpenAPI.
+// Schemas are JSON Schema objects, e.g.
+// map[string]interface{}{"type": "object", "properties": ...}.
+type OpenAPIOperation struct {
+	Summary     string
+	Description string
+	Tags        []string               // defaults to the route version, see q.Version
+	RequestBody interface{}            // schema of the application/json request body
+	Responses   map[int]interface{}    // schema of the application/json body per status; nil for no body
+	Extra       map[string]interface{} // extra fields merged into the operation, e.g. "security"
+	Requires    []string               // permissions of the route, see RouteDoc.Requires; not part of the document
+}
+
+// Describe attaches the documentation used by q.OpenAPI to the route
+// registered for method and pattern, e.g. q.Describe("GET", "/users/:id", op).
+// Permissions declared before are kept unless op sets its own.
+// See Doc to annotate a route with Go types instead of schemas.
+// The result will Describe(method, pattern string, op OpenAPIOperation)
+func (q *Quick) Describe(method, pattern string, op OpenAPIOperation) {
+	key := strings.ToUpper(method) + " " + pattern
+	if op.Requires == nil {
+		op.Requires = q.openapi[key].Requires
+	}
+	q.setOperation(key, op)
+}
+
+// setOperation stores the documentation of the route key, "METHOD pattern",
+// and copies its summary, description, tags and permissions to the
+// registered route, so GetRoute and c.Route report them
+// Method Used Internally
+// The result will setOperation(key string, op OpenAPIOperation)
+func (q *Quick) setOperation(key string, op OpenAPIOperation) {
+	if q.openapi == nil {
+		q.openapi = make(map[string]OpenAPIOperation)
+	}
+	q.openapi[key] = op
+	for _, route := range q.routesFor(key) {
+		route.Summary = op.Summary
+		route.Description = op.Description
+		route.Tags = op.Tags
+		route.Requires = op.Requires
+	}
+}
+
+// routesFor returns the registered routes of key, "METHOD pattern", one
+// per host they are constrained to
+// Method Used Internally
+// The result will routesFor(key string) []*Route
+func (q *Quick) routesFor(key string) []*Route {
+	var routes []*Route
+	for _, route := range q.routes {
+		if route.Method+" "+existingPattern(route) == key {
+			routes = append(routes, route)
+		}
+	}
+	return routes
+}
+
+// RouteDoc annotates a route for q.OpenAPI with Go types, see q.Doc.
+// It is also returned when registering a route, e.g.
+//
+//	q.Get("/users/:id", getUser).Describe("Get a user").Tags("users")
+//
+// Routes registered with q.Any or q.Match share one RouteDoc, so each
+// annotation applies to every method.
+type RouteDoc struct {
+	q    *Quick
+	keys []string // "METHOD pattern" of each documented route
+}
+
+// Doc returns the annotations of the route registered for method and
+// pattern. Request and response types are reflected into JSON schemas,
+// following their json tags:
+//
+//	q.Post("/users", createUser)
+//	q.Doc("POST", "/users").Summary("Create a user").Accepts(CreateUser{}).Returns(201, User{})
+//
+// The result will Doc(method, pattern string) *RouteDoc
+func (q *Quick) Doc(method, pattern string) *RouteDoc {
+	key := strings.ToUpper(method) + " " + pattern
+	if q.openapi == nil {
+		q.openapi = make(map[string]OpenAPIOperation)
+	}
+	if _, ok := q.openapi[key]; !ok {
+		q.openapi[key] = OpenAPIOperation{}
+	}
+	return &RouteDoc{q: q, keys: []string{key}}
+}
+
+// routeDoc returns the annotations of a route being registered, copying
+// those already attached with q.Describe or q.Doc to the route
+// Method Used Internally
+// The result will routeDoc(route *Route) *RouteDoc
+func (q *Quick) routeDoc(route *Route) *RouteDoc {
+	d := &RouteDoc{q: q, keys: []string{route.Method + " " + existingPattern(route)}}
+	if op, ok := q.openapi[d.keys[0]]; ok {
+		route.Summary = op.Summary
+		route.Description = op.Description
+		route.Tags = op.Tags
+		route.Requires = op.Requires
+	}
+	return d
+}
+
+// Summary sets the summary of the route
+// The result will Summary(summary string) *RouteDoc
+func (d *RouteDoc) Summary(summary string) *RouteDoc {
+	return d.update(func(op *OpenAPIOperation) {
+		op.Summary = summary
+	})
+}
+
+// Describe sets the description of the route, a longer explanation
+// shown under the summary
+// The result will Describe(description string) *RouteDoc
+func (d *RouteDoc) Describe(description string) *RouteDoc {
+	return d.update(func(op *OpenAPIOperation) {
+		op.Description = description
+	})
+}
+
+// Tags adds tags grouping the route in the docs, e.g. by domain; without
+// tags the route version is used
+// The result will Tags(tags ...string) *RouteDoc
+func (d *RouteDoc) Tags(tags ...string) *RouteDoc {
+	return d.update(func(op *OpenAPIOperation) {
+		op.Tags = append(append([]string(nil), op.Tags...), tags...)
+	})
+}
+
+// Requires declares permissions a request needs to reach the route, e.g.
+// q.Delete("/users/:id", h).Requires("admin"). Quick does not enforce
+// them: an authorization middleware reads them from c.Route().Requires.
+// Like the other annotations, they also apply to a route registered later.
+// The result will Requires(permissions ...string) *RouteDoc
+func (d *RouteDoc) Requires(permissions ...string) *RouteDoc {
+	return d.update(func(op *OpenAPIOperation) {
+		op.Requires = append(append([]string(nil), op.Requires...), permissions...)
+	})
+}
+
+// Accepts sets the JSON request body to the schema of v, e.g. CreateUser{}
+// The result will Accepts(v interface{}) *RouteDoc
+func (d *RouteDoc) Accepts(v interface{}) *RouteDoc {
+	return d.update(func(op *OpenAPIOperation) {
+		op.RequestBody = SchemaOf(v)
+	})
+}
+
+// Returns documents a response status with the schema of v as its JSON
+// body, e.g. Returns(200, []User{}); a nil v documents a response without body
+// The result will Returns(status int, v interface{}) *RouteDoc
+func (d *RouteDoc) Returns(status int, v interface{}) *RouteDoc {
+	return d.update(func(op *OpenAPIOperation) {
+		responses := make(map[int]interface{}, len(op.Responses)+1)
+		for k, s := range op.Responses {
+			responses[k] = s
+		}
+		if v != nil {
+			responses[status] = SchemaOf(v)
+		} else {
+			responses[status] = nil
+		}
+		op.Responses = responses
+	})
+}
+
+// update applies fn to the operation of every route documented by d
+// Method Used Internally
+// The result will update(fn func(op *OpenAPIOperation)) *RouteDoc
+func (d *RouteDoc) update(fn func(op *OpenAPIOperation)) *RouteDoc {
+	for _, key := range d.keys {
+		op := d.q.openapi[key]
+		fn(&op)
+		d.q.setOperation(key, op)
+	}
+	return d
+}
+
+// join merges the routes documented by other into d, used by q.Any and
+// q.Match to return one RouteDoc for all their methods
+// Method Used Internally
+// The result will join(other *RouteDoc) *RouteDoc
+func (d *RouteDoc) join(other *RouteDoc) *RouteDoc {
+	if d == nil {
+		return other
+	}
+	if other != nil {
+		d.keys = append(d.keys, other.keys...)
+	}
+	return d
+}
+
+// SchemaOf reflects the type of v into a JSON schema: structs become
+// objects whose properties follow the json tags, fields without omitempty
+// being required; slices become arrays, maps objects and time.Time a
+// date-time string. Recursive types stop at a plain object.
+// The result will SchemaOf(v interface{}) map[string]interface{}
+func SchemaOf(v interface{}) map[string]interface{} {
+	if v == nil {
+		return map[string]interface{}{}
+	}
+	return jsonSchema(reflect.TypeOf(v), map[reflect.Type]bool{})
+}
+
+// timeType is the reflect type of time.Time, documented as a date-time string
+var timeType = reflect.TypeOf(time.Time{})
+
+// jsonSchema builds the schema of t; seen holds the struct types being
+// expanded to cut recursion
+// Method Used Internally
+// The result will jsonSchema(t reflect.Type, seen map[reflect.Type]bool) map[string]interface{}
+func jsonSchema(t reflect.Type, seen map[reflect.Type]bool) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": jsonSchema(t.Elem(), seen)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": jsonSchema(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return map[string]interface{}{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		props := map[string]interface{}{}
+		var required []string
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" && opts == "" {
+				continue
+			}
+			ft := f.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			// embedded structs without a name are flattened, as encoding/json
+			// does, even when their type is unexported
+			if f.Anonymous && name == "" && ft.Kind() == reflect.Struct && ft != timeType {
+				embedded := jsonSchema(ft, seen)
+				if p, ok := embedded["properties"].(map[string]interface{}); ok {
+					for k, v := range p {
+						props[k] = v
+					}
+				}
+				if r, ok := embedded["required"].([]string); ok {
+					required = append(required, r...)
+				}
+				continue
+			}
+			if !f.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			props[name] = jsonSchema(f.Type, seen)
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		schema := map[string]interface{}{"type": "object", "properties": props}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	}
+	return map[string]interface{}{}
+}
+
+// OpenAPI builds an OpenAPI 3 JSON document from the registered routes:
+// every method and pattern with its path params, in the order of the
+// pattern, plus the schemas attached with Describe. Routes without a
+// description answer 200. Optional trailing params produce one path per
+// variant, since OpenAPI path params are always required.
+//
+//	q.Get("/openapi.json", func(c *quick.Ctx) error {
+//		doc, err := q.OpenAPI("Users API", "1.0.0")
+//		if err != nil {
+//			return err
+//		}
+//		c.Set("Content-Type", "application/json")
+//		return c.Status(200).Send(doc)
+//	})
+//
+// The result will OpenAPI(title, version string) ([]byte, error)
+func (q *Quick) OpenAPI(title, version string) ([]byte, error) {
+	paths := map[string]map[string]interface{}{}
+	for _, route := range q.routes {
+		pattern := existingPattern(route)
+		op := q.openapi[route.Method+" "+pattern]
+		for _, variant := range openAPIPaths(pattern) {
+			item := paths[variant.path]
+			if item == nil {
+				item = map[string]interface{}{}
+				paths[variant.path] = item
+			}
+			item[strings.ToLower(route.Method)] = openAPIOperation(route, op, variant.params)
+		}
+	}
+
+	return json.MarshalIndent(map[string]interface{}{
+		"openapi": "3.0.3",
+		"info":    map[string]string{"title": title, "version": version},
+		"paths":   paths,
+	}, "", "  ")
+}
+
+// openAPIPath is a pattern written as an OpenAPI path with its params
+type openAPIPath struct {
+	path   string
+	params []map[string]interface{}
+}
+
+// openAPIPaths converts a route pattern to OpenAPI paths: ":id" and
+// "{id:[0-9]+}" become "{id}", the regex becoming the schema pattern.
+// A pattern with optional trailing params gives one path per variant.
+// Method Used Internally
+// The result will openAPIPaths(pattern string) []openAPIPath
+func openAPIPaths(pattern string) []openAPIPath {
+	var (
+		out    []openAPIPath
+		segs   []string
+		params []map[string]interface{}
+	)
+	emit := func() {
+		out = append(out, openAPIPath{
+			path:   "/" + strings.Join(segs, "/"),
+			params: append([]map[string]interface{}(nil), params...),
+		})
+	}
+
+	for _, seg := range splitPath(pattern) {
+		optional := strings.HasSuffix(seg, "?") && (strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "{"))
+		if optional {
+			emit()
+			seg = strings.TrimSuffix(seg, "?")
+		}
+
+		schema := map[string]interface{}{"type": "string"}
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			name, re, _ := strings.Cut(seg[1:len(seg)-1], ":")
+			if re != "" {
+				schema["pattern"] = "^" + re + "$"
+			}
+			seg = ":" + name
+		}
+		if strings.HasPrefix(seg, ":") {
+			name := seg[1:]
+			params = append(params, map[string]interface{}{
+				"name":     name,
+				"in":       "path",
+				"required": true,
+				"schema":   schema,
+			})
+			seg = "{" + name + "}"
+		}
+		segs = append(segs, seg)
+	}
+	emit()
+	return out
+}
+
+// openAPIOperation builds the operation object of a route
+// Method Used Internally
+// The result will openAPIOperation(route *Route, op OpenAPIOperation, params []map[string]interface{}) map[string]interface{}
+func openAPIOperation(route *Route, op OpenAPIOperation, params []map[string]interface{}) map[string]interface{} {
+	out := map[string]interface{}{}
+	for k, v := range op.Extra {
+		out[k] = v
+	}
+	if op.Summary != "" {
+		out["summary"] = op.Summary
+	}
+	if op.Description != "" {
+		out["description"] = op.Description
+	}
+	switch {
+	case len(op.Tags) > 0:
+		out["tags"] = op.Tags
+	case route.Version != "":
+		out["tags"] = []string{route.Version}
+	}
+	if len(params) > 0 {
+		out["parameters"] = params
+	}
+	if op.RequestBody != nil {
+		out["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  openAPIContent(op.RequestBody),
+		}
+	}
+
+	responses := map[string]interface{}{}
+	for status, schema := range op.Responses {
+		res := map[string]interface{}{"description": http.StatusText(status)}
+		if schema != nil {
+			res["content"] = openAPIContent(schema)
+		}
+		responses[strconv.Itoa(status)] = res
+	}
+	if len(responses) == 0 {
+		responses["200"] = map[string]interface{}{"description": http.StatusText(http.StatusOK)}
+	}
+	out["responses"] = responses
+	return out
+}
+
+// openAPIContent wraps a schema as an application/json content object
+// Method Used Internally
+// The result will openAPIContent(schema interface{}) map[string]interface{}
+func openAPIContent(schema interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		ContentTypeAppJSON: map[string]interface{}{"schema": schema},
+	}
+}
@@ -0,0 +1,213 @@
+package quick
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQuickOpenAPI(t *testing.T) {
+	ok := func(c *Ctx) error { return nil }
+	user := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"name": map[string]string{"type": "string"}}}
+
+	q := New()
+	q.Get("/users/{id:[0-9]+}", ok)
+	q.Post("/users", ok)
+	q.Get("/posts/:id/:slug?", ok)
+	q.Version("v2", func(g *Group) {
+		g.Get("/users", ok)
+	})
+	q.Describe("POST", "/users", OpenAPIOperation{
+		Summary:     "Create a user",
+		RequestBody: user,
+		Responses:   map[int]interface{}{201: user, 400: nil},
+	})
+
+	b, err := q.OpenAPI("Users API", "1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		OpenAPI string
+		Info    map[string]string
+		Paths   map[string]map[string]struct {
+			Summary     string
+			Tags        []string
+			Parameters  []map[string]interface{}
+			RequestBody map[string]interface{}
+			Responses   map[string]map[string]interface{}
+		}
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatalf("expected valid JSON, got %v: %s", err, b)
+	}
+
+	if doc.OpenAPI != "3.0.3" || doc.Info["title"] != "Users API" || doc.Info["version"] != "1.0.0" {
+		t.Errorf("unexpected header: %s %v", doc.OpenAPI, doc.Info)
+	}
+
+	get := doc.Paths["/users/{id}"]["get"]
+	if len(get.Parameters) != 1 || get.Parameters[0]["name"] != "id" || get.Parameters[0]["schema"].(map[string]interface{})["pattern"] != "^[0-9]+$" {
+		t.Errorf("expected the id param with its regex, got %v", get.Parameters)
+	}
+	if _, ok := get.Responses["200"]; !ok {
+		t.Errorf("expected a default 200 response, got %v", get.Responses)
+	}
+
+	post := doc.Paths["/users"]["post"]
+	if post.Summary != "Create a user" || post.RequestBody == nil || post.Responses["201"]["content"] == nil || post.Responses["400"]["description"] != "Bad Request" {
+		t.Errorf("expected the described operation, got %+v", post)
+	}
+
+	if _, ok := doc.Paths["/posts/{id}"]["get"]; !ok {
+		t.Errorf("expected the variant without the optional param, got %v", doc.Paths)
+	}
+	if p := doc.Paths["/posts/{id}/{slug}"]["get"].Parameters; len(p) != 2 || p[1]["name"] != "slug" {
+		t.Errorf("expected both params in order, got %v", p)
+	}
+
+	if tags := doc.Paths["/v2/users"]["get"].Tags; len(tags) != 1 || tags[0] != "v2" {
+		t.Errorf("expected the version as tag, got %v", tags)
+	}
+}
+
+type docBase struct {
+	ID int64 `json:"id"`
+}
+
+type docUser struct {
+	docBase
+	Name    string            `json:"name"`
+	Email   *string           `json:"email,omitempty"`
+	Tags    []string          `json:"tags"`
+	Meta    map[string]int    `json:"meta,omitempty"`
+	Created time.Time         `json:"created_at"`
+	Friends []*docUser        `json:"friends,omitempty"`
+	Secret  string            `json:"-"`
+	Extra   map[string]string `json:"-,omitempty"`
+	hidden  bool
+}
+
+func TestQuickDoc(t *testing.T) {
+	q := New()
+	q.Post("/users", func(c *Ctx) error { return nil })
+	q.Doc("POST", "/users").Summary("Create a user").Accepts(docUser{}).Returns(201, &docUser{}).Returns(400, nil)
+
+	b, err := q.OpenAPI("Users API", "1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Paths map[string]map[string]struct {
+			Summary     string
+			RequestBody struct {
+				Content map[string]struct {
+					Schema struct {
+						Type       string
+						Required   []string
+						Properties map[string]map[string]interface{}
+					}
+				}
+			}
+			Responses map[string]map[string]interface{}
+		}
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	post := doc.Paths["/users"]["post"]
+	schema := post.RequestBody.Content[ContentTypeAppJSON].Schema
+	props := schema.Properties
+	if post.Summary != "Create a user" || schema.Type != "object" {
+		t.Fatalf("expected the annotated operation, got %s", b)
+	}
+	want := map[string]string{"id": "integer", "name": "string", "email": "string", "tags": "array", "meta": "object", "created_at": "string", "friends": "array", "-": "object"}
+	if len(props) != len(want) {
+		t.Errorf("expected properties %v, got %v", want, props)
+	}
+	for name, typ := range want {
+		if props[name]["type"] != typ {
+			t.Errorf("expected %s to be %s, got %v", name, typ, props[name])
+		}
+	}
+	if props["created_at"]["format"] != "date-time" {
+		t.Errorf("expected time.Time as date-time, got %v", props["created_at"])
+	}
+	if strings.Join(schema.Required, ",") != "id,name,tags,created_at" {
+		t.Errorf("expected fields without omitempty to be required, got %v", schema.Required)
+	}
+	if post.Responses["201"]["content"] == nil || post.Responses["400"]["content"] != nil {
+		t.Errorf("expected a 201 body and an empty 400, got %v", post.Responses)
+	}
+}
+
+func TestQuickRouteDescribe(t *testing.T) {
+	ok := func(c *Ctx) error { return nil }
+
+	q := New()
+	q.Describe("DELETE", "/users/:id", OpenAPIOperation{Summary: "Delete a user"})
+	q.Get("/users/:id", ok).Summary("Get a user").Describe("Returns the user with the given id").Tags("users")
+	q.Delete("/users/:id", ok)
+	q.Group("/admin").Post("/users", ok).Tags("admin", "users")
+
+	routes := map[string]*Route{}
+	for _, route := range q.GetRoute() {
+		routes[route.Method+" "+existingPattern(route)] = route
+	}
+	get := routes["GET /users/:id"]
+	if get.Summary != "Get a user" || get.Description != "Returns the user with the given id" || strings.Join(get.Tags, ",") != "users" {
+		t.Errorf("expected the description on the route, got %+v", get)
+	}
+	if routes["DELETE /users/:id"].Summary != "Delete a user" {
+		t.Errorf("expected the summary described before registration, got %+v", routes["DELETE /users/:id"])
+	}
+	if post := routes["POST /admin/users"]; strings.Join(post.Tags, ",") != "admin,users" {
+		t.Errorf("expected the group route tags, got %v", post.Tags)
+	}
+
+	b, err := q.OpenAPI("Users API", "1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Paths map[string]map[string]struct {
+			Summary     string
+			Description string
+			Tags        []string
+		}
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+	op := doc.Paths["/users/{id}"]["get"]
+	if op.Summary != "Get a user" || op.Description != "Returns the user with the given id" || strings.Join(op.Tags, ",") != "users" {
+		t.Errorf("expected the description in the document, got %s", b)
+	}
+	if tags := doc.Paths["/admin/users"]["post"].Tags; strings.Join(tags, ",") != "admin,users" {
+		t.Errorf("expected the group route tags in the document, got %v", tags)
+	}
+}
+
+// go test -v -failfast -count=1 -run ^TestQuickRouteRequires$
+func TestQuickRouteRequires(t *testing.T) {
+	q := New()
+	// declared before the route is registered
+	q.Doc("DELETE", "/users/:id").Requires("admin")
+	q.Delete("/users/:id", func(c *Ctx) error { return nil })
+	// declared on registration
+	q.Put("/users/:id", func(c *Ctx) error { return nil }).Requires("admin", "editor")
+	// a later Describe keeps them
+	q.Describe("PUT", "/users/:id", OpenAPIOperation{Summary: "Update a user"})
+
+	want := map[string]string{"DELETE": "admin", "PUT": "admin,editor"}
+	for _, r := range q.GetRoute() {
+		if got := strings.Join(r.Requires, ","); got != want[r.Method] {
+			t.Errorf("%s %s: expected requires %q, got %q", r.Method, r.Path, want[r.Method], got)
+		}
+	}
+	if len(q.GetRoute()) != 2 {
+		t.Errorf("expected 2 routes, got %d", len(q.GetRoute()))
+	}
+}
@@ -0,0 +1,102 @@
+package quick
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Limits applied by Ctx.Pagination when the query does not set one
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// Pagination is the page of a list requested by the client, read with
+// c.Pagination() or c.Paginate(total). Its fields are also the metadata
+// to send in the body, e.g. {"data": [...], "pagination": p}.
+type Pagination struct {
+	Page   int `json:"page"`            // 1 based
+	Limit  int `json:"limit"`           // items per page
+	Offset int `json:"offset"`          // items skipped
+	Total  int `json:"total,omitempty"` // items in the list, set by Paginate
+	Pages  int `json:"pages,omitempty"` // pages in the list, set by Paginate
+}
+
+// Pagination reads the page requested in the query: page (1 based) and
+// limit, or offset and limit. limit defaults to 20 and is capped at 100;
+// an explicit offset wins over page. Invalid values fall back to the
+// first page.
+//
+//	p := c.Pagination()          // ?page=3&limit=10 => {Page:3 Limit:10 Offset:20}
+//	rows, err := db.Query("... LIMIT ? OFFSET ?", p.Limit, p.Offset)
+//
+// The result will Pagination() Pagination
+func (c *Ctx) Pagination() Pagination {
+	query := c.Request.URL.Query()
+	p := Pagination{Page: 1, Limit: defaultPageLimit}
+	if n, err := strconv.Atoi(query.Get("limit")); err == nil && n > 0 {
+		p.Limit = min(n, maxPageLimit)
+	}
+	if n, err := strconv.Atoi(query.Get("offset")); err == nil && n >= 0 {
+		p.Offset = n
+		p.Page = n/p.Limit + 1
+	} else if n, err := strconv.Atoi(query.Get("page")); err == nil && n > 0 {
+		p.Page = n
+		p.Offset = (n - 1) * p.Limit
+	}
+	return p
+}
+
+// Paginate reads the requested page like Pagination and writes the
+// metadata of a list of total items: the X-Total-Count header and a Link
+// header with the first, prev, next and last pages, keeping the other
+// query params. The returned Pagination carries Total and Pages too.
+//
+//	p := c.Paginate(len(users))
+//	start, end := p.Bounds()
+//	return c.Status(200).JSON(users[start:end])
+//
+// The result will Paginate(total int) Pagination
+func (c *Ctx) Paginate(total int) Pagination {
+	p := c.Pagination()
+	if total < 0 {
+		total = 0
+	}
+	p.Total = total
+	p.Pages = (total + p.Limit - 1) / p.Limit
+
+	c.Set("X-Total-Count", strconv.Itoa(total))
+	last := max(p.Pages, 1)
+	links := []string{c.pageLink(p, 1, "first")}
+	if p.Page > 1 {
+		links = append(links, c.pageLink(p, min(p.Page-1, last), "prev"))
+	}
+	if p.Offset+p.Limit < total {
+		links = append(links, c.pageLink(p, p.Page+1, "next"))
+	}
+	links = append(links, c.pageLink(p, last, "last"))
+	c.Set("Link", strings.Join(links, ", "))
+	return p
+}
+
+// Bounds returns the indexes of the page within the list, clamped to
+// Total, to slice an in-memory list: items[start:end]
+// The result will Bounds() (start, end int)
+func (p Pagination) Bounds() (start, end int) {
+	start = min(p.Offset, p.Total)
+	end = min(p.Offset+p.Limit, p.Total)
+	return start, end
+}
+
+// pageLink returns the Link header entry of page with relation rel
+// Method Used Internally
+// The result will pageLink(p Pagination, page int, rel string) string
+func (c *Ctx) pageLink(p Pagination, page int, rel string) string {
+	query := c.Request.URL.Query()
+	query.Del("offset")
+	query.Set("page", strconv.Itoa(page))
+	query.Set("limit", strconv.Itoa(p.Limit))
+	u := url.URL{Path: c.Request.URL.Path, RawPath: c.Request.URL.RawPath, RawQuery: query.Encode()}
+	return "<" + u.String() + `>; rel="` + rel + `"`
+}
@@ -0,0 +1,72 @@
+package quick
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestCtxPagination(t *testing.T) {
+	tests := []struct {
+		query string
+		want  Pagination
+	}{
+		{"", Pagination{Page: 1, Limit: 20, Offset: 0}},
+		{"?page=3&limit=10", Pagination{Page: 3, Limit: 10, Offset: 20}},
+		{"?offset=25&limit=10&page=9", Pagination{Page: 3, Limit: 10, Offset: 25}},
+		{"?limit=1000", Pagination{Page: 1, Limit: 100, Offset: 0}},
+		{"?page=-2&limit=abc&offset=-1", Pagination{Page: 1, Limit: 20, Offset: 0}},
+	}
+	for _, tt := range tests {
+		c := &Ctx{Request: httptest.NewRequest(MethodGet, "/users"+tt.query, nil)}
+		if got := c.Pagination(); got != tt.want {
+			t.Errorf("%q: expected %+v, got %+v", tt.query, tt.want, got)
+		}
+	}
+}
+
+func TestCtxPaginate(t *testing.T) {
+	users := make([]int, 45)
+	for i := range users {
+		users[i] = i + 1
+	}
+	q := New()
+	q.Get("/users", func(c *Ctx) error {
+		p := c.Paginate(len(users))
+		start, end := p.Bounds()
+		return c.Status(StatusOK).JSON(map[string]interface{}{"data": users[start:end], "pagination": p})
+	})
+
+	tests := []struct {
+		query string
+		link  string
+		first int
+		count int
+	}{
+		{"?page=2&limit=10&sort=name", `</users?limit=10&page=1&sort=name>; rel="first", </users?limit=10&page=1&sort=name>; rel="prev", </users?limit=10&page=3&sort=name>; rel="next", </users?limit=10&page=5&sort=name>; rel="last"`, 11, 10},
+		{"", `</users?limit=20&page=1>; rel="first", </users?limit=20&page=2>; rel="next", </users?limit=20&page=3>; rel="last"`, 1, 20},
+		{"?offset=40&limit=10", `</users?limit=10&page=1>; rel="first", </users?limit=10&page=4>; rel="prev", </users?limit=10&page=5>; rel="last"`, 41, 5},
+		{"?page=9&limit=10", `</users?limit=10&page=1>; rel="first", </users?limit=10&page=5>; rel="prev", </users?limit=10&page=5>; rel="last"`, 0, 0},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		q.ServeHTTP(rec, httptest.NewRequest(MethodGet, "/users"+tt.query, nil))
+		if rec.Header().Get("X-Total-Count") != strconv.Itoa(len(users)) {
+			t.Errorf("%q: expected X-Total-Count 45, got %q", tt.query, rec.Header().Get("X-Total-Count"))
+		}
+		if rec.Header().Get("Link") != tt.link {
+			t.Errorf("%q: expected Link %s, got %s", tt.query, tt.link, rec.Header().Get("Link"))
+		}
+		var body struct {
+			Data       []int
+			Pagination Pagination
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if len(body.Data) != tt.count || (tt.count > 0 && body.Data[0] != tt.first) || body.Pagination.Total != 45 {
+			t.Errorf("%q: expected %d items from %d, got %s", tt.query, tt.count, tt.first, rec.Body.String())
+		}
+	}
+}
@@ -12,13 +12,20 @@ import (
     "embed"
     "encoding/json"
     "encoding/xml"
+    "errors"
+    "fmt"
     "io"
+    "log/slog"
     "net"
     "net/http"
+    "net/url"
     "os"
+    "os/signal"
+    "path"
     "regexp"
     "runtime/debug"
     "strings"
+    "syscall"
     "time"
 
     "github.com/jeffotoni/quick/internal/concat"
@@ -28,6 +35,12 @@ import (
 // Run Server Quick:0.0.0.0:<PORT>
 var PRINT_SERVER = os.Getenv("PRINT_SERVER")
 
+// defaultMaxRouteParams is used when Config.MaxRouteParams is not set
+const defaultMaxRouteParams = 32
+
+// defaultLogger is used when Config.Logger is not set
+var defaultLogger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+
 const (
     ContentTypeAppJSON = `application/json`
     ContentTypeAppXML  = `application/xml`
@@ -37,7 +50,11 @@ const (
 
 type contextKey int
 
-const myContextKey contextKey = 0
+const (
+    myContextKey contextKey = 0
+    logAttrsKey  contextKey = 1 // attributes added by WithLogAttrs
+    batchKey     contextKey = 2 // marks the sub-requests of a batch, see Batch
+)
 
 type HandleFunc func(*Ctx) error
 
@@ -49,19 +66,57 @@ type Route struct {
     Path    string
     Params  string
     Method  string
+    Host    string // host pattern the route is constrained to, e.g. {tenant}.example.com
+    Version string // API version of the group registered with q.Version, e.g. v1
+    // Summary, Description and Tags document the route, see RouteDoc
+    Summary     string
+    Description string
+    Tags        []string
+    // Requires lists the permissions declared with RouteDoc.Requires, for
+    // authorization middlewares reading c.Route()
+    Requires []string
     handler http.HandlerFunc
+    caller  string // file:line where the route was registered
 }
 
 type ctxServeHttp struct {
-    Path      string
-    Params    string
-    Method    string
-    ParamsMap map[string]string
+    Path            string
+    Pattern         string      // matched route pattern, e.g. /users/:id
+    Params          string
+    Method          string
+    ParamNames      []string
+    ParamValues     []string
+    Logger          *slog.Logger
+    Proxies         []*net.IPNet
+    Written         *sizeWriter // counts the response bytes, see Ctx.ResponseSize
+    Start           time.Time   // when ServeHTTP received the request, see Ctx.StartTime
+    ErrorHandler    func(c *Ctx, err error) error
+    Storage         Storage
+    MultipartMemory int64       // Config.MultipartMemoryLimit
+    Route           *Route      // matched route, see Ctx.Route
+    Locals          *requestLocals
+    OnInvalidStatus func(c *Ctx, status int) int
+}
+
+// requestState holds the route state of a request in one allocation: the
+// ctxServeHttp kept in the context and the writer and locals it points to
+type requestState struct {
+    cval   ctxServeHttp
+    sw     sizeWriter
+    locals requestLocals
+}
+
+// requestLocals holds the values of Ctx.Locals, shared by every Ctx of a
+// request so middlewares can pass values to the handler
+type requestLocals struct {
+    values map[string]interface{}
 }
 
 type Config struct {
     BodyLimit         int64
     MaxBodySize       int64
+    // MaxHeaderBytes caps the size of the request headers; larger requests
+    // are answered with 431 Request Header Fields Too Large. Default 1MB.
     MaxHeaderBytes    int64
     RouteCapacity     int
     MoreRequests      int // 0 a 1000
@@ -69,6 +124,70 @@ type Config struct {
     WriteTimeout      time.Duration
     IdleTimeout       time.Duration
     ReadHeaderTimeout time.Duration
+    // ExpectContinue is called for requests sent with "Expect: 100-continue"
+    // before the body is read. Returning false answers 417 Expectation Failed
+    // without reading the body. Otherwise net/http sends "100 Continue" when
+    // the body is first read. Oversized Content-Length values are always
+    // rejected with 413 before the client is told to continue.
+    ExpectContinue func(c *Ctx) bool
+    // RouteConflictError records routes registered twice for the same method
+    // and pattern, and routes over MaxRouteParams, as errors returned by
+    // ValidateRoutes and the Listen functions, instead of panicking at registration.
+    RouteConflictError bool
+    // MaxRouteParams caps the number of params of a route, counting host
+    // and path params, so pathological patterns are rejected at registration.
+    // Rejections panic, or are recorded like conflicts when RouteConflictError
+    // is enabled. Default 32.
+    MaxRouteParams int
+    // RequestTimeout, when set, bounds every route: the request context is
+    // cancelled after the duration and the client receives 503 Service
+    // Unavailable. The response is buffered until the handler returns, so
+    // leave it zero for streaming routes. Zero disables it.
+    RequestTimeout time.Duration
+    // TrustedProxies lists the IPs and CIDR ranges (e.g. "10.0.0.0/8",
+    // "127.0.0.1") of the proxies whose X-Forwarded-For and X-Forwarded-Proto
+    // headers are trusted by c.ClientIP and c.Protocol. Headers from other
+    // addresses are ignored. New panics on invalid entries.
+    TrustedProxies []string
+    // Logger receives the framework logs: startup messages, handler errors
+    // and the output of the logger middleware. Defaults to a text handler
+    // writing to stderr.
+    Logger *slog.Logger
+    // ErrorHandler writes the response for errors returned by handlers and
+    // for panics caught by the recover middleware, which arrive as
+    // *PanicError, so every error shares one format. By default malformed
+    // bodies get 400 and other errors 500 with the error text; panics get
+    // 500 "Internal Server Error". If it returns an error, the default is used.
+    ErrorHandler func(c *Ctx, err error) error
+    // OnBodyLimitExceeded writes the response for request bodies over
+    // MaxBodySize, whether announced by Content-Length or found while
+    // reading a chunked body, e.g. to send a JSON error. The status is up
+    // to the hook; 413 is expected. By default, or when it returns an error,
+    // the client gets a plain 413 "Request body too large".
+    OnBodyLimitExceeded func(c *Ctx) error
+    // CleanPath routes requests by their normalized path: duplicate slashes
+    // are collapsed and "." and ".." segments resolved, so /api//users and
+    // /api/v1/../users match /api/users. A trailing slash is kept, and
+    // c.Path() returns the normalized path.
+    CleanPath bool
+    // RedirectCleanPath, with CleanPath, redirects requests to the
+    // normalized path instead of serving them: 301 Moved Permanently for
+    // GET and HEAD, 308 Permanent Redirect otherwise so the body is resent.
+    RedirectCleanPath bool
+    // Storage receives the uploads saved with c.SaveFileTo(nil, file), so
+    // handlers keep working when the backend changes, e.g. DiskStorage in
+    // development and an S3 implementation in production.
+    Storage Storage
+    // MultipartMemoryLimit is how much of a multipart form is kept in
+    // memory when it is parsed, e.g. by c.FormFile; the parts past it are
+    // written to temporary files. c.FormFileLimit overrides it per request.
+    // Default 1MB.
+    MultipartMemoryLimit int64
+    // OnInvalidStatus is called when c.Status or c.SendStatus receive a
+    // code outside 100-599, e.g. 999, and returns the status to send
+    // instead. It may panic to fail fast in development. By default the
+    // code is logged as an error and 500 is sent.
+    OnInvalidStatus func(c *Ctx, status int) int
 }
 
 var defaultConfig = Config{
@@ -96,12 +215,17 @@ type Quick struct {
     handler       http.Handler
     mux           *http.ServeMux
     routes        []*Route
+    router        *router
     routeCapacity int
     mws2          []any
     CorsSet       func(http.Handler) http.Handler
     CorsOptions   map[string]string
     embedFS       embed.FS
     server        *http.Server
+    onListen      []func(addr string)
+    routeErrs     []error
+    proxies       []*net.IPNet // parsed Config.TrustedProxies
+    openapi       map[string]OpenAPIOperation // route docs added with Describe
 }
 
 // GetDefaultConfig Function is responsible for returning a default configuration that is pre-defined for the system
@@ -122,18 +246,35 @@ func New(c ...Config) *Quick {
     if config.RouteCapacity == 0 {
         config.RouteCapacity = 1000
     }
+    proxies, err := parseTrustedProxies(config.TrustedProxies)
+    if err != nil {
+        panic(err.Error())
+    }
 
     return &Quick{
         routes:        make([]*Route, 0, config.RouteCapacity),
+        router:        newRouter(),
         routeCapacity: config.RouteCapacity,
         mux:           http.NewServeMux(),
         handler:       http.NewServeMux(),
         config:        config,
+        proxies:       proxies,
     }
 }
 
-// Use function adds middleware to the Quick server, with special treatment for CORS
-// Method Used Internally
+// Logger returns the logger set in Config.Logger, or the default text logger
+// The result will Logger() *slog.Logger
+func (q *Quick) Logger() *slog.Logger {
+    if q.config.Logger == nil {
+        return defaultLogger
+    }
+    return q.config.Logger
+}
+
+// Use function adds middleware to the Quick server, with special treatment for CORS.
+// Middlewares wrap the routes registered afterwards and run in registration
+// order: the first added runs first and sees the request before the ones
+// added later, then group middlewares run, then the handler.
 // The result will Use(mw any, nf ...string)
 func (q *Quick) Use(mw any, nf ...string) {
     q.mws2 = append(q.mws2, mw)
@@ -167,8 +308,8 @@ func clearRegex(route string) string {
 
 // registerRoute is a helper function to centralize route registration logic.
 // Method Used Internally
-// The result will registerRoute(method, pattern string, handlerFunc HandleFunc)
-func (q *Quick) registerRoute(method, pattern string, handlerFunc HandleFunc) {
+// The result will registerRoute(method, pattern string, handlerFunc HandleFunc) *RouteDoc
+func (q *Quick) registerRoute(method, pattern string, handlerFunc HandleFunc) *RouteDoc {
     path, params, patternExist := extractParamsPattern(pattern)
     formattedPath := strings.ToLower(method) + "#" + clearRegex(pattern)
     route := Route{
@@ -179,44 +320,95 @@ func (q *Quick) registerRoute(method, pattern string, handlerFunc HandleFunc) {
         Method:  method,
     }
 
-    q.appendRoute(&route)
-    q.mux.HandleFunc(formattedPath, route.handler)
+    doc := q.routeDoc(&route)
+    if q.appendRoute(&route) {
+        q.mux.HandleFunc(formattedPath, route.handler)
+    }
+    return doc
 }
 
 // Get function is an HTTP route with the GET method on the Quick server
-// The result will Get(pattern string, handlerFunc HandleFunc)
-func (q *Quick) Get(pattern string, handlerFunc HandleFunc) {
-    q.registerRoute(MethodGet, pattern, handlerFunc)
+// The returned RouteDoc documents the route, e.g. q.Get("/users", list).Describe("List the users").Tags("users")
+// The result will Get(pattern string, handlerFunc HandleFunc) *RouteDoc
+func (q *Quick) Get(pattern string, handlerFunc HandleFunc) *RouteDoc {
+    return q.registerRoute(MethodGet, pattern, handlerFunc)
 }
 
 // Post function registers an HTTP route with the POST method on the Quick server
-// The result will Post(pattern string, handlerFunc HandleFunc)
-func (q *Quick) Post(pattern string, handlerFunc HandleFunc) {
-    q.registerRoute(MethodPost, pattern, handlerFunc)
+// The result will Post(pattern string, handlerFunc HandleFunc) *RouteDoc
+func (q *Quick) Post(pattern string, handlerFunc HandleFunc) *RouteDoc {
+    return q.registerRoute(MethodPost, pattern, handlerFunc)
 }
 
 // Put function registers an HTTP route with the PUT method on the Quick server.
-// The result will Put(pattern string, handlerFunc HandleFunc)
-func (q *Quick) Put(pattern string, handlerFunc HandleFunc) {
-    q.registerRoute(MethodPut, pattern, handlerFunc)
+// The result will Put(pattern string, handlerFunc HandleFunc) *RouteDoc
+func (q *Quick) Put(pattern string, handlerFunc HandleFunc) *RouteDoc {
+    return q.registerRoute(MethodPut, pattern, handlerFunc)
 }
 
 // Delete function registers an HTTP route with the DELETE method on the Quick server.
-// The result will Delete(pattern string, handlerFunc HandleFunc)
-func (q *Quick) Delete(pattern string, handlerFunc HandleFunc) {
-    q.registerRoute(MethodDelete, pattern, handlerFunc)
+// The result will Delete(pattern string, handlerFunc HandleFunc) *RouteDoc
+func (q *Quick) Delete(pattern string, handlerFunc HandleFunc) *RouteDoc {
+    return q.registerRoute(MethodDelete, pattern, handlerFunc)
 }
 
 // Path function registers an HTTP route with the PATH method on the Quick server.
-// The result will Path(pattern string, handlerFunc HandleFunc)
-func (q *Quick) Patch(pattern string, handlerFunc HandleFunc) {
-    q.registerRoute(MethodPatch, pattern, handlerFunc)
+// The result will Path(pattern string, handlerFunc HandleFunc) *RouteDoc
+func (q *Quick) Patch(pattern string, handlerFunc HandleFunc) *RouteDoc {
+    return q.registerRoute(MethodPatch, pattern, handlerFunc)
 }
 
 // Options function registers an HTTP route with the Options method on the Quick server.
-// The result will Options(pattern string, handlerFunc HandleFunc)
-func (q *Quick) Options(pattern string, handlerFunc HandleFunc) {
-    q.registerRoute(MethodOptions, pattern, handlerFunc)
+// The result will Options(pattern string, handlerFunc HandleFunc) *RouteDoc
+func (q *Quick) Options(pattern string, handlerFunc HandleFunc) *RouteDoc {
+    return q.registerRoute(MethodOptions, pattern, handlerFunc)
+}
+
+// anyMethods lists the methods registered by Any
+var anyMethods = []string{MethodGet, MethodPost, MethodPut, MethodDelete, MethodPatch, MethodOptions}
+
+// Any registers the same handler for all standard HTTP methods on the Quick server.
+// The returned RouteDoc documents every method at once, e.g. q.Any("/ping", h).Tags("health")
+// The result will Any(pattern string, handlerFunc HandleFunc) *RouteDoc
+func (q *Quick) Any(pattern string, handlerFunc HandleFunc) *RouteDoc {
+    return q.Match(anyMethods, pattern, handlerFunc)
+}
+
+// Match registers the same handler for the given HTTP methods on the Quick server.
+// It panics if a method is not supported by the router. The returned RouteDoc
+// documents every method at once.
+// The result will Match(methods []string, pattern string, handlerFunc HandleFunc) *RouteDoc
+func (q *Quick) Match(methods []string, pattern string, handlerFunc HandleFunc) *RouteDoc {
+    var doc *RouteDoc
+    for _, method := range methods {
+        method = strings.ToUpper(method)
+        if extractHandler(q, method, "", "", handlerFunc) == nil {
+            panic("Match: unsupported method " + method)
+        }
+        doc = doc.join(q.registerRoute(method, pattern, handlerFunc))
+    }
+    if doc == nil {
+        return &RouteDoc{q: q}
+    }
+    return doc
+}
+
+// WrapHandler adapts a net/http handler to a HandleFunc, so existing handlers,
+// e.g. promhttp.Handler() or pprof.Index, can be registered on any route or
+// group. The route params are available to it through r.PathValue, and the
+// body of POST and PUT requests can still be read.
+//
+//	q.Get("/metrics", quick.WrapHandler(promhttp.Handler()))
+//
+// The result will WrapHandler(h http.Handler) HandleFunc
+func WrapHandler(h http.Handler) HandleFunc {
+    return func(c *Ctx) error {
+        for name, value := range c.ParamsMap() {
+            c.Request.SetPathValue(name, value)
+        }
+        h.ServeHTTP(c.Response, c.Request)
+        return nil
+    }
 }
 
 // Generic handler extractor to minimize repeated logic across HTTP methods
@@ -263,11 +455,12 @@ func extractParamsOptions(q *Quick, method, path string, handlerFunc HandleFunc)
 
         // If a handlerFunc exists, execute it
         if handlerFunc != nil {
-            err := handlerFunc(&Ctx{Response: w, Request: r})
-            if err != nil {
-                http.Error(w, err.Error(), http.StatusInternalServerError)
-                return
+            c := acquireCtx(w, r, q.config.MoreRequests)
+            defer releaseCtx(c)
+            if cval, ok := c.matched(); ok {
+                c.setParams(cval.ParamNames, cval.ParamValues)
             }
+            execHandleFunc(c, handlerFunc)
         } else {
             w.WriteHeader(http.StatusNoContent) // Use 204 if no body is needed
         }
@@ -293,10 +486,10 @@ func extractBind(c *Ctx, v interface{}) (err error) {
     if strings.ToLower(req.Header.Get("Content-Type")) == ContentTypeAppJSON ||
             strings.ToLower(req.Header.Get("Content-Type")) == "application/json; charset=utf-8" ||
             strings.ToLower(req.Header.Get("Content-Type")) == "application/json;charset=utf-8" {
-        err = json.NewDecoder(bytes.NewReader(c.bodyByte)).Decode(v)
+        err = json.NewDecoder(bytes.NewReader(c.loadBody())).Decode(v)
     } else if strings.ToLower(req.Header.Get("Content-Type")) == ContentTypeTextXML ||
             strings.ToLower(req.Header.Get("Content-Type")) == ContentTypeAppXML {
-        err = xml.NewDecoder(bytes.NewReader(c.bodyByte)).Decode(v)
+        err = xml.NewDecoder(bytes.NewReader(c.loadBody())).Decode(v)
     }
     return err
 }
@@ -332,22 +525,16 @@ func extractParamsGet(q *Quick, pathTmp, paramsPath string, handlerFunc HandleFu
             return
         }
 
-        cval := v.(ctxServeHttp)
-        querys := make(map[string]string)
+        cval := v.(*ctxServeHttp)
+        c := acquireCtx(w, req, q.config.MoreRequests)
+        defer releaseCtx(c)
+
         queryParams := req.URL.Query()
         for key, values := range queryParams {
-            querys[key] = values[0]
-        }
-        headersMap := extractHeaders(*req)
-
-        c := &Ctx{
-            Response:     w,
-            Request:      req,
-            Params:       cval.ParamsMap,
-            Query:        querys,
-            Headers:      headersMap,
-            MoreRequests: q.config.MoreRequests,
+            c.Query[key] = values[0]
         }
+        c.setParams(cval.ParamNames, cval.ParamValues)
+        c.Headers = extractHeaders(*req)
         execHandleFunc(c, handlerFunc)
     }
 }
@@ -357,9 +544,12 @@ func extractParamsGet(q *Quick, pathTmp, paramsPath string, handlerFunc HandleFu
 // The result will extractParamsPost(q *Quick, handlerFunc HandleFunc) http.HandlerFunc
 func extractParamsPost(q *Quick, handlerFunc HandleFunc) http.HandlerFunc {
     return func(w http.ResponseWriter, req *http.Request) {
+        c := acquireCtx(w, req, q.config.MoreRequests)
+        defer releaseCtx(c)
+
         // Check if body size exceeds limit before further validations
         if req.ContentLength > q.config.MaxBodySize {
-            http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+            q.bodyLimitExceeded(c)
             return
         }
 
@@ -369,19 +559,16 @@ func extractParamsPost(q *Quick, handlerFunc HandleFunc) http.HandlerFunc {
             return
         }
 
-        headersMap := extractHeaders(*req)
-        bodyBytes, bodyReader := extractBodyBytes(req.Body)
-
-        c := &Ctx{
-            Response:     w,
-            Request:      req,
-            bodyByte:     bodyBytes,
-            Headers:      headersMap,
-            MoreRequests: q.config.MoreRequests,
+        cval := v.(*ctxServeHttp)
+        c.Headers = extractHeaders(*req)
+        c.setParams(cval.ParamNames, cval.ParamValues)
+        if !q.checkExpectContinue(c) {
+            return
         }
 
-        // reset `Request.Body` with `bodyReader`
-        c.Request.Body = bodyReader
+        if !q.bufferBody(c) {
+            return
+        }
         execHandleFunc(c, handlerFunc)
     }
 }
@@ -391,8 +578,11 @@ func extractParamsPost(q *Quick, handlerFunc HandleFunc) http.HandlerFunc {
 // The result will extractParamsPut(q *Quick, handlerFunc HandleFunc) http.HandlerFunc
 func extractParamsPut(q *Quick, handlerFunc HandleFunc) http.HandlerFunc {
     return func(w http.ResponseWriter, req *http.Request) {
+        c := acquireCtx(w, req, q.config.MoreRequests)
+        defer releaseCtx(c)
+
         if req.ContentLength > q.config.MaxBodySize {
-            http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+            q.bodyLimitExceeded(c)
             return
         }
 
@@ -402,21 +592,16 @@ func extractParamsPut(q *Quick, handlerFunc HandleFunc) http.HandlerFunc {
             return
         }
 
-        headersMap := extractHeaders(*req)
-        cval := v.(ctxServeHttp)
-        bodyBytes, bodyReader := extractBodyBytes(req.Body)
-
-        c := &Ctx{
-            Response:     w,
-            Request:      req,
-            Headers:      headersMap,
-            bodyByte:     bodyBytes,
-            Params:       cval.ParamsMap,
-            MoreRequests: q.config.MoreRequests,
+        cval := v.(*ctxServeHttp)
+        c.Headers = extractHeaders(*req)
+        c.setParams(cval.ParamNames, cval.ParamValues)
+        if !q.checkExpectContinue(c) {
+            return
         }
 
-        // reset `Request.Body` with `bodyReader`
-        c.Request.Body = bodyReader
+        if !q.bufferBody(c) {
+            return
+        }
         execHandleFunc(c, handlerFunc)
     }
 }
@@ -432,30 +617,123 @@ func extractParamsDelete(q *Quick, handlerFunc HandleFunc) http.HandlerFunc {
             return
         }
 
-        headersMap := extractHeaders(*req)
-        cval := v.(ctxServeHttp)
+        cval := v.(*ctxServeHttp)
+        c := acquireCtx(w, req, q.config.MoreRequests)
+        defer releaseCtx(c)
 
-        c := &Ctx{
-            Response:     w,
-            Request:      req,
-            Headers:      headersMap,
-            Params:       cval.ParamsMap,
-            MoreRequests: q.config.MoreRequests,
-        }
+        c.Headers = extractHeaders(*req)
+        c.setParams(cval.ParamNames, cval.ParamValues)
         execHandleFunc(c, handlerFunc)
     }
 }
 
+// checkExpectContinue runs the ExpectContinue hook for requests carrying
+// "Expect: 100-continue" and answers 417 when the hook refuses the body
+// Method Used Internally
+// The result will checkExpectContinue(c *Ctx) bool
+func (q *Quick) checkExpectContinue(c *Ctx) bool {
+    if q.config.ExpectContinue == nil || !strings.EqualFold(c.Request.Header.Get("Expect"), "100-continue") {
+        return true
+    }
+    if q.config.ExpectContinue(c) {
+        return true
+    }
+    http.Error(c.Response, "Expectation Failed", http.StatusExpectationFailed)
+    return false
+}
+
 // execHandleFunc executes the provided handler function and handles errors if they occur
 // Method Used Internally
 // The result will execHandleFunc(c *Ctx, handleFunc HandleFunc)
 func execHandleFunc(c *Ctx, handleFunc HandleFunc) {
     err := handleFunc(c)
+    if err == nil {
+        // a nil return writes nothing more: only a status set without a
+        // body, e.g. c.Status(201), is still sent
+        c.writeStatus()
+        return
+    }
+    // malformed request bodies and invalid fields are the client's fault
+    if !isClientError(err) && c.Request != nil {
+        c.Logger().Error("handler error", "error", err)
+    }
+    // #nosec G104
+    c.HandleError(err)
+}
+
+// isClientError reports whether err is caused by the request input, a
+// malformed body or invalid fields, rather than by the server
+// Method Used Internally
+// The result will isClientError(err error) bool
+func isClientError(err error) bool {
+    if _, ok := AsBodyError(err); ok {
+        return true
+    }
+    _, ok := AsValidationError(err)
+    return ok
+}
+
+// isMultipartRequest reports whether the request body is multipart/*
+// Method Used Internally
+// The result will isMultipartRequest(req *http.Request) bool
+func isMultipartRequest(req *http.Request) bool {
+    return strings.HasPrefix(strings.ToLower(req.Header.Get("Content-Type")), "multipart/")
+}
+
+// bufferBody reads the request body into c.bodyByte, at most MaxBodySize
+// bytes. The limit counts the bytes read, so it also holds for chunked bodies,
+// which carry no Content-Length; past it the client gets 413 and false is
+// returned. Multipart bodies are left unread so they can be streamed, see
+// Ctx.MultipartReader. The limit still applies when they are buffered, e.g.
+// by Body or FormFile, failing the read with *http.MaxBytesError, which
+// HandleError answers with 413.
+// Method Used Internally
+// The result will bufferBody(c *Ctx) bool
+func (q *Quick) bufferBody(c *Ctx) bool {
+    req := c.Request
+    if req.Body == nil {
+        req.Body = http.NoBody
+    }
+    if isMultipartRequest(req) {
+        c.bodyDeferred = true
+        if q.config.MaxBodySize > 0 {
+            c.streamBody = req.Body
+            req.Body = http.MaxBytesReader(c.Response, req.Body, q.config.MaxBodySize)
+        }
+        return true
+    }
+    if q.config.MaxBodySize > 0 {
+        req.Body = http.MaxBytesReader(c.Response, req.Body, q.config.MaxBodySize)
+    }
+
+    b, err := io.ReadAll(req.Body)
+    var tooLarge *http.MaxBytesError
+    if errors.As(err, &tooLarge) {
+        q.bodyLimitExceeded(c)
+        return false
+    }
     if err != nil {
-        c.Set("Content-Type", "text/plain; charset=utf-8")
-        // #nosec G104
-        c.Status(500).SendString(err.Error())
+        b = nil
     }
+    c.bodyByte = b
+    req.Body = io.NopCloser(bytes.NewReader(b))
+    return true
+}
+
+// bodyLimitExceeded answers a request whose body is over MaxBodySize
+// through the OnBodyLimitExceeded hook, falling back to a plain 413 when
+// there is no hook or it fails
+// Method Used Internally
+// The result will bodyLimitExceeded(c *Ctx)
+func (q *Quick) bodyLimitExceeded(c *Ctx) {
+    if q.config.OnBodyLimitExceeded != nil {
+        err := q.config.OnBodyLimitExceeded(c)
+        if err == nil {
+            return
+        }
+        c.Logger().Error("body limit handler error", "error", err)
+    }
+    http.Error(c.Response, "Request body too large", http.StatusRequestEntityTooLarge)
 }
 
 // extractBodyBytes reads the request body and returns it as a byte slice
@@ -488,105 +766,194 @@ func (q *Quick) mwWrapper(handler http.Handler) http.Handler {
 }
 
 // appendRoute registers a new route in the Quick router and applies middlewares
+// It returns false when the route conflicts with one already registered
 // Method Used Internally
-// The result will appendRoute(route *Route)
-func (q *Quick) appendRoute(route *Route) {
-    route.handler = q.mwWrapper(route.handler).ServeHTTP
+// The result will appendRoute(route *Route) bool
+func (q *Quick) appendRoute(route *Route) bool {
+    handler := q.mwWrapper(route.handler)
+    if q.config.RequestTimeout > 0 {
+        handler = http.TimeoutHandler(dropInformational(handler), q.config.RequestTimeout, "Service Unavailable")
+    }
+    route.handler = handler.ServeHTTP
+    route.caller = callerSite()
+
+    patternUri := existingPattern(route)
+    maxParams := q.config.MaxRouteParams
+    if maxParams <= 0 {
+        maxParams = defaultMaxRouteParams
+    }
+    if n := countRouteParams(route.Host, patternUri); n > maxParams {
+        return q.routeError(fmt.Errorf("quick: route %s %s registered at %s has %d params, more than the limit of %d (Config.MaxRouteParams)",
+            route.Method, route.Host+patternUri, route.caller, n, maxParams))
+    }
+    if existing, ok := q.router.insert(route.Method, patternUri, route); !ok && existing != nil {
+        return q.routeError(fmt.Errorf("quick: route conflict: %s %s registered at %s conflicts with %s %s registered at %s",
+            route.Method, route.Host+patternUri, route.caller, existing.Method, existing.Host+existingPattern(existing), existing.caller))
+    }
+
     //q.routes = append(q.routes, *route)
     q.routes = append(q.routes, route)
+    return true
 }
 
-// ServeHTTP is the main HTTP request dispatcher for the Quick router
-// The result will ServeHTTP(w http.ResponseWriter, req *http.Request)
-func (q *Quick) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-    for i := 0; i < len(q.routes); i++ {
-        var requestURI = req.URL.Path
-        var patternUri = q.routes[i].Pattern
-
-        if q.routes[i].Method != req.Method {
-            continue
-        }
-
-        if len(patternUri) == 0 {
-            patternUri = q.routes[i].Path
-        }
-
-        paramsMap, isValid := createParamsAndValid(requestURI, patternUri)
+// routeError panics with a registration error, or records it for
+// ValidateRoutes when Config.RouteConflictError is enabled. It returns false
+// so appendRoute can return it directly.
+// Method Used Internally
+// The result will routeError(err error) bool
+func (q *Quick) routeError(err error) bool {
+    if !q.config.RouteConflictError {
+        panic(err.Error())
+    }
+    q.routeErrs = append(q.routeErrs, err)
+    return false
+}
 
-        if !isValid {
-            continue
+// countRouteParams counts the params of a host pattern and a path pattern
+// Method Used Internally
+// The result will countRouteParams(host, pattern string) int
+func countRouteParams(host, pattern string) int {
+    n := strings.Count(host, "{")
+    for _, seg := range splitPath(pattern) {
+        if strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "{") {
+            n++
         }
-
-        var c = ctxServeHttp{Path: requestURI, ParamsMap: paramsMap, Method: q.routes[i].Method}
-        req = req.WithContext(context.WithValue(req.Context(), myContextKey, c))
-        q.routes[i].handler(w, req)
-        return
     }
-    http.NotFound(w, req)
+    return n
 }
 
-// createParamsAndValid create params map and check if the request URI and pattern URI are valid
+// existingPattern returns the pattern used to match the route
 // Method Used Internally
-// The result will createParamsAndValid(reqURI, patternURI string) (map[string]string, bool)
-func createParamsAndValid(reqURI, patternURI string) (map[string]string, bool) {
-    params := make(map[string]string)
-    var builder strings.Builder
+// The result will existingPattern(route *Route) string
+func existingPattern(route *Route) string {
+    if len(route.Pattern) > 0 {
+        return route.Pattern
+    }
+    return route.Path
+}
 
-    reqURI = strings.TrimPrefix(reqURI, "/")
-    patternURI = strings.TrimPrefix(patternURI, "/")
+// ValidateRoutes returns the route errors recorded at registration when
+// Config.RouteConflictError is enabled, or nil if the route table is valid
+// The result will ValidateRoutes() error
+func (q *Quick) ValidateRoutes() error {
+    return errors.Join(q.routeErrs...)
+}
 
-    reqSplit := strings.Split(reqURI, "/")
-    patternSplit := strings.Split(patternURI, "/")
-    if len(reqSplit) != len(patternSplit) {
-        return nil, false
-    }
+// *Quick is an http.Handler, so it can be served by any http.Server
+var _ http.Handler = (*Quick)(nil)
 
-    for i, seg := range patternSplit {
-        reqSeg := reqSplit[i]
+// ServeHTTP is the main HTTP request dispatcher for the Quick router
+// Routes are looked up in a segment trie, so the cost does not grow with the number of routes
+// Through it *Quick is a plain http.Handler: it works with httptest.NewServer(q),
+// can be mounted under another router, e.g. mux.Handle("/api/", http.StripPrefix("/api", q)),
+// and wrapped by any func(http.Handler) http.Handler middleware.
+// The result will ServeHTTP(w http.ResponseWriter, req *http.Request)
+func (q *Quick) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+    start := time.Now()
+    // http.Server already answers 431 past MaxHeaderBytes; the check here
+    // covers apps mounted in other servers and QuickTest
+    if q.config.MaxHeaderBytes > 0 && headerSize(req.Header) > q.config.MaxHeaderBytes {
+        http.Error(w, "431 Request Header Fields Too Large", http.StatusRequestHeaderFieldsTooLarge)
+        return
+    }
 
-        switch {
-        // Ex: :id => paramName = "id"
-        case strings.HasPrefix(seg, ":"):
-            paramName := seg[1:]
-            if paramName == "" {
-                return nil, false
-            }
-            params[paramName] = reqSeg
-            builder.WriteString("/")
-            builder.WriteString(reqSeg)
-
-        // Ex: {id:[0-9]+}
-        case strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}"):
-            content := seg[1 : len(seg)-1]
-            parts := strings.SplitN(content, ":", 2)
-            // Check for name and regex
-            if len(parts) != 2 || parts[0] == "" {
-                return nil, false
+    // routing uses the escaped path so that %2F is not taken as a separator
+    escaped := req.URL.EscapedPath()
+    if q.config.CleanPath {
+        if clean := cleanPath(escaped); clean != escaped {
+            if q.config.RedirectCleanPath {
+                redirectCleanPath(w, req, clean)
+                return
             }
-            paramName, regexPattern := parts[0], parts[1]
+            // shallow copies, so the request of the caller is left untouched
+            u := *req.URL
+            u.Path, _ = url.PathUnescape(clean)
+            u.RawPath = clean
+            req = req.WithContext(req.Context())
+            req.URL = &u
+            escaped = clean
+        }
+    }
 
-            rgx, err := regexp.Compile("^" + regexPattern + "$")
-            if err != nil || !rgx.MatchString(reqSeg) {
-                return nil, false
-            }
-            params[paramName] = reqSeg
-            builder.WriteString("/")
-            builder.WriteString(reqSeg)
+    route, names, values := q.router.lookupHost(req.Method, req.Host, escaped)
+    if route == nil {
+        http.NotFound(w, req)
+        return
+    }
 
-        default:
-            if seg != reqSeg {
-                return nil, false
+    // the writer is wrapped before the middlewares, so the count is taken
+    // after any compression they apply
+    st := &requestState{sw: sizeWriter{ResponseWriter: w}}
+    st.cval = ctxServeHttp{Path: req.URL.Path, Pattern: existingPattern(route), ParamNames: names, ParamValues: values, Method: route.Method, Logger: q.Logger(), Proxies: q.proxies, Written: &st.sw, Start: start, ErrorHandler: q.config.ErrorHandler, Storage: q.config.Storage, MultipartMemory: q.config.MultipartMemoryLimit, Route: route, Locals: &st.locals, OnInvalidStatus: q.config.OnInvalidStatus}
+    req = req.WithContext(context.WithValue(req.Context(), myContextKey, &st.cval))
+    route.handler(&st.sw, req)
+}
+
+// DetachRequest returns a copy of r for work that outlives the request,
+// e.g. a cache refresh in a background goroutine. The copy is not
+// cancelled with r and gets its own route state: the params and a copy
+// of the Locals are kept, while the response tracking of the finished
+// request is left behind, so the two never share mutable state.
+// The result will DetachRequest(r *http.Request) *http.Request
+func DetachRequest(r *http.Request) *http.Request {
+    ctx := context.WithoutCancel(r.Context())
+    if cval, ok := ctx.Value(myContextKey).(*ctxServeHttp); ok {
+        st := &requestState{cval: *cval}
+        if cval.Locals != nil && cval.Locals.values != nil {
+            st.locals.values = make(map[string]interface{}, len(cval.Locals.values))
+            for k, v := range cval.Locals.values {
+                st.locals.values[k] = v
             }
-            builder.WriteString("/")
-            builder.WriteString(seg)
         }
+        st.cval.Locals = &st.locals
+        st.cval.Written = nil
+        st.cval.ParamNames = append([]string(nil), cval.ParamNames...)
+        st.cval.ParamValues = append([]string(nil), cval.ParamValues...)
+        ctx = context.WithValue(ctx, myContextKey, &st.cval)
     }
+    return r.Clone(ctx)
+}
 
-    //if "/"+reqURI != builder.String() {
-    //	return nil, false
-    //}
+// cleanPath collapses duplicate slashes and resolves "." and ".." segments,
+// keeping the trailing slash, e.g. /api//v1/../users/ => /api/users/
+// Method Used Internally
+// The result will cleanPath(p string) string
+func cleanPath(p string) string {
+    clean := path.Clean("/" + p)
+    if strings.HasSuffix(p, "/") && clean != "/" {
+        clean += "/"
+    }
+    return clean
+}
 
-    return params, true
+// redirectCleanPath redirects to the normalized escaped path, keeping the
+// query. 308 keeps the method and body of non GET requests.
+// Method Used Internally
+// The result will redirectCleanPath(w http.ResponseWriter, req *http.Request, clean string)
+func redirectCleanPath(w http.ResponseWriter, req *http.Request, clean string) {
+    status := http.StatusPermanentRedirect
+    if req.Method == http.MethodGet || req.Method == http.MethodHead {
+        status = http.StatusMovedPermanently
+    }
+    target := clean
+    if req.URL.RawQuery != "" {
+        target += "?" + req.URL.RawQuery
+    }
+    http.Redirect(w, req, target, status)
+}
+
+// headerSize returns the size of the header lines as sent on the wire,
+// "Key: value\r\n" for each value
+// Method Used Internally
+// The result will headerSize(h http.Header) int64
+func headerSize(h http.Header) int64 {
+    var n int64
+    for k, vs := range h {
+        for _, v := range vs {
+            n += int64(len(k) + len(v) + 4)
+        }
+    }
+    return n
 }
 
 // GetRoute returns all registered routes in the Quick framework
@@ -595,6 +962,50 @@ func (q *Quick) GetRoute() []*Route {
     return q.routes
 }
 
+// Route looks up a registered route by method and path. The path may be the
+// registered pattern (e.g. "/users/:id") or a concrete path (e.g. "/users/42")
+// The result will Route(method, path string) (*Route, bool)
+func (q *Quick) Route(method, path string) (*Route, bool) {
+    method = strings.ToUpper(method)
+    for _, route := range q.routes {
+        if route.Method != method {
+            continue
+        }
+        if route.Pattern == path || (len(route.Pattern) == 0 && route.Path == path) {
+            return route, true
+        }
+    }
+
+    route, _, _ := q.router.lookup(method, path)
+    return route, route != nil
+}
+
+// RoutesByMethod returns a copy of the routes registered for the given method
+// The result will RoutesByMethod(method string) []Route
+func (q *Quick) RoutesByMethod(method string) []Route {
+    method = strings.ToUpper(method)
+    var routes []Route
+    for _, route := range q.routes {
+        if route.Method == method {
+            routes = append(routes, *route)
+        }
+    }
+    return routes
+}
+
+// RoutesByVersion returns a copy of the routes registered with q.Version
+// for the given version, e.g. to document the v2 API
+// The result will RoutesByVersion(version string) []Route
+func (q *Quick) RoutesByVersion(version string) []Route {
+    var routes []Route
+    for _, route := range q.routes {
+        if route.Version == version {
+            routes = append(routes, *route)
+        }
+    }
+    return routes
+}
+
 // Static server files html, css, js etc
 // Embed.FS allows you to include files directly into
 // the binary during compilation, eliminating the need to load files
@@ -637,6 +1048,23 @@ func (q *Quick) corsHandler() http.Handler {
     return q.CorsSet(q)
 }
 
+// OnListen registers a callback invoked once the listener is bound, before
+// serving starts. It receives the real address, useful when listening on ":0".
+// The result will OnListen(fn func(addr string))
+func (q *Quick) OnListen(fn func(addr string)) {
+    q.onListen = append(q.onListen, fn)
+}
+
+// notifyListen logs the listening address and runs the OnListen callbacks in registration order
+// Method Used Internally
+// The result will notifyListen(addr string)
+func (q *Quick) notifyListen(addr string) {
+    q.Logger().Info("quick server listening", "addr", addr)
+    for _, fn := range q.onListen {
+        fn(addr)
+    }
+}
+
 // httpServer creates and returns an HTTP server instance configured with Quick.
 // Method Used Internally
 // The result will httpServer(addr string, handler ...http.Handler) *http.Server
@@ -657,12 +1085,17 @@ func (q *Quick) httpServer(addr string, handler ...http.Handler) *http.Server {
         WriteTimeout:      q.config.WriteTimeout,
         IdleTimeout:       q.config.IdleTimeout,
         ReadHeaderTimeout: q.config.ReadHeaderTimeout,
+        MaxHeaderBytes:    int(q.config.MaxHeaderBytes),
     }
 }
 
 // ListenWithShutdown starts the HTTP server and returns a shutdown function.
 // The result will ListenWithShutdown(addr string, handler ...http.Handler) (*http.Server, func(), error)
 func (q *Quick) ListenWithShutdown(addr string, handler ...http.Handler) (*http.Server, func(), error) {
+    if err := q.ValidateRoutes(); err != nil {
+        return nil, nil, err
+    }
+
     if q.config.MoreRequests > 0 {
         debug.SetGCPercent(q.config.MoreRequests)
     }
@@ -672,7 +1105,17 @@ func (q *Quick) ListenWithShutdown(addr string, handler ...http.Handler) (*http.
         return nil, nil, err
     }
 
+    server, shutdownFunc := q.serveInBackground(listener, handler...)
+    return server, shutdownFunc, nil
+}
+
+// serveInBackground serves on listener in a goroutine and returns the
+// server with a function that shuts it down gracefully
+// Method Used Internally
+// The result will serveInBackground(listener net.Listener, handler ...http.Handler) (*http.Server, func())
+func (q *Quick) serveInBackground(listener net.Listener, handler ...http.Handler) (*http.Server, func()) {
     server := q.httpServer(listener.Addr().String(), handler...)
+    q.notifyListen(listener.Addr().String())
     shutdownFunc := func() {
         ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
         defer cancel()
@@ -682,10 +1125,12 @@ func (q *Quick) ListenWithShutdown(addr string, handler ...http.Handler) (*http.
 
     // Servidor inicia em background
     go func() {
-        server.Serve(listener)
+        if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+            q.Logger().Error("quick server stopped", "addr", server.Addr, "error", err)
+        }
     }()
 
-    return server, shutdownFunc, nil
+    return server, shutdownFunc
 }
 
 // Listen calls ListenWithShutdown and blocks with select{}
@@ -701,9 +1146,160 @@ func (q *Quick) Listen(addr string, handler ...http.Handler) error {
     select {}
 }
 
+// Serve serves on a listener created by the caller, e.g. one passed by
+// systemd socket activation, and blocks until the server stops. It returns
+// nil after q.Shutdown. The listener is closed when Serve returns.
+// The result will Serve(listener net.Listener, handler ...http.Handler) error
+func (q *Quick) Serve(listener net.Listener, handler ...http.Handler) error {
+    if err := q.ValidateRoutes(); err != nil {
+        return err
+    }
+
+    if q.config.MoreRequests > 0 {
+        debug.SetGCPercent(q.config.MoreRequests)
+    }
+
+    q.server = q.httpServer(listener.Addr().String(), handler...)
+    q.notifyListen(listener.Addr().String())
+    if err := q.server.Serve(listener); err != http.ErrServerClosed {
+        return err
+    }
+    return nil
+}
+
+// ServeWithShutdown serves on a listener created by the caller in the
+// background and returns a shutdown function, like ListenWithShutdown
+// The result will ServeWithShutdown(listener net.Listener, handler ...http.Handler) (*http.Server, func(), error)
+func (q *Quick) ServeWithShutdown(listener net.Listener, handler ...http.Handler) (*http.Server, func(), error) {
+    if err := q.ValidateRoutes(); err != nil {
+        return nil, nil, err
+    }
+
+    if q.config.MoreRequests > 0 {
+        debug.SetGCPercent(q.config.MoreRequests)
+    }
+
+    server, shutdownFunc := q.serveInBackground(listener, handler...)
+    return server, shutdownFunc, nil
+}
+
+// ListenUnixWithShutdown starts the HTTP server on a unix domain socket, e.g.
+// for nginx in front of Quick, and returns a shutdown function that also
+// removes the socket file. A stale socket left by a previous run is removed
+// on startup; a socket another server is still accepting on, or a path that
+// is not a socket, is an error.
+// The result will ListenUnixWithShutdown(path string, handler ...http.Handler) (*http.Server, func(), error)
+func (q *Quick) ListenUnixWithShutdown(path string, handler ...http.Handler) (*http.Server, func(), error) {
+    if err := q.ValidateRoutes(); err != nil {
+        return nil, nil, err
+    }
+
+    if q.config.MoreRequests > 0 {
+        debug.SetGCPercent(q.config.MoreRequests)
+    }
+
+    if err := removeStaleSocket(path); err != nil {
+        return nil, nil, err
+    }
+    listener, err := net.Listen("unix", path)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    server, shutdown := q.serveInBackground(listener, handler...)
+    shutdownFunc := func() {
+        shutdown()
+        os.Remove(path)
+    }
+    return server, shutdownFunc, nil
+}
+
+// ListenUnix calls ListenUnixWithShutdown and blocks with select{}
+// The result will ListenUnix(path string, handler ...http.Handler) error
+func (q *Quick) ListenUnix(path string, handler ...http.Handler) error {
+    _, shutdown, err := q.ListenUnixWithShutdown(path, handler...)
+    if err != nil {
+        return err
+    }
+    defer shutdown()
+
+    // Locks indefinitely
+    select {}
+}
+
+// removeStaleSocket removes the socket file at path when no server is
+// accepting on it anymore
+// Method Used Internally
+// The result will removeStaleSocket(path string) error
+func removeStaleSocket(path string) error {
+    info, err := os.Lstat(path)
+    if errors.Is(err, os.ErrNotExist) {
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+    if info.Mode()&os.ModeSocket == 0 {
+        return fmt.Errorf("quick: %s exists and is not a unix socket", path)
+    }
+    if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+        conn.Close()
+        return fmt.Errorf("quick: %s is in use by another server", path)
+    }
+    return os.Remove(path)
+}
+
+// SignalContext returns a context that is cancelled when the process receives
+// SIGINT or SIGTERM, to be used with ListenWithContext
+// The result will SignalContext() (context.Context, context.CancelFunc)
+func SignalContext() (context.Context, context.CancelFunc) {
+    return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// ListenWithContext starts the HTTP server and serves until ctx is cancelled,
+// then gracefully shuts down, waiting up to 5 seconds for active requests to finish
+// The result will ListenWithContext(ctx context.Context, addr string, handler ...http.Handler) error
+func (q *Quick) ListenWithContext(ctx context.Context, addr string, handler ...http.Handler) error {
+    if err := q.ValidateRoutes(); err != nil {
+        return err
+    }
+
+    if q.config.MoreRequests > 0 {
+        debug.SetGCPercent(q.config.MoreRequests)
+    }
+
+    listener, err := net.Listen("tcp", addr)
+    if err != nil {
+        return err
+    }
+
+    server := q.httpServer(listener.Addr().String(), handler...)
+    q.notifyListen(listener.Addr().String())
+    errCh := make(chan error, 1)
+    go func() {
+        errCh <- server.Serve(listener)
+    }()
+
+    select {
+    case err := <-errCh:
+        if err == http.ErrServerClosed {
+            return nil
+        }
+        return err
+    case <-ctx.Done():
+        shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+        defer cancel()
+        return server.Shutdown(shutdownCtx)
+    }
+}
+
 // ListenTLS starts an HTTPS server with TLS support
 // The result will ListenTLS(addr, certFile, keyFile string, handler ...http.Handler) error
 func (q *Quick) ListenTLS(addr, certFile, keyFile string, handler ...http.Handler) error {
+    if err := q.ValidateRoutes(); err != nil {
+        return err
+    }
+
     if q.config.MoreRequests > 0 {
         debug.SetGCPercent(q.config.MoreRequests)
     }
@@ -714,6 +1310,7 @@ func (q *Quick) ListenTLS(addr, certFile, keyFile string, handler ...http.Handle
     }
 
     q.server = q.httpServer(listener.Addr().String(), handler...) // 🔧 Stores the server in the struct Quick
+    q.notifyListen(listener.Addr().String())
 
     shutdownFunc := func() {
         ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
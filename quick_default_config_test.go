@@ -5,6 +5,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -86,7 +87,7 @@ func TestDefaultConfig(t *testing.T) {
 		ReadHeaderTimeout: 0,
 	}
 
-	if defaultConfig != expectedConfig {
+	if !reflect.DeepEqual(defaultConfig, expectedConfig) {
 		t.Errorf("esperado %+v, mas obteve %+v", expectedConfig, defaultConfig)
 	}
 }
@@ -106,7 +107,7 @@ func TestQuickInitializationWithCustomConfig(t *testing.T) {
 
 	q := New(customConfig)
 
-	if q.config != customConfig {
+	if !reflect.DeepEqual(q.config, customConfig) {
 		t.Errorf("esperado %+v, mas obteve %+v", customConfig, q.config)
 	}
 }
@@ -1,13 +1,20 @@
 package quick
 
 import (
+    "bufio"
     "bytes"
+    "context"
     "crypto/tls"
     "errors"
+    "fmt"
     "io"
+    "log/slog"
+    "mime/multipart"
     "net"
     "net/http"
     "net/http/httptest"
+    "os"
+    "strings"
     "testing"
     "time"
 )
@@ -136,6 +143,22 @@ func TestExtractParamsOptions(t *testing.T) {
         }
     })
 
+    t.Run("Sends a status set without body", func(t *testing.T) {
+        q := New()
+        q.Options("/test", func(c *Ctx) error {
+            c.Status(http.StatusAccepted)
+            return nil
+        })
+
+        data, err := q.QuickTest(MethodOptions, "/test", nil)
+        if err != nil {
+            t.Fatalf("Unexpected error: %v", err)
+        }
+        if data.StatusCode() != http.StatusAccepted || data.BodyStr() != "" {
+            t.Errorf("Expected 202 without body, got %d %q", data.StatusCode(), data.BodyStr())
+        }
+    })
+
     t.Run("Handles empty handler function", func(t *testing.T) {
         q := New()
         req := httptest.NewRequest(http.MethodOptions, "/test", nil)
@@ -366,11 +389,11 @@ func TestHttpServer(t *testing.T) {
     })
 }
 
-// TestCreateParamsAndValid tests if regex parameters are correctly extracted from URLs
-// The result will TestCreateParamsAndValid(expected any) error
-func TestCreateParamsAndValid(t *testing.T) {
+// TestRouteParams tests if regex parameters are correctly extracted from URLs
+// The result will TestRouteParams(expected any) error
+func TestRouteParams(t *testing.T) {
     t.Run("Handles regex parameter", func(t *testing.T) {
-        params, valid := createParamsAndValid("/users/123", "/users/{id:[0-9]+}")
+        params, valid := routeParams("/users/123", "/users/{id:[0-9]+}")
         if !valid {
             t.Errorf("Expected valid match")
         }
@@ -439,12 +462,12 @@ func TestListen(t *testing.T) {
     })
 }
 
-// TestCreateParamsAndValid_RegexScenarios tests various regex matching cases for URL parameters
-// The result will TestCreateParamsAndValid_RegexScenarios(expected any) error
-func TestCreateParamsAndValid_RegexScenarios(t *testing.T) {
+// TestRouteParams_RegexScenarios tests various regex matching cases for URL parameters
+// The result will TestRouteParams_RegexScenarios(expected any) error
+func TestRouteParams_RegexScenarios(t *testing.T) {
     t.Run("Fails on non-matching numeric regex", func(t *testing.T) {
         // It should fail because 'abc' does not match [0-9]+
-        _, valid := createParamsAndValid("/users/abc", "/users/{id:[0-9]+}")
+        _, valid := routeParams("/users/abc", "/users/{id:[0-9]+}")
         if valid {
             t.Error("Expected invalid match for '/users/abc' with '/users/{id:[0-9]+}'")
         }
@@ -452,7 +475,7 @@ func TestCreateParamsAndValid_RegexScenarios(t *testing.T) {
 
     t.Run("Matches alphabetic regex", func(t *testing.T) {
         // {slug:[a-z]+} must match 'golang'
-        params, valid := createParamsAndValid("/profile/golang", "/profile/{slug:[a-z]+}")
+        params, valid := routeParams("/profile/golang", "/profile/{slug:[a-z]+}")
         if !valid {
             t.Error("Expected valid match for '/profile/golang' with '/profile/{slug:[a-z]+'")
         }
@@ -463,7 +486,7 @@ func TestCreateParamsAndValid_RegexScenarios(t *testing.T) {
 
     t.Run("Fails alphabetic regex if uppercase is present", func(t *testing.T) {
         // 'Golang' has a capital letter, it should not match [a-z]+
-        _, valid := createParamsAndValid("/profile/Golang", "/profile/{slug:[a-z]+}")
+        _, valid := routeParams("/profile/Golang", "/profile/{slug:[a-z]+}")
         if valid {
             t.Error("Expected invalid match, but it was valid")
         }
@@ -471,7 +494,7 @@ func TestCreateParamsAndValid_RegexScenarios(t *testing.T) {
 
     t.Run("Handles multiple regex segments", func(t *testing.T) {
         // Example: /api/v1/users/123 => /api/{version:v[0-9]+}/users/{id:[0-9]+}
-        params, valid := createParamsAndValid("/api/v1/users/123", "/api/{version:v[0-9]+}/users/{id:[0-9]+}")
+        params, valid := routeParams("/api/v1/users/123", "/api/{version:v[0-9]+}/users/{id:[0-9]+}")
         if !valid {
             t.Error("Expected valid match for multiple segments")
         }
@@ -486,7 +509,7 @@ func TestCreateParamsAndValid_RegexScenarios(t *testing.T) {
     t.Run("Handles empty param name but valid regex", func(t *testing.T) {
         // Example: /number/123 => /number/{:[0-9]+}
         // Note: paramName is empty before the ':', can we use it?
-        _, valid := createParamsAndValid("/number/123", "/number/{:[0-9]+}")
+        _, valid := routeParams("/number/123", "/number/{:[0-9]+}")
         // We expect failure because the logic requires name and regex
         if valid {
             t.Error("Expected invalid match because there's no param name before the colon")
@@ -545,11 +568,11 @@ func TestListen_ErrorCase(t *testing.T) {
     }
 }
 
-// TestCreateParamsAndValid_NoMatch tests if non-matching routes return nil and false
-// The result will TestCreateParamsAndValid_NoMatch(expected any) error
-func TestCreateParamsAndValid_NoMatch(t *testing.T) {
+// TestRouteParams_NoMatch tests if non-matching routes return nil and false
+// The result will TestRouteParams_NoMatch(expected any) error
+func TestRouteParams_NoMatch(t *testing.T) {
     t.Run("Returns nil and false for non-matching routes", func(t *testing.T) {
-        params, valid := createParamsAndValid("/wrong/path", "/expected/{id:[0-9]+}")
+        params, valid := routeParams("/wrong/path", "/expected/{id:[0-9]+}")
 
         if valid {
             t.Errorf("Expected valid to be false, but got true")
@@ -560,11 +583,11 @@ func TestCreateParamsAndValid_NoMatch(t *testing.T) {
     })
 }
 
-// TestCreateParamsAndValid_EmptyParamName tests if an empty param name returns nil and false
-// The result will TestCreateParamsAndValid_EmptyParamName(expected any) error
-func TestCreateParamsAndValid_EmptyParamName(t *testing.T) {
+// TestRouteParams_EmptyParamName tests if an empty param name returns nil and false
+// The result will TestRouteParams_EmptyParamName(expected any) error
+func TestRouteParams_EmptyParamName(t *testing.T) {
     t.Run("Returns nil and false for empty param name", func(t *testing.T) {
-        params, valid := createParamsAndValid("/users/123", "/users/:")
+        params, valid := routeParams("/users/123", "/users/:")
 
         if valid {
             t.Errorf("Expected valid to be false, but got true")
@@ -575,11 +598,11 @@ func TestCreateParamsAndValid_EmptyParamName(t *testing.T) {
     })
 }
 
-// TestCreateParamsAndValid_BuilderMismatch tests if mismatched reconstructed paths return nil and false
-// The result will TestCreateParamsAndValid_BuilderMismatch(expected any) error
-func TestCreateParamsAndValid_BuilderMismatch(t *testing.T) {
+// TestRouteParams_BuilderMismatch tests if mismatched reconstructed paths return nil and false
+// The result will TestRouteParams_BuilderMismatch(expected any) error
+func TestRouteParams_BuilderMismatch(t *testing.T) {
     t.Run("Returns nil and false if reconstructed path does not match", func(t *testing.T) {
-        params, valid := createParamsAndValid("/users/123", "/users/456")
+        params, valid := routeParams("/users/123", "/users/456")
 
         if valid {
             t.Errorf("Expected valid to be false, but got true")
@@ -590,11 +613,11 @@ func TestCreateParamsAndValid_BuilderMismatch(t *testing.T) {
     })
 }
 
-// TestCreateParamsAndValid_PathMismatch tests if mismatched paths return nil and false
-// The result will TestCreateParamsAndValid_PathMismatch(expected any) error
-func TestCreateParamsAndValid_PathMismatch(t *testing.T) {
+// TestRouteParams_PathMismatch tests if mismatched paths return nil and false
+// The result will TestRouteParams_PathMismatch(expected any) error
+func TestRouteParams_PathMismatch(t *testing.T) {
     t.Run("Returns nil and false when the reconstructed path does not match the request URI", func(t *testing.T) {
-        params, valid := createParamsAndValid("/users/123/profile", "/users/:id/settings")
+        params, valid := routeParams("/users/123/profile", "/users/:id/settings")
 
         if valid {
             t.Errorf("Expected valid to be false, but got true")
@@ -673,3 +696,1036 @@ func TestListenTLS(t *testing.T) {
     // Shut down the server at the end of the test
     _ = q.Shutdown()
 }
+
+// TestQuickAnyAndMatch test if Any and Match register one route per method
+// The result will TestQuickAnyAndMatch(expected any) error
+func TestQuickAnyAndMatch(t *testing.T) {
+    t.Run("Any reaches every method", func(t *testing.T) {
+        q := New()
+        q.Any("/ping", func(c *Ctx) error {
+            return c.Status(200).SendString("pong " + c.Request.Method)
+        })
+
+        if len(q.GetRoute()) != len(anyMethods) {
+            t.Fatalf("Expected %d routes, got %d", len(anyMethods), len(q.GetRoute()))
+        }
+        for _, method := range []string{MethodGet, MethodPost, MethodPut, MethodDelete, MethodPatch} {
+            data, err := q.QuickTest(method, "/ping", nil)
+            if err != nil {
+                t.Fatalf("Unexpected error: %v", err)
+            }
+            if data.BodyStr() != "pong "+method {
+                t.Errorf("Expected 'pong %s', got '%s'", method, data.BodyStr())
+            }
+        }
+    })
+
+    t.Run("Match registers only the given methods", func(t *testing.T) {
+        q := New()
+        q.Match([]string{"get", MethodPost}, "/users/:id", func(c *Ctx) error {
+            return c.Status(200).SendString(c.Request.Method)
+        })
+
+        routes := q.GetRoute()
+        if len(routes) != 2 || routes[0].Method != MethodGet || routes[1].Method != MethodPost {
+            t.Fatalf("Expected GET and POST routes, got %+v", routes)
+        }
+        data, _ := q.QuickTest(MethodPut, "/users/1", nil)
+        if data.StatusCode() != 404 {
+            t.Errorf("Expected 404 for PUT, got %d", data.StatusCode())
+        }
+    })
+
+    t.Run("Any documents every method", func(t *testing.T) {
+        q := New()
+        q.Any("/ping", func(c *Ctx) error { return nil }).Summary("Ping").Tags("health")
+
+        for _, route := range q.GetRoute() {
+            if route.Summary != "Ping" || len(route.Tags) != 1 || route.Tags[0] != "health" {
+                t.Errorf("Expected %s /ping to be documented, got %q %v", route.Method, route.Summary, route.Tags)
+            }
+        }
+    })
+
+    t.Run("Group Match registers and documents the given methods", func(t *testing.T) {
+        q := New()
+        g := q.Group("/v1")
+        g.Match([]string{"get", MethodPut}, "/items/:id", func(c *Ctx) error {
+            return c.Status(200).SendString(c.Request.Method + " " + c.Param("id"))
+        }).Requires("admin")
+
+        routes := q.GetRoute()
+        if len(routes) != 2 {
+            t.Fatalf("Expected 2 routes, got %d", len(routes))
+        }
+        for _, route := range routes {
+            if len(route.Requires) != 1 || route.Requires[0] != "admin" {
+                t.Errorf("Expected %s to require admin, got %v", route.Method, route.Requires)
+            }
+        }
+        for _, method := range []string{MethodGet, MethodPut} {
+            data, err := q.QuickTest(method, "/v1/items/7", nil)
+            if err != nil {
+                t.Fatalf("Unexpected error: %v", err)
+            }
+            if data.BodyStr() != method+" 7" {
+                t.Errorf("Expected '%s 7', got '%s'", method, data.BodyStr())
+            }
+        }
+    })
+
+    t.Run("Group Any reaches every method", func(t *testing.T) {
+        q := New()
+        q.Group("/api").Any("/ping", func(c *Ctx) error {
+            return c.Status(200).SendString("pong")
+        })
+        if len(q.GetRoute()) != len(anyMethods) {
+            t.Fatalf("Expected %d routes, got %d", len(anyMethods), len(q.GetRoute()))
+        }
+        data, _ := q.QuickTest(MethodDelete, "/api/ping", nil)
+        if data.BodyStr() != "pong" {
+            t.Errorf("Expected 'pong', got '%s'", data.BodyStr())
+        }
+    })
+
+    t.Run("Match panics on unsupported method", func(t *testing.T) {
+        defer func() {
+            if recover() == nil {
+                t.Errorf("Expected panic for unsupported method")
+            }
+        }()
+        New().Match([]string{"BREW"}, "/coffee", func(c *Ctx) error { return nil })
+    })
+}
+
+// sendExpectContinue writes the headers of a request with "Expect: 100-continue"
+// over a raw connection and returns the first status line received
+func sendExpectContinue(t *testing.T, addr, path string, contentLength int) (net.Conn, *bufio.Reader, string) {
+    conn, err := net.Dial("tcp", addr)
+    if err != nil {
+        t.Fatalf("Failed to dial: %v", err)
+    }
+    fmt.Fprintf(conn, "POST %s HTTP/1.1\r\nHost: %s\r\nContent-Type: text/plain\r\nContent-Length: %d\r\nExpect: 100-continue\r\n\r\n", path, addr, contentLength)
+    conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+    reader := bufio.NewReader(conn)
+    line, err := reader.ReadString('\n')
+    if err != nil {
+        t.Fatalf("Failed to read status line: %v", err)
+    }
+    return conn, reader, strings.TrimSpace(line)
+}
+
+// TestQuickExpectContinue test the "Expect: 100-continue" flow over a real connection
+// The result will TestQuickExpectContinue(expected any) error
+func TestQuickExpectContinue(t *testing.T) {
+    cfg := GetDefaultConfig()
+    cfg.MaxBodySize = 10
+    cfg.ExpectContinue = func(c *Ctx) bool {
+        return c.Request.Header.Get("Content-Type") == "text/plain" && c.Request.URL.Path != "/refuse"
+    }
+    q := New(cfg)
+    q.Post("/upload", func(c *Ctx) error {
+        return c.Status(200).SendString("got " + c.BodyString())
+    })
+    q.Post("/refuse", func(c *Ctx) error {
+        return c.Status(200).SendString("unreachable")
+    })
+
+    server := httptest.NewServer(q)
+    defer server.Close()
+    addr := strings.TrimPrefix(server.URL, "http://")
+
+    t.Run("Accepted body receives 100 Continue", func(t *testing.T) {
+        conn, reader, line := sendExpectContinue(t, addr, "/upload", 5)
+        defer conn.Close()
+        if line != "HTTP/1.1 100 Continue" {
+            t.Fatalf("Expected 100 Continue, got %q", line)
+        }
+        reader.ReadString('\n') // blank line after the interim response
+        conn.Write([]byte("hello"))
+
+        resp, err := http.ReadResponse(reader, nil)
+        if err != nil {
+            t.Fatalf("Failed to read response: %v", err)
+        }
+        body, _ := io.ReadAll(resp.Body)
+        if resp.StatusCode != 200 || string(body) != "got hello" {
+            t.Errorf("Expected 200 'got hello', got %d %q", resp.StatusCode, body)
+        }
+    })
+
+    t.Run("Oversized body is rejected without 100 Continue", func(t *testing.T) {
+        conn, _, line := sendExpectContinue(t, addr, "/upload", 1024)
+        defer conn.Close()
+        if line != "HTTP/1.1 413 Request Entity Too Large" {
+            t.Errorf("Expected 413, got %q", line)
+        }
+    })
+
+    t.Run("Refused by hook answers 417", func(t *testing.T) {
+        conn, _, line := sendExpectContinue(t, addr, "/refuse", 5)
+        defer conn.Close()
+        if line != "HTTP/1.1 417 Expectation Failed" {
+            t.Errorf("Expected 417, got %q", line)
+        }
+    })
+}
+
+// TestQuickListenWithContext test if the server drains active requests when the context is cancelled
+// The result will TestQuickListenWithContext(expected any) error
+func TestQuickListenWithContext(t *testing.T) {
+    t.Run("Graceful drain on cancel", func(t *testing.T) {
+        l, err := net.Listen("tcp", "127.0.0.1:0")
+        if err != nil {
+            t.Fatalf("Failed to reserve port: %v", err)
+        }
+        addr := l.Addr().String()
+        l.Close()
+
+        q := New()
+        started := make(chan struct{})
+        q.Get("/slow", func(c *Ctx) error {
+            close(started)
+            time.Sleep(100 * time.Millisecond)
+            return c.Status(200).SendString("drained")
+        })
+
+        ctx, cancel := context.WithCancel(context.Background())
+        done := make(chan error, 1)
+        go func() { done <- q.ListenWithContext(ctx, addr) }()
+
+        var resp *http.Response
+        respErr := make(chan error, 1)
+        go func() {
+            for i := 0; i < 50; i++ {
+                resp, err = http.Get("http://" + addr + "/slow")
+                if err == nil {
+                    break
+                }
+                time.Sleep(10 * time.Millisecond)
+            }
+            respErr <- err
+        }()
+
+        <-started
+        cancel()
+
+        if err := <-respErr; err != nil {
+            t.Fatalf("Request failed: %v", err)
+        }
+        body, _ := io.ReadAll(resp.Body)
+        resp.Body.Close()
+        if string(body) != "drained" {
+            t.Errorf("Expected 'drained', got %q", body)
+        }
+        if err := <-done; err != nil {
+            t.Errorf("Expected nil error after shutdown, got %v", err)
+        }
+    })
+
+    t.Run("Returns listen error", func(t *testing.T) {
+        if err := New().ListenWithContext(context.Background(), "invalid:address:99"); err == nil {
+            t.Errorf("Expected error for invalid address")
+        }
+    })
+}
+
+// TestQuickOnListen test if OnListen callbacks receive the bound address before serving
+// The result will TestQuickOnListen(expected any) error
+func TestQuickOnListen(t *testing.T) {
+    q := New()
+    q.Get("/ping", func(c *Ctx) error { return c.Status(200).SendString("pong") })
+
+    var got []string
+    q.OnListen(func(addr string) { got = append(got, "first "+addr) })
+    q.OnListen(func(addr string) { got = append(got, "second "+addr) })
+
+    server, shutdown, err := q.ListenWithShutdown("127.0.0.1:0")
+    if err != nil {
+        t.Fatalf("Unexpected error: %v", err)
+    }
+    defer shutdown()
+
+    if len(got) != 2 || got[0] != "first "+server.Addr || got[1] != "second "+server.Addr {
+        t.Fatalf("Expected callbacks in order with %s, got %v", server.Addr, got)
+    }
+    if strings.HasSuffix(server.Addr, ":0") {
+        t.Errorf("Expected the dynamic port, got %s", server.Addr)
+    }
+
+    resp, err := http.Get("http://" + server.Addr + "/ping")
+    if err != nil {
+        t.Fatalf("Request failed: %v", err)
+    }
+    resp.Body.Close()
+}
+
+// TestQuickRouteLookup test if routes can be found by method and pattern or concrete path
+// The result will TestQuickRouteLookup(expected any) error
+func TestQuickRouteLookup(t *testing.T) {
+    q := New()
+    handler := func(c *Ctx) error { return nil }
+    q.Post("/users", handler)
+    q.Get("/users/:id", handler)
+    q.Get("/health", handler)
+
+    tests := []struct {
+        name    string
+        method  string
+        path    string
+        found   bool
+        pattern string
+    }{
+        {"Static pattern", "post", "/users", true, "/users"},
+        {"Param pattern", MethodGet, "/users/:id", true, "/users/:id"},
+        {"Concrete path", MethodGet, "/users/42", true, "/users/:id"},
+        {"Wrong method", MethodDelete, "/users", false, ""},
+        {"Unknown path", MethodGet, "/nope", false, ""},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            route, ok := q.Route(tt.method, tt.path)
+            if ok != tt.found {
+                t.Fatalf("Expected found=%v, got %v", tt.found, ok)
+            }
+            if !ok {
+                return
+            }
+            pattern := route.Pattern
+            if pattern == "" {
+                pattern = route.Path
+            }
+            if pattern != tt.pattern {
+                t.Errorf("Expected pattern %s, got %s", tt.pattern, pattern)
+            }
+        })
+    }
+
+    t.Run("RoutesByMethod", func(t *testing.T) {
+        if got := q.RoutesByMethod("get"); len(got) != 2 {
+            t.Errorf("Expected 2 GET routes, got %d", len(got))
+        }
+        if got := q.RoutesByMethod(MethodPut); len(got) != 0 {
+            t.Errorf("Expected no PUT routes, got %d", len(got))
+        }
+    })
+}
+
+// TestQuickRouteConflict test if duplicate routes are reported with both call sites
+// The result will TestQuickRouteConflict(expected any) error
+func TestQuickRouteConflict(t *testing.T) {
+    handler := func(c *Ctx) error { return nil }
+
+    t.Run("Panics by default", func(t *testing.T) {
+        defer func() {
+            r := recover()
+            msg, _ := r.(string)
+            if !strings.Contains(msg, "route conflict: GET /x") || strings.Count(msg, "quick_test.go:") != 2 {
+                t.Errorf("Expected conflict panic naming both call sites, got %v", r)
+            }
+        }()
+        q := New()
+        q.Get("/x", handler)
+        q.Get("/x", handler)
+    })
+
+    t.Run("Same shape with different param names", func(t *testing.T) {
+        defer func() {
+            if recover() == nil {
+                t.Errorf("Expected conflict panic")
+            }
+        }()
+        q := New()
+        q.Get("/users/:id", handler)
+        q.Group("/users").Get("/:name", handler)
+    })
+
+    t.Run("Recorded as error when configured", func(t *testing.T) {
+        cfg := GetDefaultConfig()
+        cfg.RouteConflictError = true
+        q := New(cfg)
+        q.Get("/x", handler)
+        q.Get("/x", handler)
+        q.Post("/x", handler)
+
+        if len(q.GetRoute()) != 2 {
+            t.Errorf("Expected the duplicate to be skipped, got %d routes", len(q.GetRoute()))
+        }
+        err := q.ValidateRoutes()
+        if err == nil || !strings.Contains(err.Error(), "route conflict: GET /x") {
+            t.Fatalf("Expected conflict error, got %v", err)
+        }
+        if _, _, listenErr := q.ListenWithShutdown("127.0.0.1:0"); listenErr == nil {
+            t.Errorf("Expected Listen to fail with the conflict error")
+        }
+    })
+}
+
+// TestQuickConfigLogger test if startup messages and handler errors go to Config.Logger
+// The result will TestQuickConfigLogger(expected any) error
+func TestQuickConfigLogger(t *testing.T) {
+    var buf bytes.Buffer
+    q := New(Config{Logger: slog.New(slog.NewJSONHandler(&buf, nil))})
+    if q.Logger() == nil {
+        t.Fatal("Expected the configured logger")
+    }
+    q.Get("/fail", func(c *Ctx) error {
+        return errors.New("boom")
+    })
+
+    server, shutdown, err := q.ListenWithShutdown("127.0.0.1:0")
+    if err != nil {
+        t.Fatalf("Failed to start server: %v", err)
+    }
+    shutdown()
+
+    q.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+    lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+    if len(lines) != 2 {
+        t.Fatalf("Expected 2 JSON records, got %q", buf.String())
+    }
+    want := []string{`"msg":"quick server listening","addr":"` + server.Addr + `"`, `"msg":"handler error"`}
+    for i, w := range want {
+        if !strings.Contains(lines[i], w) {
+            t.Errorf("Expected record %d to contain %s, got %s", i, w, lines[i])
+        }
+    }
+    if !strings.Contains(lines[1], `"error":"boom"`) {
+        t.Errorf("Expected the handler error in the record, got %s", lines[1])
+    }
+
+    if (&Ctx{}).Logger() != slog.Default() {
+        t.Error("Expected slog.Default outside a route")
+    }
+}
+
+// TestQuickConfigRequestTimeout test if Config.RequestTimeout cuts off hung handlers with 503
+// The result will TestQuickConfigRequestTimeout(expected any) error
+func TestQuickConfigRequestTimeout(t *testing.T) {
+    q := New(Config{RequestTimeout: 50 * time.Millisecond})
+    observed := make(chan error, 1)
+    q.Get("/hung", func(c *Ctx) error {
+        <-c.Context().Done()
+        observed <- c.Context().Err()
+        return nil
+    })
+    q.Get("/fast", func(c *Ctx) error {
+        return c.Status(StatusOK).SendString("ok")
+    })
+
+    start := time.Now()
+    data, err := q.QuickTest(MethodGet, "/hung", nil)
+    if err != nil {
+        t.Fatalf("Unexpected error: %v", err)
+    }
+    if data.StatusCode() != StatusServiceUnavailable {
+        t.Errorf("Expected 503, got %d", data.StatusCode())
+    }
+    if elapsed := time.Since(start); elapsed > time.Second {
+        t.Errorf("Expected the handler to be cut off, took %v", elapsed)
+    }
+    if err := <-observed; !errors.Is(err, context.DeadlineExceeded) {
+        t.Errorf("Expected the handler to observe the deadline, got %v", err)
+    }
+
+    data, err = q.QuickTest(MethodGet, "/fast", nil)
+    if err != nil || data.StatusCode() != StatusOK || data.BodyStr() != "ok" {
+        t.Errorf("Expected 200 ok, got %v %v", data, err)
+    }
+}
+
+// TestQuickRequestTimeoutDeadline test if c.Context carries the deadline of Config.RequestTimeout
+// The result will TestQuickRequestTimeoutDeadline(expected any) error
+func TestQuickRequestTimeoutDeadline(t *testing.T) {
+    q := New(Config{RequestTimeout: 50 * time.Millisecond})
+    observed := make(chan error, 1)
+    q.Get("/query", func(c *Ctx) error {
+        deadline, ok := c.Context().Deadline()
+        if !ok || time.Until(deadline) > 50*time.Millisecond {
+            t.Errorf("Expected a deadline within 50ms, got %v %v", deadline, ok)
+        }
+        // stands in for db.QueryContext(c.Context(), ...)
+        select {
+        case <-c.Context().Done():
+            observed <- c.Context().Err()
+        case <-time.After(time.Second):
+            observed <- nil
+        }
+        return nil
+    })
+
+    q.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(MethodGet, "/query", nil))
+    if err := <-observed; !errors.Is(err, context.DeadlineExceeded) {
+        t.Errorf("Expected the query to be cancelled by the deadline, got %v", err)
+    }
+}
+
+// TestQuickCleanPath test if Config.CleanPath routes and redirects non canonical paths
+// The result will TestQuickCleanPath(expected any) error
+func TestQuickCleanPath(t *testing.T) {
+    handler := func(c *Ctx) error {
+        return c.Status(StatusOK).SendString(c.Path() + " " + c.Param("id"))
+    }
+    serve := func(q *Quick, method, target string) *httptest.ResponseRecorder {
+        rec := httptest.NewRecorder()
+        q.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+        return rec
+    }
+
+    q := New(Config{CleanPath: true})
+    q.Get("/api/users/:id", handler)
+    q.Get("/api/docs/", handler)
+    tests := []struct {
+        target string
+        body   string
+    }{
+        {"/api//users/7", "/api/users/7 7"},
+        {"//api/users//7", "/api/users/7 7"},
+        {"/api/./users/7", "/api/users/7 7"},
+        {"/api/v1/../users/7", "/api/users/7 7"},
+        {"/../api/users/7", "/api/users/7 7"},
+        {"/api//docs//", "/api/docs/ "},
+        {"/api//users/a%2Fb", "/api/users/a/b a/b"},
+    }
+    for _, tt := range tests {
+        if rec := serve(q, MethodGet, tt.target); rec.Code != StatusOK || rec.Body.String() != tt.body {
+            t.Errorf("%s: expected 200 %q, got %d %q", tt.target, tt.body, rec.Code, rec.Body.String())
+        }
+    }
+
+    if rec := serve(New(), MethodGet, "/api//users/7"); rec.Code != StatusNotFound {
+        t.Errorf("Expected 404 without CleanPath, got %d", rec.Code)
+    }
+
+    q = New(Config{CleanPath: true, RedirectCleanPath: true})
+    q.Get("/api/users/:id", handler)
+    q.Post("/api/users/:id", handler)
+    rec := serve(q, MethodGet, "/api//users/7?fields=name")
+    if rec.Code != StatusMovedPermanently || rec.Header().Get("Location") != "/api/users/7?fields=name" {
+        t.Errorf("Expected 301 to the clean path, got %d %q", rec.Code, rec.Header().Get("Location"))
+    }
+    rec = serve(q, MethodPost, "/api/./users/7")
+    if rec.Code != StatusPermanentRedirect || rec.Header().Get("Location") != "/api/users/7" {
+        t.Errorf("Expected 308 to the clean path, got %d %q", rec.Code, rec.Header().Get("Location"))
+    }
+    if rec = serve(q, MethodGet, "/api/users/7"); rec.Code != StatusOK {
+        t.Errorf("Expected 200 for the clean path, got %d", rec.Code)
+    }
+}
+
+// TestQuickWrapHandler test if net/http handlers run on Quick routes through WrapHandler
+// The result will TestQuickWrapHandler(expected any) error
+func TestQuickWrapHandler(t *testing.T) {
+    std := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        body, _ := io.ReadAll(r.Body)
+        w.Header().Set("X-Std", "1")
+        w.WriteHeader(http.StatusAccepted)
+        fmt.Fprintf(w, "%s %s %s", r.Method, r.PathValue("id"), body)
+    })
+
+    q := New(Config{MaxBodySize: 1024})
+    q.Get("/users/:id", WrapHandler(std))
+    q.Post("/users/:id", WrapHandler(std))
+    q.Group("/v1").Get("/health", WrapHandler(http.NotFoundHandler()))
+
+    tests := []struct {
+        method, path, body string
+        status             int
+        want               string
+    }{
+        {MethodGet, "/users/42", "", StatusAccepted, "GET 42 "},
+        {MethodPost, "/users/7", "payload", StatusAccepted, "POST 7 payload"},
+        {MethodGet, "/v1/health", "", StatusNotFound, "404 page not found\n"},
+    }
+    for _, tt := range tests {
+        rec := httptest.NewRecorder()
+        q.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+        if rec.Code != tt.status || rec.Body.String() != tt.want {
+            t.Errorf("%s %s: expected %d %q, got %d %q", tt.method, tt.path, tt.status, tt.want, rec.Code, rec.Body.String())
+        }
+    }
+}
+
+// TestQuickAsHandler test if Quick works as an http.Handler mounted under another mux
+// The result will TestQuickAsHandler(expected any) error
+func TestQuickAsHandler(t *testing.T) {
+    q := New()
+    q.Get("/users/:id", func(c *Ctx) error {
+        return c.Status(StatusOK).SendString(c.Path() + " " + c.Param("id") + " " + c.Request.Header.Get("X-Outer"))
+    })
+
+    // a middleware from another ecosystem wraps the whole app
+    outer := func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            r.Header.Set("X-Outer", "yes")
+            next.ServeHTTP(w, r)
+        })
+    }
+    mux := http.NewServeMux()
+    mux.Handle("/api/", http.StripPrefix("/api", outer(q)))
+    mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte("pong"))
+    })
+    ts := httptest.NewServer(mux)
+    defer ts.Close()
+
+    tests := []struct {
+        path   string
+        status int
+        want   string
+    }{
+        {"/api/users/42", StatusOK, "/users/42 42 yes"},
+        {"/api/users/a%2Fb", StatusOK, "/users/a/b a/b yes"},
+        {"/ping", StatusOK, "pong"},
+        {"/api/missing", StatusNotFound, "404 page not found\n"},
+    }
+    for _, tt := range tests {
+        resp, err := http.Get(ts.URL + tt.path)
+        if err != nil {
+            t.Fatal(err)
+        }
+        body, _ := io.ReadAll(resp.Body)
+        resp.Body.Close()
+        if resp.StatusCode != tt.status || string(body) != tt.want {
+            t.Errorf("%s: expected %d %q, got %d %q", tt.path, tt.status, tt.want, resp.StatusCode, body)
+        }
+    }
+}
+
+// traceHandler is a middleware returned as a plain http.Handler, not an http.HandlerFunc
+type traceHandler struct {
+    name  string
+    trace *[]string
+    next  http.Handler
+}
+
+func (h traceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    *h.trace = append(*h.trace, h.name)
+    h.next.ServeHTTP(w, r)
+}
+
+// TestQuickMiddlewareOrder test if middlewares run in registration order, global before group
+// The result will TestQuickMiddlewareOrder(expected any) error
+func TestQuickMiddlewareOrder(t *testing.T) {
+    var trace []string
+    mw := func(name string) func(http.Handler) http.Handler {
+        return func(next http.Handler) http.Handler {
+            return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                trace = append(trace, name+" in")
+                next.ServeHTTP(w, r)
+                trace = append(trace, name+" out")
+            })
+        }
+    }
+
+    q := New()
+    q.Use(mw("global1"))
+    q.Use(mw("global2"))
+    q.Use(func(w http.ResponseWriter, r *http.Request, next http.Handler) {
+        trace = append(trace, "global3")
+        next.ServeHTTP(w, r)
+    })
+
+    g := q.Group("/v1")
+    g.Use(mw("group1"))
+    g.Use(func(next http.Handler) http.Handler {
+        return traceHandler{name: "group2", trace: &trace, next: next}
+    })
+    g.Get("/users", func(c *Ctx) error {
+        trace = append(trace, "handler")
+        return c.Status(StatusOK).SendString("ok")
+    })
+    q.Get("/health", func(c *Ctx) error {
+        trace = append(trace, "handler")
+        return c.Status(StatusOK).SendString("ok")
+    })
+
+    tests := []struct {
+        uri  string
+        want []string
+    }{
+        {"/v1/users", []string{"global1 in", "global2 in", "global3", "group1 in", "group2", "handler", "group1 out", "global2 out", "global1 out"}},
+        {"/health", []string{"global1 in", "global2 in", "global3", "handler", "global2 out", "global1 out"}},
+    }
+    for _, tt := range tests {
+        trace = nil
+        data, err := q.QuickTest(MethodGet, tt.uri, nil)
+        if err != nil || data.StatusCode() != StatusOK {
+            t.Fatalf("Expected 200 for %s, got %v %v", tt.uri, data, err)
+        }
+        if strings.Join(trace, ",") != strings.Join(tt.want, ",") {
+            t.Errorf("%s: expected order %v, got %v", tt.uri, tt.want, trace)
+        }
+    }
+}
+
+// TestQuickMaxRouteParams test if routes with too many params are rejected at registration
+// The result will TestQuickMaxRouteParams(expected any) error
+func TestQuickMaxRouteParams(t *testing.T) {
+    pattern := func(n int) string {
+        var b strings.Builder
+        for i := 0; i < n; i++ {
+            fmt.Fprintf(&b, "/:p%d", i)
+        }
+        return b.String()
+    }
+    handler := func(c *Ctx) error { return c.SendString(c.Param("p31")) }
+
+    t.Run("default limit panics", func(t *testing.T) {
+        q := New()
+        q.Get(pattern(32), handler)
+        defer func() {
+            r := recover()
+            if r == nil || !strings.Contains(fmt.Sprint(r), "has 50 params, more than the limit of 32") {
+                t.Errorf("Expected a clear rejection, got %v", r)
+            }
+        }()
+        q.Get("/many"+pattern(50), handler)
+    })
+
+    t.Run("custom limit recorded as route error", func(t *testing.T) {
+        q := New(Config{MaxRouteParams: 2, RouteConflictError: true})
+        q.Get("/a/:x/:y", handler)
+        q.Group("/t").Host("{tenant}.example.com").Get("/:x/:y", handler)
+        err := q.ValidateRoutes()
+        if err == nil || !strings.Contains(err.Error(), "{tenant}.example.com/t/:x/:y") || !strings.Contains(err.Error(), "has 3 params") {
+            t.Fatalf("Expected the host route to be rejected, got %v", err)
+        }
+        if _, ok := q.Route(MethodGet, "/a/:x/:y"); !ok {
+            t.Error("Expected the route within the limit to be registered")
+        }
+    })
+}
+
+// TestQuickMaxHeaderBytes test if oversized request headers are answered with 431
+// The result will TestQuickMaxHeaderBytes(expected any) error
+func TestQuickMaxHeaderBytes(t *testing.T) {
+    handler := func(c *Ctx) error { return c.SendString("ok") }
+
+    t.Run("QuickTest", func(t *testing.T) {
+        q := New()
+        q.Get("/", handler)
+
+        resp, err := q.QuickTest("GET", "/", map[string]string{"Cookie": "a=" + strings.Repeat("x", 2<<20)})
+        if err != nil {
+            t.Fatal(err)
+        }
+        if resp.StatusCode() != StatusRequestHeaderFieldsTooLarge || !strings.Contains(resp.BodyStr(), "Request Header Fields Too Large") {
+            t.Errorf("Expected 431 with a clear message, got %d %q", resp.StatusCode(), resp.BodyStr())
+        }
+
+        resp, err = q.QuickTest("GET", "/", map[string]string{"Cookie": "a=b"})
+        if err != nil || resp.StatusCode() != StatusOK {
+            t.Errorf("Expected small headers to pass, got %v %v", resp, err)
+        }
+    })
+
+    t.Run("server", func(t *testing.T) {
+        q := New(Config{MaxHeaderBytes: 1024})
+        q.Get("/", handler)
+        ts, err := q.NewTestServer()
+        if err != nil {
+            t.Fatal(err)
+        }
+        defer ts.Close()
+
+        for size, want := range map[int]int{100: StatusOK, 3000: StatusRequestHeaderFieldsTooLarge, 64 << 10: StatusRequestHeaderFieldsTooLarge} {
+            req, _ := http.NewRequest("GET", ts.URL+"/", nil)
+            req.Header.Set("Cookie", "a="+strings.Repeat("x", size))
+            resp, err := ts.Client.Do(req)
+            if err != nil {
+                t.Fatalf("cookie of %d bytes: %v", size, err)
+            }
+            body, _ := io.ReadAll(resp.Body)
+            resp.Body.Close()
+            if resp.StatusCode != want {
+                t.Errorf("cookie of %d bytes: expected %d, got %d %q", size, want, resp.StatusCode, body)
+            }
+        }
+    })
+}
+
+// TestQuickListenUnix test if the server listens on a unix socket, replacing a stale one
+// The result will TestQuickListenUnix(expected any) error
+func TestQuickListenUnix(t *testing.T) {
+    path := t.TempDir() + "/quick.sock"
+
+    // a socket left behind by a crashed process
+    stale, err := net.Listen("unix", path)
+    if err != nil {
+        t.Skipf("unix sockets not supported: %v", err)
+    }
+    stale.(*net.UnixListener).SetUnlinkOnClose(false)
+    stale.Close()
+
+    q := New()
+    q.Get("/ping", func(c *Ctx) error { return c.Status(StatusOK).SendString("pong") })
+    _, shutdown, err := q.ListenUnixWithShutdown(path)
+    if err != nil {
+        t.Fatalf("Expected the stale socket to be replaced, got %v", err)
+    }
+
+    if _, _, err := New().ListenUnixWithShutdown(path); err == nil || !strings.Contains(err.Error(), "in use") {
+        t.Errorf("Expected a socket in use to be rejected, got %v", err)
+    }
+
+    client := &http.Client{Transport: &http.Transport{
+        DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+            return (&net.Dialer{}).DialContext(ctx, "unix", path)
+        },
+    }}
+    resp, err := client.Get("http://unix/ping")
+    if err != nil {
+        t.Fatal(err)
+    }
+    body, _ := io.ReadAll(resp.Body)
+    resp.Body.Close()
+    if string(body) != "pong" {
+        t.Errorf("Expected pong, got %q", body)
+    }
+    client.CloseIdleConnections()
+
+    shutdown()
+    if _, err := os.Stat(path); !os.IsNotExist(err) {
+        t.Errorf("Expected the socket file to be removed, got %v", err)
+    }
+
+    file := t.TempDir() + "/regular"
+    os.WriteFile(file, nil, 0o600)
+    if _, _, err := New().ListenUnixWithShutdown(file); err == nil {
+        t.Error("Expected a regular file to be left alone")
+    }
+}
+
+// TestQuickServe test if the server runs on a listener created by the caller
+// The result will TestQuickServe(expected any) error
+func TestQuickServe(t *testing.T) {
+    newApp := func() *Quick {
+        q := New()
+        q.Get("/ping", func(c *Ctx) error { return c.Status(StatusOK).SendString("pong") })
+        return q
+    }
+    get := func(url string) string {
+        resp, err := http.Get(url)
+        if err != nil {
+            t.Fatal(err)
+        }
+        defer resp.Body.Close()
+        body, _ := io.ReadAll(resp.Body)
+        return string(body)
+    }
+
+    t.Run("ServeWithShutdown", func(t *testing.T) {
+        listener, err := net.Listen("tcp", "127.0.0.1:0")
+        if err != nil {
+            t.Fatal(err)
+        }
+        _, shutdown, err := newApp().ServeWithShutdown(listener)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if body := get("http://" + listener.Addr().String() + "/ping"); body != "pong" {
+            t.Errorf("Expected pong, got %q", body)
+        }
+        shutdown()
+    })
+
+    t.Run("Serve", func(t *testing.T) {
+        listener, err := net.Listen("tcp", "127.0.0.1:0")
+        if err != nil {
+            t.Fatal(err)
+        }
+        q := newApp()
+        errCh := make(chan error, 1)
+        go func() { errCh <- q.Serve(listener) }()
+
+        if body := get("http://" + listener.Addr().String() + "/ping"); body != "pong" {
+            t.Errorf("Expected pong, got %q", body)
+        }
+        if err := q.Shutdown(); err != nil {
+            t.Fatal(err)
+        }
+        select {
+        case err := <-errCh:
+            if err != nil {
+                t.Errorf("Expected nil after Shutdown, got %v", err)
+            }
+        case <-time.After(2 * time.Second):
+            t.Fatal("Serve did not return after Shutdown")
+        }
+    })
+}
+
+// TestQuickConfigErrorHandler test if handler errors are formatted by Config.ErrorHandler
+// The result will TestQuickConfigErrorHandler(expected any) error
+func TestQuickConfigErrorHandler(t *testing.T) {
+    q := New(Config{
+        Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+        ErrorHandler: func(c *Ctx, err error) error {
+            if c.Path() == "/broken" {
+                return errors.New("cannot format")
+            }
+            return c.Status(StatusTeapot).JSON(map[string]string{"error": err.Error()})
+        },
+    })
+    q.Get("/fail", func(c *Ctx) error { return errors.New("boom") })
+    q.Get("/broken", func(c *Ctx) error { return errors.New("boom") })
+    q.Options("/fail", func(c *Ctx) error { return errors.New("boom") })
+
+    for _, method := range []string{MethodGet, MethodOptions} {
+        data, err := q.QuickTest(method, "/fail", nil)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if data.StatusCode() != StatusTeapot || data.BodyStr() != `{"error":"boom"}` {
+            t.Errorf("Expected the ErrorHandler response for %s, got %d %q", method, data.StatusCode(), data.BodyStr())
+        }
+    }
+
+    data, _ := q.QuickTest("GET", "/broken", nil)
+    if data.StatusCode() != StatusInternalServerError || data.BodyStr() != "boom" {
+        t.Errorf("Expected the default response when the ErrorHandler fails, got %d %q", data.StatusCode(), data.BodyStr())
+    }
+
+    c := &Ctx{Response: httptest.NewRecorder()}
+    if err := c.HandleError(&PanicError{Value: "secret"}); err != nil || c.Response.(*httptest.ResponseRecorder).Body.String() != "Internal Server Error" {
+        t.Errorf("Expected panics to hide their value, got %v %q", err, c.Response.(*httptest.ResponseRecorder).Body.String())
+    }
+}
+
+// TestQuickChunkedBody test if chunked bodies are parsed and held to MaxBodySize
+// The result will TestQuickChunkedBody(expected any) error
+func TestQuickChunkedBody(t *testing.T) {
+    type item struct {
+        Name string `json:"name"`
+    }
+    q := New(Config{MaxBodySize: 1024})
+    q.Post("/items", func(c *Ctx) error {
+        if len(c.Request.TransferEncoding) == 0 || c.Request.TransferEncoding[0] != "chunked" {
+            t.Errorf("Expected a chunked request, got %v", c.Request.TransferEncoding)
+        }
+        var it item
+        if err := c.BodyParser(&it); err != nil {
+            return err
+        }
+        return c.Status(StatusCreated).SendString(it.Name)
+    })
+    ts, err := q.NewTestServer()
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer ts.Close()
+
+    post := func(body string) (int, string) {
+        // a reader of unknown length is sent with Transfer-Encoding: chunked
+        req, _ := http.NewRequest("POST", ts.URL+"/items", io.MultiReader(strings.NewReader(body)))
+        req.Header.Set("Content-Type", "application/json")
+        resp, err := ts.Client.Do(req)
+        if err != nil {
+            t.Fatal(err)
+        }
+        defer resp.Body.Close()
+        b, _ := io.ReadAll(resp.Body)
+        return resp.StatusCode, string(b)
+    }
+
+    if status, body := post(`{"name":"quick"}`); status != StatusCreated || body != "quick" {
+        t.Errorf("Expected the chunked JSON to parse, got %d %q", status, body)
+    }
+    if status, _ := post(`{"name":"` + strings.Repeat("x", 2048) + `"}`); status != StatusRequestEntityTooLarge {
+        t.Errorf("Expected 413 for a chunked body over MaxBodySize, got %d", status)
+    }
+}
+
+// TestQuickChunkedMultipartBody test if chunked multipart bodies, which are
+// read lazily, are held to MaxBodySize too
+func TestQuickChunkedMultipartBody(t *testing.T) {
+    q := New(Config{MaxBodySize: 1024})
+    q.Post("/raw", func(c *Ctx) error {
+        if n := len(c.Body()); n > 1024 {
+            t.Errorf("Expected the body to stop at MaxBodySize, read %d bytes", n)
+        }
+        return c.Status(StatusOK).SendString("read")
+    })
+    q.Post("/upload", func(c *Ctx) error {
+        file, err := c.FormFile("file")
+        if err != nil {
+            return err
+        }
+        return c.Status(StatusOK).SendString(file.FileName())
+    })
+    ts, err := q.NewTestServer()
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer ts.Close()
+
+    post := func(path string, size int) (int, string) {
+        var buf bytes.Buffer
+        mw := multipart.NewWriter(&buf)
+        fw, _ := mw.CreateFormFile("file", "big.txt")
+        fw.Write(bytes.Repeat([]byte("x"), size))
+        mw.Close()
+        // a reader of unknown length is sent with Transfer-Encoding: chunked
+        req, _ := http.NewRequest("POST", ts.URL+path, io.MultiReader(&buf))
+        req.Header.Set("Content-Type", mw.FormDataContentType())
+        resp, err := ts.Client.Do(req)
+        if err != nil {
+            t.Fatal(err)
+        }
+        defer resp.Body.Close()
+        b, _ := io.ReadAll(resp.Body)
+        return resp.StatusCode, string(b)
+    }
+
+    if status, body := post("/upload", 100); status != StatusOK || body != "big.txt" {
+        t.Errorf("Expected the small upload to parse, got %d %q", status, body)
+    }
+    if status, _ := post("/upload", 8<<10); status != StatusRequestEntityTooLarge {
+        t.Errorf("Expected 413 for a chunked multipart body over MaxBodySize, got %d", status)
+    }
+    post("/raw", 8<<10)
+}
+
+func TestQuickOnBodyLimitExceeded(t *testing.T) {
+    q := New(Config{
+        MaxBodySize: 16,
+        OnBodyLimitExceeded: func(c *Ctx) error {
+            if c.Path() == "/fail" {
+                return errors.New("hook failed")
+            }
+            return c.Status(StatusRequestEntityTooLarge).JSON(map[string]string{"error": "body too large"})
+        },
+    })
+    handler := func(c *Ctx) error { return c.Status(StatusOK).Send(c.Body()) }
+    q.Post("/items", handler)
+    q.Put("/items", handler)
+    q.Post("/fail", handler)
+
+    send := func(method, path string, chunked bool) *httptest.ResponseRecorder {
+        req := httptest.NewRequest(method, path, strings.NewReader(strings.Repeat("x", 64)))
+        if chunked {
+            req.ContentLength = -1
+        }
+        rec := httptest.NewRecorder()
+        q.ServeHTTP(rec, req)
+        return rec
+    }
+
+    for _, tt := range []struct {
+        method  string
+        chunked bool
+    }{{"POST", false}, {"POST", true}, {"PUT", false}, {"PUT", true}} {
+        rec := send(tt.method, "/items", tt.chunked)
+        if rec.Code != StatusRequestEntityTooLarge || rec.Body.String() != `{"error":"body too large"}` {
+            t.Errorf("%s chunked=%v: expected the JSON 413, got %d %q", tt.method, tt.chunked, rec.Code, rec.Body.String())
+        }
+    }
+
+    rec := send("POST", "/fail", true)
+    if rec.Code != StatusRequestEntityTooLarge || !strings.Contains(rec.Body.String(), "Request body too large") {
+        t.Errorf("Expected the plain 413 when the hook fails, got %d %q", rec.Code, rec.Body.String())
+    }
+}
@@ -3,6 +3,7 @@ package quick
 import (
 	"errors"
 	"github.com/jeffotoni/quick/internal/concat"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -81,6 +82,47 @@ func SaveAll(files []*UploadedFile, destination string) error {
 	return nil
 }
 
+// Storage is where uploaded files are kept, e.g. a local directory, S3 or
+// GCS. Save stores the content of r under name and returns its location
+// (a path, URL or object key). Set it in Config.Storage and save uploads
+// with c.SaveFileTo, so handlers do not depend on the backend.
+type Storage interface {
+	Save(name string, r io.Reader) (string, error)
+}
+
+// DiskStorage is a Storage that writes files into the directory Dir,
+// creating it when missing. Names are reduced to their last element so
+// a client-sent name cannot escape Dir.
+type DiskStorage struct {
+	Dir string
+}
+
+// Save writes r to Dir/name and returns the path of the file.
+// The result will Save(name string, r io.Reader) (string, error)
+func (d DiskStorage) Save(name string, r io.Reader) (string, error) {
+	name = filepath.Base(filepath.Clean("/" + name))
+	if name == "/" || name == "." {
+		return "", errors.New("invalid file name")
+	}
+	if err := os.MkdirAll(d.Dir, os.ModePerm); err != nil {
+		return "", errors.New("failed to create destination directory")
+	}
+
+	fullPath := filepath.Join(d.Dir, name)
+	dst, err := os.Create(fullPath)
+	if err != nil {
+		return "", errors.New("failed to create file on disk")
+	}
+	if _, err := io.Copy(dst, r); err != nil {
+		dst.Close()
+		return "", errors.New("failed to save file")
+	}
+	if err := dst.Close(); err != nil {
+		return "", errors.New("failed to save file")
+	}
+	return fullPath, nil
+}
+
 // parseSize converts a human-readable size string (e.g., "10MB") to bytes.
 // The result will parseSize(sizeStr string) (int64, error)
 func parseSize(sizeStr string) (int64, error) {
@@ -860,3 +860,136 @@ func TestFormFileLimitErrors(t *testing.T) {
 		}
 	})
 }
+
+// memoryStorage is a Storage that keeps files in memory
+type memoryStorage struct {
+	files map[string]string
+}
+
+func (m *memoryStorage) Save(name string, r io.Reader) (string, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	m.files[name] = string(b)
+	return "mem://" + name, nil
+}
+
+func TestSaveFileTo(t *testing.T) {
+	mem := &memoryStorage{files: map[string]string{}}
+	dir := t.TempDir()
+
+	// storage is nil for the first request, so Config.Storage is used
+	var storage Storage
+	q := New(Config{MaxBodySize: 1 << 20, Storage: mem})
+	q.Post("/upload", func(c *Ctx) error {
+		file, err := c.FormFile("file")
+		if err != nil {
+			return c.Status(400).SendString(err.Error())
+		}
+		location, err := c.SaveFileTo(storage, file)
+		if err != nil {
+			return c.Status(500).SendString(err.Error())
+		}
+		return c.Status(200).SendString(location)
+	})
+	ts := httptest.NewServer(q)
+	defer ts.Close()
+
+	body, _ := sendMultipartRequest(t, ts.URL, "testfile.txt", "Hello, Quick!")
+	if string(body) != "mem://testfile.txt" || mem.files["testfile.txt"] != "Hello, Quick!" {
+		t.Errorf("expected the file in Config.Storage, got %q %v", body, mem.files)
+	}
+
+	storage = DiskStorage{Dir: dir}
+	body, _ = sendMultipartRequest(t, ts.URL, "testfile.txt", "Hello, Disk!")
+	if want := filepath.Join(dir, "testfile.txt"); string(body) != want {
+		t.Errorf("expected location %q, got %q", want, body)
+	}
+	if b, err := os.ReadFile(filepath.Join(dir, "testfile.txt")); err != nil || string(b) != "Hello, Disk!" {
+		t.Errorf("expected the file on disk, got %q %v", b, err)
+	}
+
+	c := &Ctx{}
+	if _, err := c.SaveFileTo(nil, &UploadedFile{}); err == nil || err.Error() != "no storage configured" {
+		t.Errorf("expected 'no storage configured', got %v", err)
+	}
+}
+
+func TestDiskStorageSave(t *testing.T) {
+	dir := t.TempDir()
+	d := DiskStorage{Dir: filepath.Join(dir, "uploads")}
+
+	location, err := d.Save("../../escape.txt", bytes.NewReader([]byte("data")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "uploads", "escape.txt"); location != want {
+		t.Errorf("expected %q, got %q", want, location)
+	}
+
+	for _, name := range []string{"", "/", ".."} {
+		if _, err := d.Save(name, bytes.NewReader(nil)); err == nil {
+			t.Errorf("expected an error for name %q", name)
+		}
+	}
+}
+
+func TestMultipartMemoryLimit(t *testing.T) {
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	for name, size := range map[string]int{"small": 100, "large": 4096} {
+		part, _ := w.CreateFormFile(name, name+".bin")
+		part.Write(bytes.Repeat([]byte("x"), size))
+	}
+	w.Close()
+
+	send := func(q *Quick) string {
+		req := httptest.NewRequest(MethodPost, "/upload", bytes.NewReader(body.Bytes()))
+		req.Header.Set("Content-Type", w.FormDataContentType())
+		rec := httptest.NewRecorder()
+		q.ServeHTTP(rec, req)
+		return rec.Body.String()
+	}
+	// onDisk reports which files were spilled to temporary files
+	onDisk := func(c *Ctx) error {
+		form, err := c.MultipartForm()
+		if err != nil {
+			return err
+		}
+		var out string
+		for _, name := range []string{"small", "large"} {
+			f, err := form.File[name][0].Open()
+			if err != nil {
+				return err
+			}
+			_, disk := f.(*os.File)
+			f.Close()
+			out += fmt.Sprintf("%s=%v ", name, disk)
+		}
+		return c.Status(StatusOK).SendString(out)
+	}
+
+	q := New(Config{MaxBodySize: 1 << 20, MultipartMemoryLimit: 1024})
+	q.Post("/upload", onDisk)
+	if got := send(q); got != "small=false large=true " {
+		t.Errorf("expected the large part in a temporary file, got %q", got)
+	}
+
+	q = New(Config{MaxBodySize: 1 << 20, MultipartMemoryLimit: 8 << 20})
+	q.Post("/upload", onDisk)
+	if got := send(q); got != "small=false large=false " {
+		t.Errorf("expected both parts in memory, got %q", got)
+	}
+
+	q = New(Config{MaxBodySize: 1 << 20, MultipartMemoryLimit: 8 << 20})
+	q.Post("/upload", func(c *Ctx) error {
+		if err := c.FormFileLimit("1KB"); err != nil {
+			return err
+		}
+		return onDisk(c)
+	})
+	if got := send(q); got != "small=false large=true " {
+		t.Errorf("expected FormFileLimit to override the config, got %q", got)
+	}
+}
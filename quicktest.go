@@ -2,9 +2,12 @@ package quick
 
 import (
 	"bytes"
+	"context"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"time"
 )
 
 const logDelimiter = "====================="
@@ -38,6 +41,13 @@ type (
 // Required Params: method (e.g., GET, POST), URI (path only, e.g., /test/:param)
 // Optional Param: body (optional; use only when necessary)
 func (q Quick) QuickTest(method, URI string, headers map[string]string, body ...[]byte) (QuickTestReturn, error) {
+	return q.QuickTestContext(context.Background(), method, URI, headers, body...)
+}
+
+// QuickTestContext works like QuickTest with ctx attached to the request.
+// Cancelling ctx, or giving it a deadline, simulates a client that went away;
+// the response written by the handler is returned so it can be asserted.
+func (q Quick) QuickTestContext(ctx context.Context, method, URI string, headers map[string]string, body ...[]byte) (QuickTestReturn, error) {
 	requestBody := []byte{}
 	if len(body) > 0 {
 		requestBody = body[0]
@@ -45,7 +55,7 @@ func (q Quick) QuickTest(method, URI string, headers map[string]string, body ...
 
 	logRequestDetails(method, URI, len(requestBody))
 
-	req, err := createHTTPRequest(method, URI, headers, requestBody)
+	req, err := createHTTPRequest(ctx, method, URI, headers, requestBody)
 	if err != nil {
 		return nil, err
 	}
@@ -67,9 +77,59 @@ func (q Quick) QuickTest(method, URI string, headers map[string]string, body ...
 	}, nil
 }
 
+// QuickTestServer is a Quick app listening on an ephemeral loopback port,
+// for tests that need a real TCP round-trip, like httptest.Server
+type QuickTestServer struct {
+	URL    string       // base URL, e.g. http://127.0.0.1:41234
+	Addr   string       // listening address, e.g. 127.0.0.1:41234
+	Client *http.Client // client with its own transport, closed by Close
+
+	server   *http.Server
+	listener net.Listener
+}
+
+// NewTestServer starts the app on 127.0.0.1 with a random port. The optional
+// handler wraps the app as in Listen, e.g. a middleware that must see every
+// request. Call Close when done, usually with t.Cleanup(ts.Close).
+// The result will NewTestServer(handler ...http.Handler) (*QuickTestServer, error)
+func (q *Quick) NewTestServer(handler ...http.Handler) (*QuickTestServer, error) {
+	if err := q.ValidateRoutes(); err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	addr := listener.Addr().String()
+	ts := &QuickTestServer{
+		URL:      "http://" + addr,
+		Addr:     addr,
+		Client:   &http.Client{Transport: &http.Transport{}},
+		server:   q.httpServer(addr, handler...),
+		listener: listener,
+	}
+	q.notifyListen(addr)
+
+	go ts.server.Serve(listener)
+	return ts, nil
+}
+
+// Close shuts the server down, waiting for active requests to finish,
+// and closes the idle connections of the client
+// The result will Close()
+func (ts *QuickTestServer) Close() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = ts.server.Shutdown(ctx)
+	ts.listener.Close()
+	ts.Client.CloseIdleConnections()
+}
+
 // createHTTPRequest: Encapsulates the creation of an HTTP request.
-func createHTTPRequest(method, URI string, headers map[string]string, body []byte) (*http.Request, error) {
-	req, err := http.NewRequest(method, URI, io.NopCloser(bytes.NewBuffer(body)))
+func createHTTPRequest(ctx context.Context, method, URI string, headers map[string]string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, URI, io.NopCloser(bytes.NewBuffer(body)))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,56 @@
+package quick
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestNewTestServer checks a real TCP round-trip through the app and its middlewares.
+// The result will TestNewTestServer(expected any) error
+func TestNewTestServer(t *testing.T) {
+	q := New()
+	q.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// RemoteAddr only carries a real port over a real connection
+			if _, port, err := net.SplitHostPort(r.RemoteAddr); err == nil && port != "1234" {
+				w.Header().Set("X-Remote-Port", port)
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	q.Post("/echo", func(c *Ctx) error {
+		return c.Status(StatusOK).Send(c.Body())
+	})
+
+	ts, err := q.NewTestServer()
+	if err != nil {
+		t.Fatalf("Error starting test server: %v", err)
+	}
+	t.Cleanup(ts.Close)
+
+	if !strings.HasPrefix(ts.URL, "http://127.0.0.1:") {
+		t.Fatalf("unexpected URL %q", ts.URL)
+	}
+
+	resp, err := ts.Client.Post(ts.URL+"/echo", "text/plain", strings.NewReader("quick"))
+	if err != nil {
+		t.Fatalf("Error in request: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != StatusOK || string(body) != "quick" {
+		t.Errorf("expected 200 quick, got %d %q", resp.StatusCode, body)
+	}
+	if resp.Header.Get("X-Remote-Port") == "" {
+		t.Error("expected the middleware to see a real remote address")
+	}
+
+	ts.Close()
+	if _, err := ts.Client.Get(ts.URL + "/echo"); err == nil {
+		t.Error("expected requests to fail after Close")
+	}
+}
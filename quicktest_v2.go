@@ -16,6 +16,7 @@ package quick
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -23,6 +24,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"strings"
+	"time"
 )
 
 // QtestReturn represents the response and additional functionality for validation.
@@ -52,6 +54,12 @@ type QuickTestOptions struct {
 	Body        []byte
 	Cookies     []*http.Cookie
 	LogDetails  bool // Enables request/response logging
+	// Context is attached to the request, so cancelling it simulates a
+	// client that went away. Handlers observe it through c.Context().
+	Context context.Context
+	// Timeout, when set, derives a deadline from Context (or from
+	// context.Background) that is cancelled after the given duration.
+	Timeout time.Duration
 }
 
 // Qtest performs HTTP tests with query params, cookies, and validation options.
@@ -63,9 +71,20 @@ func (q Quick) Qtest(opts QuickTestOptions) (QtestReturn, error) {
 		return nil, err
 	}
 
+	// Attach the caller context and the optional deadline
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
 	// Create HTTP request
 	reqBody := bytes.NewBuffer(opts.Body)
-	req, err := http.NewRequest(opts.Method, uriWithParams, reqBody)
+	req, err := http.NewRequestWithContext(ctx, opts.Method, uriWithParams, reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %w", err)
 	}
@@ -1,9 +1,11 @@
 package quick
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 )
 
 // TestQTest_Options_GET checks if the response body contains a specific substring.
@@ -223,3 +225,54 @@ func TestQTest_Options_OPTIONS(t *testing.T) {
 		t.Errorf("Header assertion failed: %v", err)
 	}
 }
+
+// TestQTest_Options_Timeout checks that handlers observe the deadline of the test request.
+// The result will TestQTest_Options_Timeout(expected any) error
+func TestQTest_Options_Timeout(t *testing.T) {
+	q := New()
+	q.Get("/slow", func(c *Ctx) error {
+		select {
+		case <-time.After(2 * time.Second):
+			return c.Status(StatusOK).String("done")
+		case <-c.Context().Done():
+			return c.Status(StatusGatewayTimeout).String(c.Context().Err().Error())
+		}
+	})
+
+	start := time.Now()
+	result, err := q.Qtest(QuickTestOptions{Method: MethodGet, URI: "/slow", Timeout: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Error in test: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected the handler to stop at the deadline, took %v", elapsed)
+	}
+	if err := result.AssertStatus(StatusGatewayTimeout); err != nil {
+		t.Error(err)
+	}
+	if err := result.AssertBodyContains("deadline exceeded"); err != nil {
+		t.Error(err)
+	}
+
+	// a context cancelled by the caller simulates a client that went away
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err = q.Qtest(QuickTestOptions{Method: MethodGet, URI: "/slow", Context: ctx})
+	if err != nil {
+		t.Fatalf("Error in test: %v", err)
+	}
+	if err := result.AssertBodyContains("context canceled"); err != nil {
+		t.Error(err)
+	}
+
+	// QuickTestContext offers the same for the simpler helper
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	res, err := q.QuickTestContext(ctx, MethodGet, "/slow", nil)
+	if err != nil {
+		t.Fatalf("Error in test: %v", err)
+	}
+	if res.StatusCode() != StatusGatewayTimeout {
+		t.Errorf("expected 504, got %d", res.StatusCode())
+	}
+}
@@ -0,0 +1,353 @@
+package quick
+
+import (
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// routeNode is a node of the segment trie used to match requests.
+// Each level of the trie corresponds to one path segment, so a lookup
+// costs O(number of segments) instead of O(number of routes).
+type routeNode struct {
+	static  map[string]*routeNode // literal segments, e.g. "users"
+	regex   []*routeNode          // {name:regex} segments, in registration order
+	param   *routeNode            // :name segment
+	rgxText string                // regex source for regex nodes
+	rgx     *regexp.Regexp        // compiled regex for regex nodes
+	route   *Route                // route that terminates at this node
+	names   []string              // param names of route, in path order
+}
+
+// router holds one segment trie per HTTP method, plus the tries of the
+// routes constrained to a host pattern
+type router struct {
+	trees map[string]*routeNode
+	hosts []*hostRouter
+}
+
+// hostRouter holds the tries of the routes registered for one host pattern,
+// e.g. "{tenant}.example.com"
+type hostRouter struct {
+	pattern string
+	labels  []string // host pattern split by ".", "" marks a param label
+	names   []string // param names captured from the host, in order
+	trees   map[string]*routeNode
+}
+
+// newRouter creates an empty router
+// Method Used Internally
+// The result will newRouter() *router
+func newRouter() *router {
+	return &router{trees: make(map[string]*routeNode)}
+}
+
+// splitPath removes the leading slash and splits the path into segments
+// Method Used Internally
+// The result will splitPath(p string) []string
+func splitPath(p string) []string {
+	return strings.Split(strings.TrimPrefix(p, "/"), "/")
+}
+
+// insert adds a route to the trie of its method.
+// Patterns with invalid segments (e.g. "{id}" or ":") are never matched,
+// so they are not inserted. Trailing params marked with "?", e.g.
+// /posts/:id/:slug?, also terminate the route at the node before them.
+// When a route with the same method and shape is already registered,
+// the first registration is kept and returned.
+// Method Used Internally
+// The result will insert(method, pattern string, route *Route) (*Route, bool)
+func (r *router) insert(method, pattern string, route *Route) (*Route, bool) {
+	trees := r.trees
+	var names []string
+	if route.Host != "" {
+		h := r.hostRouter(route.Host)
+		if h == nil {
+			return nil, false
+		}
+		trees = h.trees
+		names = append([]string(nil), h.names...)
+	}
+
+	root, ok := trees[method]
+	if !ok {
+		root = &routeNode{}
+		trees[method] = root
+	}
+
+	n := root
+	var ends []routeEnd
+	for _, seg := range splitPath(pattern) {
+		// Ex: :slug? => the route also ends before this segment
+		if strings.HasSuffix(seg, "?") && (strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "{")) {
+			ends = append(ends, routeEnd{n, append([]string(nil), names...)})
+			seg = seg[:len(seg)-1]
+		} else if len(ends) > 0 {
+			// optional segments must be trailing
+			return nil, false
+		}
+
+		switch {
+		// Ex: :id => paramName = "id"
+		case strings.HasPrefix(seg, ":"):
+			if len(seg) == 1 {
+				return nil, false
+			}
+			names = append(names, seg[1:])
+			if n.param == nil {
+				n.param = &routeNode{}
+			}
+			n = n.param
+
+		// Ex: {id:[0-9]+}
+		case strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}"):
+			parts := strings.SplitN(seg[1:len(seg)-1], ":", 2)
+			if len(parts) != 2 || parts[0] == "" {
+				return nil, false
+			}
+			child := n.regexChild(parts[1])
+			if child == nil {
+				rgx, err := regexp.Compile("^" + parts[1] + "$")
+				if err != nil {
+					return nil, false
+				}
+				child = &routeNode{rgxText: parts[1], rgx: rgx}
+				n.regex = append(n.regex, child)
+			}
+			names = append(names, parts[0])
+			n = child
+
+		default:
+			if n.static == nil {
+				n.static = make(map[string]*routeNode)
+			}
+			child, ok := n.static[seg]
+			if !ok {
+				child = &routeNode{}
+				n.static[seg] = child
+			}
+			n = child
+		}
+	}
+
+	ends = append(ends, routeEnd{n, names})
+	for _, end := range ends {
+		if end.node.route != nil {
+			return end.node.route, false
+		}
+	}
+	for _, end := range ends {
+		end.node.route = route
+		end.node.names = end.names
+	}
+	return nil, true
+}
+
+// routeEnd is a node where a route terminates, with the names of the
+// params matched up to it. Routes with optional params have several.
+type routeEnd struct {
+	node  *routeNode
+	names []string
+}
+
+// regexChild returns the regex child compiled from the given source, if any
+// Method Used Internally
+// The result will regexChild(src string) *routeNode
+func (n *routeNode) regexChild(src string) *routeNode {
+	for _, child := range n.regex {
+		if child.rgxText == src {
+			return child
+		}
+	}
+	return nil
+}
+
+// lookup finds the route registered for method and path, which may be
+// percent-encoded, e.g. /users/%34%32 matches /users/:id with id "42".
+// Literal segments take precedence over regex params, which take
+// precedence over plain params; the search backtracks when a branch
+// does not lead to a route. Param values are substrings of path, unless
+// decoded, and the walk does not allocate unless the route has params.
+// Method Used Internally
+// The result will lookup(method, path string) (*Route, []string, []string)
+func (r *router) lookup(method, path string) (*Route, []string, []string) {
+	root, ok := r.trees[method]
+	if !ok {
+		return nil, nil, nil
+	}
+
+	n, values := root.match(strings.TrimPrefix(path, "/"), false, nil)
+	if n == nil {
+		return nil, nil, nil
+	}
+	return n.route, n.names, values
+}
+
+// lookupHost finds the route registered for method, host and path.
+// Routes constrained to a matching host pattern are tried first, literal
+// hosts before hosts with params, then the routes registered without a host.
+// A trailing dot, as in the fully qualified "api.example.com.", is ignored.
+// Values captured from the host come before the path params.
+// Method Used Internally
+// The result will lookupHost(method, host, path string) (*Route, []string, []string)
+func (r *router) lookupHost(method, host, path string) (*Route, []string, []string) {
+	if len(r.hosts) > 0 {
+		host = strings.TrimSuffix(strings.ToLower(stripPort(host)), ".")
+		for _, h := range r.hosts {
+			root, ok := h.trees[method]
+			if !ok {
+				continue
+			}
+			values, ok := h.match(host)
+			if !ok {
+				continue
+			}
+			if n, v := root.match(strings.TrimPrefix(path, "/"), false, values); n != nil {
+				return n.route, n.names, v
+			}
+		}
+	}
+	return r.lookup(method, path)
+}
+
+// hostRouter returns the host router for pattern, creating it on first use.
+// It returns nil for invalid patterns, e.g. "{}.example.com".
+// Method Used Internally
+// The result will hostRouter(pattern string) *hostRouter
+func (r *router) hostRouter(pattern string) *hostRouter {
+	pattern = strings.ToLower(pattern)
+	for _, h := range r.hosts {
+		if h.pattern == pattern {
+			return h
+		}
+	}
+
+	h := &hostRouter{pattern: pattern, trees: make(map[string]*routeNode)}
+	for _, label := range strings.Split(pattern, ".") {
+		switch {
+		case strings.HasPrefix(label, "{") && strings.HasSuffix(label, "}"):
+			if len(label) == 2 {
+				return nil
+			}
+			h.labels = append(h.labels, "")
+			h.names = append(h.names, label[1:len(label)-1])
+		case label == "" || strings.ContainsAny(label, "{}"):
+			return nil
+		default:
+			h.labels = append(h.labels, label)
+		}
+	}
+	// literal hosts are tried before the ones capturing params
+	i := len(r.hosts)
+	if len(h.names) == 0 {
+		for i = 0; i < len(r.hosts) && len(r.hosts[i].names) == 0; i++ {
+		}
+	}
+	r.hosts = append(r.hosts[:i], append([]*hostRouter{h}, r.hosts[i:]...)...)
+	return h
+}
+
+// match reports whether host matches the pattern, label by label,
+// and returns the values of the param labels
+// Method Used Internally
+// The result will match(host string) ([]string, bool)
+func (h *hostRouter) match(host string) ([]string, bool) {
+	var values []string
+	rest := host
+	for i, want := range h.labels {
+		label, tail, more := strings.Cut(rest, ".")
+		if label == "" || more != (i < len(h.labels)-1) {
+			return nil, false
+		}
+		if want == "" {
+			values = append(values, label)
+		} else if want != label {
+			return nil, false
+		}
+		rest = tail
+	}
+	return values, true
+}
+
+// stripPort removes the port from a Host header value, keeping IPv6 brackets
+// Method Used Internally
+// The result will stripPort(host string) string
+func stripPort(host string) string {
+	if i := strings.LastIndexByte(host, ':'); i > strings.LastIndexByte(host, ']') {
+		return host[:i]
+	}
+	return host
+}
+
+// match walks the trie recursively, consuming one segment of path per level
+// and collecting param values
+// Method Used Internally
+// The result will match(path string, end bool, values []string) (*routeNode, []string)
+func (n *routeNode) match(path string, end bool, values []string) (*routeNode, []string) {
+	if end {
+		if n.route != nil {
+			return n, values
+		}
+		return nil, values
+	}
+
+	seg, rest, more := strings.Cut(path, "/")
+	// the path is matched escaped and decoded one segment at a time, so an
+	// encoded slash (%2F) stays inside its segment instead of splitting it
+	if strings.IndexByte(seg, '%') >= 0 {
+		if decoded, err := url.PathUnescape(seg); err == nil {
+			seg = decoded
+		}
+	}
+
+	if child, ok := n.static[seg]; ok {
+		if found, v := child.match(rest, !more, values); found != nil {
+			return found, v
+		}
+	}
+
+	for _, child := range n.regex {
+		if !child.rgx.MatchString(seg) {
+			continue
+		}
+		if found, v := child.match(rest, !more, append(values, seg)); found != nil {
+			return found, v
+		}
+	}
+
+	if n.param != nil {
+		if found, v := n.param.match(rest, !more, append(values, seg)); found != nil {
+			return found, v
+		}
+	}
+
+	return nil, values
+}
+
+// quickDir is the directory holding the quick package sources
+var quickDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file)
+}()
+
+// callerSite returns "file:line" of the first caller outside the quick
+// package sources, i.e. the place where the application registered a route
+// Method Used Internally
+// The result will callerSite() string
+func callerSite() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if filepath.Dir(frame.File) != quickDir || strings.HasSuffix(frame.File, "_test.go") {
+			return frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package quick
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// routeParams registers patternURI in an empty router and looks up reqURI,
+// returning the params by name and whether the route matched
+// Method Used Internally
+// The result will routeParams(reqURI, patternURI string) (map[string]string, bool)
+func routeParams(reqURI, patternURI string) (map[string]string, bool) {
+	r := newRouter()
+	if _, ok := r.insert(MethodGet, patternURI, &Route{Method: MethodGet}); !ok {
+		return nil, false
+	}
+	route, names, values := r.lookup(MethodGet, reqURI)
+	if route == nil {
+		return nil, false
+	}
+	params := make(map[string]string, len(names))
+	for i, name := range names {
+		params[name] = values[i]
+	}
+	return params, true
+}
+
+// TestRouterLookup verifies static, regex and param segments are matched by the trie
+// The will test TestRouterLookup(t *testing.T)
+//
+// Run:
+//
+//	$ go test -v -run ^TestRouterLookup
+func TestRouterLookup(t *testing.T) {
+	r := newRouter()
+	static := &Route{Method: MethodGet}
+	param := &Route{Method: MethodGet}
+	regex := &Route{Method: MethodGet}
+	r.insert(MethodGet, "/users/me", static)
+	r.insert(MethodGet, "/users/:name", param)
+	r.insert(MethodGet, "/users/{id:[0-9]+}", regex)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   *Route
+		values []string
+	}{
+		{"static wins", MethodGet, "/users/me", static, nil},
+		{"regex wins over param", MethodGet, "/users/42", regex, []string{"42"}},
+		{"param fallback", MethodGet, "/users/jeff", param, []string{"jeff"}},
+		{"wrong method", MethodPost, "/users/me", nil, nil},
+		{"too many segments", MethodGet, "/users/me/x", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route, _, values := r.lookup(tt.method, tt.path)
+			if route != tt.want {
+				t.Fatalf("expected route %p, got %p", tt.want, route)
+			}
+			if fmt.Sprint(values) != fmt.Sprint(tt.values) {
+				t.Errorf("expected values %v, got %v", tt.values, values)
+			}
+		})
+	}
+}
+
+// TestRouterBacktracking verifies the lookup backtracks when a static branch dead-ends
+// The will test TestRouterBacktracking(t *testing.T)
+//
+// Run:
+//
+//	$ go test -v -run ^TestRouterBacktracking
+func TestRouterBacktracking(t *testing.T) {
+	q := New()
+	q.Get("/a/b/c", func(c *Ctx) error { return c.SendString("static") })
+	q.Get("/a/:x/d", func(c *Ctx) error { return c.SendString("param " + c.Param("x")) })
+
+	data, err := q.QuickTest("GET", "/a/b/d", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data.BodyStr() != "param b" {
+		t.Errorf("expected 'param b', got '%s'", data.BodyStr())
+	}
+}
+
+// TestRouterPrecedenceOrder verifies the most specific route wins whatever the registration order
+// The will test TestRouterPrecedenceOrder(t *testing.T)
+//
+// Run:
+//
+//	$ go test -v -run ^TestRouterPrecedenceOrder
+func TestRouterPrecedenceOrder(t *testing.T) {
+	q := New()
+	q.Get("/users/:name", func(c *Ctx) error { return c.SendString("param " + c.Param("name")) })
+	q.Get("/users/{id:[0-9]+}", func(c *Ctx) error { return c.SendString("regex " + c.Param("id")) })
+	q.Get("/users/me", func(c *Ctx) error { return c.SendString("static") })
+
+	for path, want := range map[string]string{
+		"/users/me":   "static",
+		"/users/42":   "regex 42",
+		"/users/jeff": "param jeff",
+	} {
+		data, err := q.QuickTest("GET", path, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if data.BodyStr() != want {
+			t.Errorf("%s: expected '%s', got '%s'", path, want, data.BodyStr())
+		}
+	}
+}
+
+// TestRouterInvalidPattern verifies patterns with invalid segments are never matched
+// The will test TestRouterInvalidPattern(t *testing.T)
+//
+// Run:
+//
+//	$ go test -v -run ^TestRouterInvalidPattern
+func TestRouterInvalidPattern(t *testing.T) {
+	r := newRouter()
+	for _, p := range []string{"/users/:", "/users/{id}", "/users/{:[0-9]+}", "/users/{id:[}"} {
+		if existing, ok := r.insert(MethodGet, p, &Route{}); ok || existing != nil {
+			t.Errorf("expected pattern %s to be rejected", p)
+		}
+	}
+}
+
+// TestRouterHost verifies routes constrained to a host pattern capture the subdomain
+// The will test TestRouterHost(t *testing.T)
+//
+// Run:
+//
+//	$ go test -v -run ^TestRouterHost
+func TestRouterHost(t *testing.T) {
+	q := New()
+	tenant := q.Group("/").Host("{tenant}.example.com")
+	tenant.Get("/dashboard", func(c *Ctx) error {
+		return c.SendString("tenant " + c.Params["tenant"])
+	})
+	tenant.Get("/users/:id", func(c *Ctx) error {
+		return c.SendString(c.Param("tenant") + " user " + c.Param("id"))
+	})
+	q.Group("/").Host("admin.example.com").Get("/dashboard", func(c *Ctx) error {
+		return c.SendString("admin")
+	})
+	q.Get("/dashboard", func(c *Ctx) error {
+		return c.SendString("default")
+	})
+
+	tests := []struct {
+		host, path string
+		want       string
+		code       int
+	}{
+		{"acme.example.com", "/dashboard", "tenant acme", 200},
+		{"ACME.example.com:8080", "/dashboard", "tenant acme", 200},
+		{"acme.example.com.", "/dashboard", "tenant acme", 200},
+		{"admin.example.com.:8080", "/dashboard", "admin", 200},
+		{"acme.example.com", "/users/7", "acme user 7", 200},
+		{"admin.example.com", "/dashboard", "admin", 200},
+		{"admin.example.com", "/users/1", "admin user 1", 200},
+		{"example.com", "/dashboard", "default", 200},
+		{"a.b.example.com", "/dashboard", "default", 200},
+		{"other.org", "/users/7", "", 404},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Host = tt.host
+			rec := httptest.NewRecorder()
+			q.ServeHTTP(rec, req)
+
+			if rec.Code != tt.code {
+				t.Fatalf("expected %d, got %d", tt.code, rec.Code)
+			}
+			if tt.code == 200 && rec.Body.String() != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, rec.Body.String())
+			}
+		})
+	}
+
+	r := newRouter()
+	if _, ok := r.insert(MethodGet, "/", &Route{Host: "{}.example.com"}); ok {
+		t.Error("expected invalid host pattern to be rejected")
+	}
+}
+
+// TestRouterOptionalParams verifies trailing params marked with "?" may be omitted
+// The will test TestRouterOptionalParams(t *testing.T)
+//
+// Run:
+//
+//	$ go test -v -run ^TestRouterOptionalParams
+func TestRouterOptionalParams(t *testing.T) {
+	q := New()
+	q.Get("/posts/:id/:slug?", func(c *Ctx) error {
+		return c.SendString(c.Param("id") + "|" + c.Param("slug") + "|" + c.RoutePattern())
+	})
+	q.Get("/files/{year:[0-9]+}?", func(c *Ctx) error {
+		return c.SendString("files " + c.Param("year"))
+	})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/posts/42", "42||/posts/:id/:slug?"},
+		{"/posts/42/hello", "42|hello|/posts/:id/:slug?"},
+		{"/files", "files "},
+		{"/files/2024", "files 2024"},
+	}
+	for _, tt := range tests {
+		data, err := q.QuickTest("GET", tt.path, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if data.StatusCode() != http.StatusOK || data.BodyStr() != tt.want {
+			t.Errorf("%s: expected 200 %q, got %d %q", tt.path, tt.want, data.StatusCode(), data.BodyStr())
+		}
+	}
+
+	if data, _ := q.QuickTest("GET", "/files/abc", nil); data.StatusCode() != http.StatusNotFound {
+		t.Errorf("expected the regex to still apply, got %d", data.StatusCode())
+	}
+
+	r := newRouter()
+	if _, ok := r.insert(MethodGet, "/posts/:id?/comments", &Route{}); ok {
+		t.Error("expected optional params followed by other segments to be rejected")
+	}
+	existing := &Route{}
+	r.insert(MethodGet, "/posts/:id", existing)
+	if found, ok := r.insert(MethodGet, "/posts/:id/:slug?", &Route{}); ok || found != existing {
+		t.Error("expected a conflict with the route matching the omitted form")
+	}
+	if route, _, _ := r.lookup(MethodGet, "/posts/1/x"); route != nil {
+		t.Error("expected a conflicting route to register none of its forms")
+	}
+}
+
+// TestRouterPercentEncoding verifies encoded segments are decoded for matching,
+// while an encoded slash stays inside its segment
+// The will test TestRouterPercentEncoding(t *testing.T)
+//
+// Run:
+//
+//	$ go test -v -run ^TestRouterPercentEncoding
+func TestRouterPercentEncoding(t *testing.T) {
+	q := New()
+	q.Get("/users/:id", func(c *Ctx) error {
+		return c.SendString("user " + c.Params["id"])
+	})
+	q.Get("/files/:dir/:name", func(c *Ctx) error {
+		return c.SendString("file " + c.Param("dir") + " " + c.Param("name"))
+	})
+	q.Get("/docs/{id:[0-9]+}", func(c *Ctx) error {
+		return c.SendString("doc " + c.Param("id"))
+	})
+
+	tests := []struct {
+		path string
+		want string
+		code int
+	}{
+		{"/users/%34%32", "user 42", 200},
+		{"/%75sers/42", "user 42", 200},
+		{"/users/jeff%20otoni", "user jeff otoni", 200},
+		{"/users/a%2Fb", "user a/b", 200},
+		{"/users/a%2fb", "user a/b", 200},
+		{"/files/a%2Fb", "", 404},
+		{"/files/a/b%2Fc", "file a b/c", 200},
+		{"/docs/%31%32", "doc 12", 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			q.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.code {
+				t.Fatalf("expected %d, got %d", tt.code, rec.Code)
+			}
+			if tt.code == 200 && rec.Body.String() != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, rec.Body.String())
+			}
+		})
+	}
+}
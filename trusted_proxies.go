@@ -0,0 +1,121 @@
+package quick
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// parseTrustedProxies parses Config.TrustedProxies, accepting single IPs
+// (10.0.0.1, ::1) and CIDR ranges (10.0.0.0/8). Single IPs become /32 or
+// /128 networks.
+// Method Used Internally
+// The result will parseTrustedProxies(entries []string) ([]*net.IPNet, error)
+func parseTrustedProxies(entries []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if strings.Contains(entry, "/") {
+			_, ipNet, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, fmt.Errorf("quick: invalid trusted proxy %q: %w", entry, err)
+			}
+			nets = append(nets, ipNet)
+			continue
+		}
+
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return nil, fmt.Errorf("quick: invalid trusted proxy %q: not an IP or CIDR", entry)
+		}
+		bits := 128
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 32
+		}
+		nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+	return nets, nil
+}
+
+// isTrustedProxy reports whether ip belongs to one of the trusted networks
+// Method Used Internally
+// The result will isTrustedProxy(nets []*net.IPNet, ip string) bool
+func isTrustedProxy(nets []*net.IPNet, ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, n := range nets {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// trustedProxies returns the trusted networks of the Quick instance
+// serving the request, nil outside a Quick route
+// Method Used Internally
+// The result will trustedProxies() []*net.IPNet
+func (c *Ctx) trustedProxies() []*net.IPNet {
+	cval, _ := c.matched()
+	return cval.Proxies
+}
+
+// remoteIP returns the IP of the peer connected to the server
+// Method Used Internally
+// The result will remoteIP() string
+func (c *Ctx) remoteIP() string {
+	ip, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+	if err != nil {
+		return c.Request.RemoteAddr
+	}
+	return ip
+}
+
+// ClientIP returns the IP of the client. X-Forwarded-For is only honored
+// when the request comes from an address listed in Config.TrustedProxies:
+// its entries are walked from the right, skipping trusted proxies, and the
+// first untrusted address is the client. Spoofed headers sent by other
+// addresses are ignored and the peer address is returned.
+// The result will ClientIP() string
+func (c *Ctx) ClientIP() string {
+	remote := c.remoteIP()
+	proxies := c.trustedProxies()
+	if !isTrustedProxy(proxies, remote) {
+		return remote
+	}
+
+	var hops []string
+	for _, v := range c.Request.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		client = hop
+		if !isTrustedProxy(proxies, hop) {
+			break
+		}
+	}
+	return client
+}
+
+// Protocol returns "https" or "http". X-Forwarded-Proto is only honored
+// when the request comes from an address listed in Config.TrustedProxies.
+// The result will Protocol() string
+func (c *Ctx) Protocol() string {
+	if isTrustedProxy(c.trustedProxies(), c.remoteIP()) {
+		proto, _, _ := strings.Cut(c.Request.Header.Get("X-Forwarded-Proto"), ",")
+		if proto = strings.ToLower(strings.TrimSpace(proto)); proto == "https" || proto == "http" {
+			return proto
+		}
+	}
+	if c.Request.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
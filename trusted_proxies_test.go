@@ -0,0 +1,63 @@
+package quick
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTrustedProxiesInvalid(t *testing.T) {
+	for _, entry := range []string{"10.0.0.0/33", "nope", ""} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected New to panic for %q", entry)
+				}
+			}()
+			New(Config{TrustedProxies: []string{entry}})
+		}()
+	}
+}
+
+func TestCtxClientIPAndProtocol(t *testing.T) {
+	handler := func(c *Ctx) error {
+		return c.SendString(c.ClientIP() + " " + c.Protocol())
+	}
+	trusting := New(Config{TrustedProxies: []string{"10.0.0.0/8", "127.0.0.1"}})
+	trusting.Get("/ip", handler)
+	plain := New()
+	plain.Get("/ip", handler)
+
+	tests := []struct {
+		name   string
+		q      *Quick
+		remote string
+		xff    string
+		proto  string
+		want   string
+	}{
+		{"trusted proxy", trusting, "10.1.2.3:4000", "203.0.113.5, 10.0.0.2", "https", "203.0.113.5 https"},
+		{"spoofed headers ignored", trusting, "192.0.2.9:4000", "1.2.3.4", "https", "192.0.2.9 http"},
+		{"client address spoofed left of the proxy", trusting, "127.0.0.1:4000", "1.2.3.4, 198.51.100.7", "", "198.51.100.7 http"},
+		{"only trusted hops", trusting, "127.0.0.1:4000", "10.0.0.1", "", "10.0.0.1 http"},
+		{"no forwarded header", trusting, "10.1.2.3:4000", "", "", "10.1.2.3 http"},
+		{"no trusted proxies", plain, "10.1.2.3:4000", "203.0.113.5", "https", "10.1.2.3 http"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+			req.RemoteAddr = tt.remote
+			if tt.xff != "" {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			if tt.proto != "" {
+				req.Header.Set("X-Forwarded-Proto", tt.proto)
+			}
+			rec := httptest.NewRecorder()
+			tt.q.ServeHTTP(rec, req)
+			if rec.Body.String() != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,335 @@
+package quick
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FieldError describes why a single field failed binding or validation
+type FieldError struct {
+	Field   string `json:"field"`
+	Tag     string `json:"tag,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned by Bind and BindAll when one or more fields
+// fail binding or the rules declared in their `validate` tag.
+// It serializes to {"errors":[{"field":"email","tag":"required","message":"required"}]}
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+// Error joins the field errors in a single message
+// The result will Error() string
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		parts[i] = fe.Field + ": " + fe.Message
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// add appends a field error
+// Method Used Internally
+// The result will add(field, tag, message string)
+func (e *ValidationError) add(field, tag, message string) {
+	e.Errors = append(e.Errors, FieldError{Field: field, Tag: tag, Message: message})
+}
+
+// AsValidationError reports whether err is, or wraps, a *ValidationError and returns it
+// The result will AsValidationError(err error) (*ValidationError, bool)
+func AsValidationError(err error) (*ValidationError, bool) {
+	var ve *ValidationError
+	if errors.As(err, &ve) {
+		return ve, true
+	}
+	return nil, false
+}
+
+// BodyError reports a request body that could not be decoded because it is
+// malformed, e.g. invalid JSON syntax or a value of the wrong type.
+// Handlers returning it, directly or wrapped, answer 400 Bad Request.
+type BodyError struct {
+	Format string // JSON or XML
+	Offset int64  // byte offset of the error in JSON bodies
+	Line   int    // line of the error in XML bodies
+	Err    error  // decoder error
+}
+
+// Error describes where the body is malformed, e.g. "invalid JSON at offset 1: ..."
+// The result will Error() string
+func (e *BodyError) Error() string {
+	if e.Format == "XML" {
+		return fmt.Sprintf("invalid XML at line %d: %v", e.Line, e.Err)
+	}
+	return fmt.Sprintf("invalid %s at offset %d: %v", e.Format, e.Offset, e.Err)
+}
+
+// Unwrap returns the decoder error
+// The result will Unwrap() error
+func (e *BodyError) Unwrap() error {
+	return e.Err
+}
+
+// AsBodyError reports whether err is, or wraps, a *BodyError and returns it
+// The result will AsBodyError(err error) (*BodyError, bool)
+func AsBodyError(err error) (*BodyError, bool) {
+	var be *BodyError
+	if errors.As(err, &be) {
+		return be, true
+	}
+	return nil, false
+}
+
+// malformedBodyError wraps the decoder errors caused by the client input in
+// a *BodyError; other errors are returned as they are. size is the length
+// of the body, used as the offset of truncated documents.
+// Method Used Internally
+// The result will malformedBodyError(err error, size int) error
+func malformedBodyError(err error, size int) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var xmlErr *xml.SyntaxError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return &BodyError{Format: "JSON", Offset: syntaxErr.Offset, Err: err}
+	case errors.As(err, &typeErr):
+		return &BodyError{Format: "JSON", Offset: typeErr.Offset, Err: err}
+	case errors.As(err, &xmlErr):
+		return &BodyError{Format: "XML", Line: xmlErr.Line, Err: err}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &BodyError{Format: "JSON", Offset: int64(size), Err: err}
+	}
+	return err
+}
+
+// bindDecodeError turns body type mismatches into a *ValidationError
+// naming the field; other decoding errors are returned as they are
+// Method Used Internally
+// The result will bindDecodeError(err error) error
+func bindDecodeError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		ve := &ValidationError{}
+		ve.add(typeErr.Field, "type", "must be "+typeErr.Type.String())
+		return ve
+	}
+	return err
+}
+
+// Validate checks the `validate` tags of the struct pointed to by v.
+// Rules are comma separated:
+//
+//	required    the field must not be the zero value
+//	min=n       minimum length for strings, slices and maps, minimum value for numbers
+//	max=n       maximum length for strings, slices and maps, maximum value for numbers
+//	len=n       exact length for strings, slices and maps
+//	email       the string must be an e-mail address
+//	oneof=a b   the value must be one of the space separated options
+//
+// Fields are reported by their json, form, query, param or header tag name,
+// nested structs as "parent.child". It returns nil or a *ValidationError.
+// Other rules, e.g. tags written for another validator such as gte=0 or
+// uuid, are ignored and logged once per field with slog.Default, since
+// they are a mistake in the struct rather than in the request; c.Bind and
+// c.BindAll log them with the logger of the app instead.
+// The result will Validate(v interface{}) error
+func Validate(v interface{}) error {
+	return validate(v, slog.Default())
+}
+
+// validate checks the validate tags of v, logging the rules it cannot
+// apply to logger
+// Method Used Internally
+// The result will validate(v interface{}, logger *slog.Logger) error
+func validate(v interface{}, logger *slog.Logger) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	ve := &ValidationError{}
+	validateStruct(rv, "", ve, logger)
+	if len(ve.Errors) == 0 {
+		return nil
+	}
+	return ve
+}
+
+// validateStruct walks the struct fields collecting rule violations
+// Method Used Internally
+// The result will validateStruct(rv reflect.Value, prefix string, ve *ValidationError, logger *slog.Logger)
+func validateStruct(rv reflect.Value, prefix string, ve *ValidationError, logger *slog.Logger) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		fv := rv.Field(i)
+
+		if field.Anonymous && fv.Kind() == reflect.Struct {
+			validateStruct(fv, prefix, ve, logger)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		name := prefix + fieldName(field)
+		if rules, ok := field.Tag.Lookup("validate"); ok && rules != "-" {
+			validateField(fv, name, rules, ve, ruleSite{owner: rt, field: field.Name}, logger)
+		}
+
+		// nested structs are validated after their own rules, e.g. required
+		nested := fv
+		if nested.Kind() == reflect.Ptr && !nested.IsNil() {
+			nested = nested.Elem()
+		}
+		if nested.Kind() == reflect.Struct {
+			validateStruct(nested, name+".", ve, logger)
+		}
+	}
+}
+
+// fieldName returns the name used to report a field: the first of its
+// json, form, query, param or header tags, or the Go field name
+// Method Used Internally
+// The result will fieldName(field reflect.StructField) string
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "query", "param", "header"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// validateField applies the rules of one field
+// Method Used Internally
+// The result will validateField(fv reflect.Value, name, rules string, ve *ValidationError, site ruleSite, logger *slog.Logger)
+func validateField(fv reflect.Value, name, rules string, ve *ValidationError, site ruleSite, logger *slog.Logger) {
+	// a non-nil pointer satisfies required, its value is checked by the other rules
+	isPtr := fv.Kind() == reflect.Ptr
+	if isPtr {
+		if fv.IsNil() {
+			if strings.Contains(","+rules+",", ",required,") {
+				ve.add(name, "required", "required")
+			}
+			return
+		}
+		fv = fv.Elem()
+	}
+
+	for _, rule := range strings.Split(rules, ",") {
+		tag, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch tag {
+		case "":
+			continue
+		case "required":
+			if !isPtr && fv.IsZero() {
+				ve.add(name, tag, "required")
+				return
+			}
+		case "min", "max", "len":
+			limit, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				site.report(rule, logger)
+				continue
+			}
+			if msg := checkSize(fv, tag, limit); msg != "" {
+				ve.add(name, tag, msg)
+			}
+		case "email":
+			s := fmt.Sprint(fv.Interface())
+			if s == "" {
+				continue
+			}
+			if addr, err := mail.ParseAddress(s); err != nil || addr.Address != s {
+				ve.add(name, tag, "must be a valid email address")
+			}
+		case "oneof":
+			s := fmt.Sprint(fv.Interface())
+			found := false
+			for _, opt := range strings.Fields(param) {
+				if opt == s {
+					found = true
+					break
+				}
+			}
+			if !found {
+				ve.add(name, tag, "must be one of: "+strings.Join(strings.Fields(param), ", "))
+			}
+		default:
+			site.report(rule, logger)
+		}
+	}
+}
+
+// ruleSite is the struct field a validate tag belongs to
+type ruleSite struct {
+	owner reflect.Type
+	field string
+}
+
+// reportedRules holds the ruleSite and rule pairs already logged
+var reportedRules sync.Map
+
+// report logs a rule Validate cannot apply to logger, once per field and rule
+// Method Used Internally
+// The result will report(rule string, logger *slog.Logger)
+func (s ruleSite) report(rule string, logger *slog.Logger) {
+	key := struct {
+		site ruleSite
+		rule string
+	}{s, strings.TrimSpace(rule)}
+	if _, seen := reportedRules.LoadOrStore(key, true); !seen {
+		logger.Warn("validate rule ignored", "field", s.owner.String()+"."+s.field, "rule", key.rule)
+	}
+}
+
+// checkSize evaluates min, max and len against a length or a numeric value
+// Method Used Internally
+// The result will checkSize(fv reflect.Value, tag string, limit float64) string
+func checkSize(fv reflect.Value, tag string, limit float64) string {
+	var n float64
+	unit := ""
+	switch fv.Kind() {
+	case reflect.String:
+		n, unit = float64(len([]rune(fv.String()))), " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		n, unit = float64(fv.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(fv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = float64(fv.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = fv.Float()
+	default:
+		return ""
+	}
+
+	limitStr := strconv.FormatFloat(limit, 'f', -1, 64)
+	switch {
+	case tag == "min" && n < limit:
+		return "must be at least " + limitStr + unit
+	case tag == "max" && n > limit:
+		return "must be at most " + limitStr + unit
+	case tag == "len" && n != limit:
+		return "must be exactly " + limitStr + unit
+	}
+	return ""
+}
@@ -0,0 +1,247 @@
+package quick
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type validationAddress struct {
+	City string `json:"city" validate:"required"`
+}
+
+type validationUser struct {
+	Email   string             `json:"email" validate:"required,email"`
+	Name    string             `json:"name" validate:"min=3,max=10"`
+	Age     int                `json:"age" validate:"min=18"`
+	Role    string             `json:"role" validate:"oneof=admin user"`
+	Tags    []string           `json:"tags" validate:"max=2"`
+	Address *validationAddress `json:"address" validate:"required"`
+}
+
+func TestValidate(t *testing.T) {
+	valid := validationUser{
+		Email:   "jeff@example.com",
+		Name:    "jeff",
+		Age:     30,
+		Role:    "admin",
+		Address: &validationAddress{City: "BH"},
+	}
+	if err := Validate(&valid); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	invalid := validationUser{
+		Email:   "not-an-email",
+		Name:    "jo",
+		Age:     10,
+		Role:    "root",
+		Tags:    []string{"a", "b", "c"},
+		Address: &validationAddress{},
+	}
+	ve, ok := AsValidationError(Validate(&invalid))
+	if !ok {
+		t.Fatal("expected a *ValidationError")
+	}
+
+	want := []FieldError{
+		{Field: "email", Tag: "email", Message: "must be a valid email address"},
+		{Field: "name", Tag: "min", Message: "must be at least 3 characters"},
+		{Field: "age", Tag: "min", Message: "must be at least 18"},
+		{Field: "role", Tag: "oneof", Message: "must be one of: admin, user"},
+		{Field: "tags", Tag: "max", Message: "must be at most 2 items"},
+		{Field: "address.city", Tag: "required", Message: "required"},
+	}
+	if !reflect.DeepEqual(ve.Errors, want) {
+		t.Errorf("unexpected errors:\n got %+v\nwant %+v", ve.Errors, want)
+	}
+
+	if ve, _ := AsValidationError(Validate(&validationUser{})); len(ve.Errors) != 5 {
+		t.Errorf("expected required email and address, min name and age, oneof role errors, got %+v", ve.Errors)
+	}
+}
+
+// foreignRules is tagged for another validator, e.g. go-playground/validator
+type foreignRules struct {
+	ID    string `json:"id" validate:"required,uuid"`
+	Count int    `json:"count" validate:"gte=0,min=x"`
+}
+
+func TestValidateUnknownRules(t *testing.T) {
+	var logs bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	defer slog.SetDefault(prev)
+
+	for i := 0; i < 3; i++ {
+		if err := Validate(&foreignRules{ID: "f47ac10b-58cc-4372-a567-0e02b2c3d479", Count: 5}); err != nil {
+			t.Fatalf("expected unknown rules to be ignored, got %v", err)
+		}
+	}
+	ve, ok := AsValidationError(Validate(&foreignRules{}))
+	if !ok || len(ve.Errors) != 1 || ve.Errors[0].Tag != "required" {
+		t.Errorf("expected only the known rules to apply, got %v", ve)
+	}
+
+	for _, rule := range []string{`rule=uuid`, `rule="gte=0"`, `rule="min=x"`} {
+		if n := strings.Count(logs.String(), rule+"\n"); n != 1 {
+			t.Errorf("expected %s to be logged once, got %d in %q", rule, n, logs.String())
+		}
+	}
+}
+
+// appRules is tagged with a rule Validate does not know
+type appRules struct {
+	Name string `json:"name" validate:"alpha"`
+}
+
+func TestCtxBindUnknownRulesLogger(t *testing.T) {
+	var logs, defaults bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&defaults, nil)))
+	defer slog.SetDefault(prev)
+
+	q := New(Config{MaxBodySize: 1024, Logger: slog.New(slog.NewTextHandler(&logs, nil))})
+	q.Post("/rules", func(c *Ctx) error {
+		var v appRules
+		if err := c.Bind(&v); err != nil {
+			return err
+		}
+		return c.Status(http.StatusOK).SendString(v.Name)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/rules", strings.NewReader(`{"name":"jeff"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	q.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(logs.String(), "validate rule ignored") || !strings.Contains(logs.String(), "rule=alpha") {
+		t.Errorf("expected the ignored rule in the app logs, got %q", logs.String())
+	}
+	if defaults.Len() != 0 {
+		t.Errorf("expected nothing on the default logger, got %q", defaults.String())
+	}
+}
+
+func TestCtxBindValidationError(t *testing.T) {
+	q := New()
+	q.Post("/users", func(c *Ctx) error {
+		var u validationUser
+		if err := c.Bind(&u); err != nil {
+			if ve, ok := AsValidationError(err); ok {
+				return c.Status(http.StatusUnprocessableEntity).JSON(ve)
+			}
+			return c.Status(http.StatusBadRequest).SendString(err.Error())
+		}
+		return c.Status(http.StatusOK).JSON(u)
+	})
+
+	t.Run("missing required field", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/users",
+			strings.NewReader(`{"name":"jeff","age":30,"role":"user","address":{"city":"BH"}}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		q.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("expected 422, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var body ValidationError
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if len(body.Errors) != 1 || body.Errors[0].Field != "email" || body.Errors[0].Message != "required" {
+			t.Errorf("unexpected body: %s", rec.Body.String())
+		}
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"age":"thirty"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		q.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), `"field":"age"`) {
+			t.Errorf("expected 422 naming age, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+}
+
+func TestCtxBodyParserMalformed(t *testing.T) {
+	q := New()
+	q.Post("/parse", func(c *Ctx) error {
+		var u validationUser
+		if err := c.BodyParser(&u); err != nil {
+			return err
+		}
+		return c.Status(http.StatusOK).JSON(u)
+	})
+	q.Post("/bind", func(c *Ctx) error {
+		var u validationUser
+		return c.Bind(&u)
+	})
+
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{"syntax error", "/parse", "application/json", `{bad}`, "invalid JSON at offset 2: invalid character 'b'"},
+		{"truncated", "/parse", "application/json", `{"name":`, "invalid JSON at offset 8: unexpected end of JSON input"},
+		{"wrong type", "/parse", "application/json", `{"age":"thirty"}`, "invalid JSON at offset 15: json: cannot unmarshal string"},
+		{"xml", "/parse", "application/xml", "<user>\n<name>", "invalid XML at line 2: XML syntax error"},
+		{"bind syntax error", "/bind", "application/json", `{"name":}`, "invalid JSON at offset 9: invalid character '}'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			q.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusBadRequest || !strings.HasPrefix(rec.Body.String(), tt.want) {
+				t.Errorf("expected 400 %q, got %d %q", tt.want, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	if _, ok := AsBodyError(errors.New("db down")); ok {
+		t.Error("expected other errors not to be body errors")
+	}
+}
+
+func TestCtxBindTypeMismatchDefault(t *testing.T) {
+	var logs bytes.Buffer
+	q := New(Config{MaxBodySize: 1 << 20, Logger: slog.New(slog.NewTextHandler(&logs, nil))})
+	q.Post("/users", func(c *Ctx) error {
+		var u validationUser
+		return c.Bind(&u)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"age":"x"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	q.ServeHTTP(rec, req)
+
+	want := `{"errors":[{"field":"age","tag":"type","message":"must be int"}]}`
+	if rec.Code != http.StatusUnprocessableEntity || strings.TrimSpace(rec.Body.String()) != want {
+		t.Errorf("expected 422 %s, got %d %q", want, rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("expected a JSON body, got %q", ct)
+	}
+	if strings.Contains(logs.String(), "handler error") {
+		t.Errorf("expected a client error not to be logged as a handler error, got %q", logs.String())
+	}
+}